@@ -5,7 +5,9 @@ import (
 	"errors"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -17,6 +19,9 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+// readinessInterval is the interval of the readiness checks before the app is ready.
+const readinessInterval = 500 * time.Millisecond
+
 // AppInfo is application context value.
 type AppInfo interface {
 	ID() string
@@ -26,6 +31,12 @@ type AppInfo interface {
 	Endpoint() []string
 }
 
+// Readier is the readiness status of the app, the AppInfo of the context
+// of the app implements it, e.g. FromContext(ctx) type-asserted to Readier.
+type Readier interface {
+	Ready() bool
+}
+
 // App is an application components lifecycle manager.
 type App struct {
 	opts     options
@@ -33,6 +44,15 @@ type App struct {
 	cancel   func()
 	lk       sync.Mutex
 	instance *registry.ServiceInstance
+	// heartbeat stops the heartbeat and waits for it to be stopped.
+	heartbeat func()
+	// stopping reports whether Stop has been called, the BeforeStop hooks run once.
+	stopping bool
+	// draining reports whether the app is drained, see Drain.
+	draining bool
+	ready    int32
+	// drainMu serializes Drain, Resume and Stop.
+	drainMu sync.Mutex
 }
 
 // New create an application lifecycle manager.
@@ -40,7 +60,9 @@ func New(opts ...Option) *App {
 	o := options{
 		ctx:              context.Background(),
 		logger:           log.NewHelper(log.GetLogger()),
+		rawLogger:        log.GetLogger(),
 		sigs:             []os.Signal{syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT},
+		drainSigs:        defaultDrainSignals,
 		registrarTimeout: 10 * time.Second,
 		stopTimeout:      10 * time.Second,
 	}
@@ -84,6 +106,19 @@ func (a *App) Run() error {
 	if err != nil {
 		return err
 	}
+	hctx := NewContext(a.opts.ctx, a)
+	for i, fn := range a.opts.beforeStart {
+		if err = fn(hctx); err != nil {
+			// tear down the completed ones
+			errs := append(multiError{err}, runReverse(hctx, a.opts.teardown[:i])...)
+			a.opts.logger.Errorf("failed to start app: %v", errs.err())
+			// flush the buffered logs before exiting
+			if err = log.Flush(a.opts.rawLogger); err != nil {
+				errs = append(errs, err)
+			}
+			return errs.err()
+		}
+	}
 	eg, ctx := errgroup.WithContext(NewContext(a.ctx, a))
 	wg := sync.WaitGroup{}
 	for _, srv := range a.opts.servers {
@@ -101,18 +136,13 @@ func (a *App) Run() error {
 		})
 	}
 	wg.Wait()
-	if a.opts.registrar != nil {
-		rctx, rcancel := context.WithTimeout(ctx, a.opts.registrarTimeout)
-		defer rcancel()
-		if err := a.opts.registrar.Register(rctx, instance); err != nil {
-			return err
-		}
-		a.lk.Lock()
-		a.instance = instance
-		a.lk.Unlock()
-	}
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, a.opts.sigs...)
+	d := make(chan os.Signal, 1)
+	if len(a.opts.drainSigs) > 0 {
+		signal.Notify(d, a.opts.drainSigs...)
+	}
+	defer signal.Stop(d)
 	eg.Go(func() error {
 		for {
 			select {
@@ -123,33 +153,286 @@ func (a *App) Run() error {
 					a.opts.logger.Errorf("failed to stop app: %v", err)
 					return err
 				}
+			case <-d:
+				a.toggleDrain(ctx)
 			}
 		}
 	})
-	if err := eg.Wait(); err != nil && !errors.Is(err, context.Canceled) {
-		return err
+	var errs multiError
+	// do not register nor run the AfterStart hooks if stopped before ready
+	if a.waitReady(ctx) {
+		err = a.register(ctx, instance)
+		for i := 0; err == nil && i < len(a.opts.afterStart); i++ {
+			err = a.opts.afterStart[i](hctx)
+		}
+		if err != nil {
+			// stop the servers, the AfterStop hooks tear down the started app
+			errs = append(errs, err)
+			if err = a.Stop(); err != nil {
+				errs = append(errs, err)
+			}
+		}
 	}
-	return nil
+	if err = eg.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+		errs = append(errs, err)
+	}
+	errs = append(errs, runReverse(hctx, a.opts.afterStop)...)
+	// flush the buffered logs before exiting
+	if err = log.Flush(a.opts.rawLogger); err != nil {
+		errs = append(errs, err)
+	}
+	return errs.err()
 }
 
 // Stop gracefully stops the application.
 func (a *App) Stop() error {
+	a.drainMu.Lock()
+	defer a.drainMu.Unlock()
 	a.lk.Lock()
-	instance := a.instance
+	instance, heartbeat := a.instance, a.heartbeat
+	a.heartbeat = nil
+	if a.draining {
+		// deregistered by Drain already
+		instance = nil
+	}
+	stopping := a.stopping
+	a.stopping = true
+	atomic.StoreInt32(&a.ready, 0)
 	a.lk.Unlock()
+	var errs multiError
+	if !stopping {
+		for _, fn := range a.opts.beforeStop {
+			if err := fn(NewContext(a.ctx, a)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	// stop the heartbeat not to register again after deregistering
+	if heartbeat != nil {
+		heartbeat()
+	}
 	if a.opts.registrar != nil && instance != nil {
 		ctx, cancel := context.WithTimeout(NewContext(a.ctx, a), a.opts.registrarTimeout)
 		defer cancel()
 		if err := a.opts.registrar.Deregister(ctx, instance); err != nil {
-			return err
+			return append(errs, err).err()
 		}
 	}
 	if a.cancel != nil {
 		a.cancel()
 	}
+	return errs.err()
+}
+
+// Drain takes the app out of traffic without stopping it, e.g. during the
+// rolling deploys: the instance is deregistered, Ready reports false and the
+// servers implementing transport.Drainer are drained, i.e. the gRPC health
+// checks report not serving and the HTTP servers reject the new requests
+// with 503, while the in-flight requests are served.
+// It returns an error if the app is not registered yet or fails to deregister,
+// the drain is rolled back then, and does nothing if it is drained or stopping
+// already.
+func (a *App) Drain(ctx context.Context) error {
+	a.drainMu.Lock()
+	defer a.drainMu.Unlock()
+	a.lk.Lock()
+	if a.draining || a.stopping {
+		a.lk.Unlock()
+		return nil
+	}
+	if !a.Ready() || (a.opts.registrar != nil && a.instance == nil) {
+		a.lk.Unlock()
+		return errors.New("app is not ready to drain")
+	}
+	instance, heartbeat := a.instance, a.heartbeat
+	a.heartbeat = nil
+	a.draining = true
+	atomic.StoreInt32(&a.ready, 0)
+	a.lk.Unlock()
+	for _, srv := range a.opts.servers {
+		if d, ok := srv.(transport.Drainer); ok {
+			d.Drain()
+		}
+	}
+	// stop the heartbeat not to register again after deregistering
+	if heartbeat != nil {
+		heartbeat()
+	}
+	if a.opts.registrar != nil {
+		ctx, cancel := context.WithTimeout(NewContext(ctx, a), a.opts.registrarTimeout)
+		defer cancel()
+		if err := a.opts.registrar.Deregister(ctx, instance); err != nil {
+			a.undrain(instance)
+			return err
+		}
+	}
+	a.opts.logger.Info("app drained")
 	return nil
 }
 
+// undrain rolls back a drain failed to deregister the instance, so that it is
+// still deregistered by Stop.
+func (a *App) undrain(instance *registry.ServiceInstance) {
+	for _, srv := range a.opts.servers {
+		if d, ok := srv.(transport.Drainer); ok {
+			d.Resume()
+		}
+	}
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	a.draining = false
+	if a.stopping {
+		return
+	}
+	atomic.StoreInt32(&a.ready, 1)
+	if a.opts.heartbeat > 0 {
+		a.heartbeat = a.startHeartbeat(a.ctx, instance)
+	}
+}
+
+// Resume puts the app drained by Drain back into traffic, it registers the
+// instance again and reports ready. It does nothing if the app is not drained
+// or is stopping.
+func (a *App) Resume() error {
+	a.drainMu.Lock()
+	defer a.drainMu.Unlock()
+	a.lk.Lock()
+	instance := a.instance
+	drained := a.draining && !a.stopping
+	a.lk.Unlock()
+	if !drained {
+		return nil
+	}
+	if err := a.register(a.ctx, instance); err != nil {
+		return err
+	}
+	for _, srv := range a.opts.servers {
+		if d, ok := srv.(transport.Drainer); ok {
+			d.Resume()
+		}
+	}
+	a.lk.Lock()
+	a.draining = false
+	if !a.stopping {
+		atomic.StoreInt32(&a.ready, 1)
+	}
+	a.lk.Unlock()
+	a.opts.logger.Info("app resumed")
+	return nil
+}
+
+// toggleDrain drains the app on the drain signals, or resumes it if drained.
+func (a *App) toggleDrain(ctx context.Context) {
+	a.lk.Lock()
+	draining := a.draining
+	a.lk.Unlock()
+	if draining {
+		if err := a.Resume(); err != nil {
+			a.opts.logger.Errorf("failed to resume app: %v", err)
+		}
+		return
+	}
+	if err := a.Drain(ctx); err != nil {
+		a.opts.logger.Errorf("failed to drain app: %v", err)
+	}
+}
+
+// register registers the instance and starts the heartbeat.
+func (a *App) register(ctx context.Context, instance *registry.ServiceInstance) error {
+	if a.opts.registrar == nil {
+		return nil
+	}
+	if a.opts.heartbeat > 0 {
+		instance = registry.WithHeartbeat(instance, time.Now(), a.heartbeatTTL())
+	}
+	rctx, rcancel := context.WithTimeout(ctx, a.opts.registrarTimeout)
+	defer rcancel()
+	if err := a.opts.registrar.Register(rctx, instance); err != nil {
+		return err
+	}
+	a.lk.Lock()
+	a.instance = instance
+	if a.opts.heartbeat > 0 {
+		a.heartbeat = a.startHeartbeat(ctx, instance)
+	}
+	a.lk.Unlock()
+	return nil
+}
+
+// Ready reports whether all the readiness checks have passed and the app is
+// not stopping, it is the readiness status for the health endpoints, e.g. the
+// grpc.HealthCheck func gets the app of the request context by FromContext
+// and type-asserts it to Readier.
+func (a *App) Ready() bool {
+	return atomic.LoadInt32(&a.ready) == 1
+}
+
+// waitReady waits for all the readiness checks to pass, it returns false if
+// ctx is done before.
+func (a *App) waitReady(ctx context.Context) bool {
+	ticker := time.NewTicker(readinessInterval)
+	defer ticker.Stop()
+	for !a.checkReady(ctx) {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	if a.stopping {
+		return false
+	}
+	atomic.StoreInt32(&a.ready, 1)
+	return true
+}
+
+func (a *App) checkReady(ctx context.Context) bool {
+	for _, fn := range a.opts.readiness {
+		if !fn(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *App) heartbeatTTL() time.Duration {
+	if a.opts.heartbeatTTL > 0 {
+		return a.opts.heartbeatTTL
+	}
+	return 3 * a.opts.heartbeat
+}
+
+// startHeartbeat registers the instance again every heartbeat interval until
+// ctx is done or the returned func is called.
+func (a *App) startHeartbeat(ctx context.Context, instance *registry.ServiceInstance) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(a.opts.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				rctx, rcancel := context.WithTimeout(ctx, a.opts.registrarTimeout)
+				err := a.opts.registrar.Register(rctx, registry.WithHeartbeat(instance, now, a.heartbeatTTL()))
+				rcancel()
+				if err != nil && ctx.Err() == nil {
+					a.opts.logger.Errorf("failed to send heartbeat: %v", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
 func (a *App) buildInstance() (*registry.ServiceInstance, error) {
 	endpoints := make([]string, 0, len(a.opts.endpoints))
 	for _, e := range a.opts.endpoints {
@@ -175,6 +458,42 @@ func (a *App) buildInstance() (*registry.ServiceInstance, error) {
 	}, nil
 }
 
+// runReverse runs the hooks in reverse order and returns their errors.
+func runReverse(ctx context.Context, hooks []func(context.Context) error) multiError {
+	var errs multiError
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if hooks[i] == nil {
+			continue
+		}
+		if err := hooks[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// multiError is the errors of the lifecycle of the app.
+type multiError []error
+
+func (e multiError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// err returns nil if there is no error, the error itself if there is only one.
+func (e multiError) err() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	}
+	return e
+}
+
 type appKey struct{}
 
 // NewContext returns a new Context that carries value.
@@ -182,8 +501,26 @@ func NewContext(ctx context.Context, s AppInfo) context.Context {
 	return context.WithValue(ctx, appKey{}, s)
 }
 
-// FromContext returns the Transport value stored in ctx, if any.
+// FromContext returns the Transport value stored in ctx, if any. The contexts
+// of the hooks, of the servers and of the requests they serve all carry the
+// app, e.g. to set a version header of the replies.
 func FromContext(ctx context.Context) (s AppInfo, ok bool) {
 	s, ok = ctx.Value(appKey{}).(AppInfo)
 	return
 }
+
+// Valuer returns a log.Valuer of the field of the app in the context, or empty
+// string if there is none, e.g. to tag the request logs with the service:
+//
+//	log.With(logger, "service.version", kratos.Valuer(kratos.AppInfo.Version))
+func Valuer(field func(AppInfo) string) log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if ctx == nil {
+			return ""
+		}
+		if s, ok := FromContext(ctx); ok {
+			return field(s)
+		}
+		return ""
+	}
+}
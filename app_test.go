@@ -3,14 +3,19 @@ package kratos
 import (
 	"context"
 	"fmt"
+	nethttp "net/http"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/registry"
 	"github.com/go-kratos/kratos/v2/transport/grpc"
 	"github.com/go-kratos/kratos/v2/transport/http"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type mockRegistry struct {
@@ -192,3 +197,461 @@ func TestApp_Endpoint(t *testing.T) {
 		})
 	}
 }
+
+type flushLogger struct {
+	flushed bool
+}
+
+func (l *flushLogger) Log(log.Level, ...interface{}) error { return nil }
+func (l *flushLogger) Flush() error {
+	l.flushed = true
+	return nil
+}
+
+func TestApp_FlushLogger(t *testing.T) {
+	l := &flushLogger{}
+	app := New(Name("kratos"), Logger(log.With(l, "service", "kratos")))
+	time.AfterFunc(100*time.Millisecond, func() {
+		_ = app.Stop()
+	})
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !l.flushed {
+		t.Fatal("logger should be flushed when app exits")
+	}
+}
+
+type heartbeatRegistry struct {
+	mockRegistry
+	registered int32
+}
+
+func (r *heartbeatRegistry) Register(ctx context.Context, service *registry.ServiceInstance) error {
+	atomic.AddInt32(&r.registered, 1)
+	return r.mockRegistry.Register(ctx, service)
+}
+
+func TestApp_Heartbeat(t *testing.T) {
+	r := &heartbeatRegistry{mockRegistry: mockRegistry{service: make(map[string]*registry.ServiceInstance)}}
+	app := New(
+		Name("kratos"),
+		Registrar(r),
+		RegistrarHeartbeat(20*time.Millisecond, 0),
+	)
+	time.AfterFunc(200*time.Millisecond, func() {
+		_ = app.Stop()
+	})
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&r.registered); n < 3 {
+		t.Fatalf("want the instance registered again by the heartbeat, got %d registrations", n)
+	}
+	time.Sleep(50 * time.Millisecond)
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	if len(r.service) != 0 {
+		t.Fatalf("the instance should not be registered again after stop, got %v", r.service)
+	}
+}
+
+func TestApp_Hooks(t *testing.T) {
+	var (
+		lk    sync.Mutex
+		calls []string
+	)
+	hook := func(name string, err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			if _, ok := FromContext(ctx); !ok {
+				t.Errorf("hook %s: no app in the context", name)
+			}
+			lk.Lock()
+			defer lk.Unlock()
+			calls = append(calls, name)
+			return err
+		}
+	}
+	errHook := fmt.Errorf("hook failed")
+	tests := []struct {
+		name  string
+		opts  []Option
+		err   error
+		calls []string
+	}{
+		{
+			name: "ordering",
+			opts: []Option{
+				BeforeStart(hook("before-start-1", nil)),
+				BeforeStart(hook("before-start-2", nil)),
+				AfterStart(hook("after-start-1", nil)),
+				AfterStart(hook("after-start-2", nil)),
+				BeforeStop(hook("before-stop-1", nil)),
+				BeforeStop(hook("before-stop-2", nil)),
+				AfterStop(hook("after-stop-1", nil)),
+				AfterStop(hook("after-stop-2", nil)),
+			},
+			calls: []string{
+				"before-start-1", "before-start-2",
+				"after-start-1", "after-start-2",
+				"before-stop-1", "before-stop-2",
+				"after-stop-2", "after-stop-1",
+			},
+		},
+		{
+			name: "paired",
+			opts: []Option{
+				StartStop(hook("start-1", nil), hook("stop-1", nil)),
+				AfterStop(hook("after-stop-1", nil)),
+				StartStop(hook("start-2", nil), hook("stop-2", nil)),
+			},
+			calls: []string{"start-1", "start-2", "stop-2", "after-stop-1", "stop-1"},
+		},
+		{
+			name: "before start failed",
+			opts: []Option{
+				StartStop(hook("start-1", nil), hook("stop-1", nil)),
+				BeforeStart(hook("before-start-1", nil)),
+				AfterStop(hook("after-stop-1", nil)),
+				AfterStop(hook("after-stop-2", nil)),
+				StartStop(hook("start-2", nil), hook("stop-2", nil)),
+				BeforeStart(hook("before-start-2", errHook)),
+				StartStop(hook("start-3", nil), hook("stop-3", nil)),
+				AfterStart(hook("after-start-1", nil)),
+			},
+			err:   errHook,
+			calls: []string{"start-1", "before-start-1", "start-2", "before-start-2", "stop-2", "stop-1"},
+		},
+		{
+			name: "after start failed",
+			opts: []Option{
+				AfterStart(hook("after-start-1", errHook)),
+				AfterStart(hook("after-start-2", nil)),
+				BeforeStop(hook("before-stop-1", nil)),
+				AfterStop(hook("after-stop-1", nil)),
+			},
+			err:   errHook,
+			calls: []string{"after-start-1", "before-stop-1", "after-stop-1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls = nil
+			app := New(append(test.opts, Server(http.NewServer()))...)
+			time.AfterFunc(time.Second, func() {
+				_ = app.Stop()
+			})
+			if err := app.Run(); err != test.err {
+				t.Fatalf("want %v, got %v", test.err, err)
+			}
+			lk.Lock()
+			defer lk.Unlock()
+			if !reflect.DeepEqual(calls, test.calls) {
+				t.Fatalf("want %v, got %v", test.calls, calls)
+			}
+		})
+	}
+}
+
+func TestApp_Readiness(t *testing.T) {
+	var warm int32
+	r := &mockRegistry{service: make(map[string]*registry.ServiceInstance)}
+	hs := http.NewServer()
+	hs.HandleFunc("/ready", func(w nethttp.ResponseWriter, req *nethttp.Request) {
+		app, ok := FromContext(req.Context())
+		if r, isReadier := app.(Readier); !ok || !isReadier || !r.Ready() {
+			w.WriteHeader(nethttp.StatusServiceUnavailable)
+		}
+	})
+	app := New(
+		Server(hs),
+		Registrar(r),
+		Readiness(func(context.Context) bool { return atomic.LoadInt32(&warm) == 1 }),
+	)
+	ready := func() int {
+		e, err := hs.Endpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := nethttp.Get(e.String() + "/ready")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	registered := func() bool {
+		r.lk.Lock()
+		defer r.lk.Unlock()
+		return r.service[app.ID()] != nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+	time.Sleep(time.Second)
+	if app.Ready() || registered() {
+		t.Fatal("the app is registered before ready")
+	}
+	if code := ready(); code != nethttp.StatusServiceUnavailable {
+		t.Fatalf("want 503, got %d", code)
+	}
+	atomic.StoreInt32(&warm, 1)
+	time.Sleep(time.Second)
+	if !app.Ready() || !registered() {
+		t.Fatal("the app is not registered once ready")
+	}
+	if code := ready(); code != nethttp.StatusOK {
+		t.Fatalf("want 200, got %d", code)
+	}
+	if err := app.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if app.Ready() {
+		t.Fatal("the app is ready after stopped")
+	}
+}
+
+func TestApp_RequestContext(t *testing.T) {
+	var (
+		lk   sync.Mutex
+		logs []interface{}
+	)
+	logger := log.With(loggerFunc(func(keyvals ...interface{}) {
+		lk.Lock()
+		defer lk.Unlock()
+		logs = append(logs, keyvals...)
+	}), "service.version", Valuer(AppInfo.Version))
+	hs := http.NewServer()
+	hs.HandleFunc("/version", func(w nethttp.ResponseWriter, req *nethttp.Request) {
+		if app, ok := FromContext(req.Context()); ok {
+			w.Header().Set("X-Version", app.Version())
+		}
+		_ = log.WithContext(req.Context(), logger).Log(log.LevelInfo, "msg", "version")
+	})
+	var grpcName atomic.Value
+	gs := grpc.NewServer(grpc.HealthCheck(func(ctx context.Context, _ string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		if app, ok := FromContext(ctx); ok {
+			grpcName.Store(app.Name())
+		}
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	}))
+	app := New(Name("kratos"), Version("v1.0.0"), Server(hs, gs))
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+	time.Sleep(time.Second)
+
+	e, err := hs.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := nethttp.Get(e.String() + "/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if v := resp.Header.Get("X-Version"); v != "v1.0.0" {
+		t.Fatalf("want the version header v1.0.0, got %q", v)
+	}
+	lk.Lock()
+	if !reflect.DeepEqual(logs, []interface{}{"service.version", "v1.0.0", "msg", "version"}) {
+		t.Fatalf("want the log tagged with the version, got %v", logs)
+	}
+	lk.Unlock()
+
+	e, err = gs.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.DialInsecure(context.Background(), grpc.WithEndpoint(e.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := grpcName.Load().(string); name != "kratos" {
+		t.Fatalf("want the app name kratos in the grpc request, got %q", name)
+	}
+
+	if err = app.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if v := Valuer(AppInfo.Name)(context.Background()); v != "" {
+		t.Fatalf("want empty without app, got %v", v)
+	}
+}
+
+type loggerFunc func(keyvals ...interface{})
+
+func (f loggerFunc) Log(_ log.Level, keyvals ...interface{}) error {
+	f(keyvals...)
+	return nil
+}
+
+func TestApp_Drain(t *testing.T) {
+	r := &mockRegistry{service: make(map[string]*registry.ServiceInstance)}
+	hs := http.NewServer()
+	hs.HandleFunc("/ping", func(w nethttp.ResponseWriter, req *nethttp.Request) {})
+	gs := grpc.NewServer()
+	app := New(Server(hs, gs), Registrar(r), DrainSignal())
+	if err := app.Drain(context.Background()); err == nil {
+		t.Fatal("want an error draining before the app is ready")
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+	time.Sleep(time.Second)
+
+	registered := func() bool {
+		r.lk.Lock()
+		defer r.lk.Unlock()
+		return r.service[app.ID()] != nil
+	}
+	e, err := gs.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.DialInsecure(context.Background(), grpc.WithEndpoint(e.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	health := func() grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Status
+	}
+	ping := func(status int) {
+		e, err := hs.Endpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := nethttp.Get(e.String() + "/ping")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("want the status %d, got %d", status, resp.StatusCode)
+		}
+	}
+
+	if err = app.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if app.Ready() || registered() {
+		t.Fatal("want the app not ready and deregistered once drained")
+	}
+	if s := health(); s != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("want NOT_SERVING once drained, got %v", s)
+	}
+	ping(nethttp.StatusServiceUnavailable)
+	// draining again does nothing
+	if err = app.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = app.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if !app.Ready() || !registered() {
+		t.Fatal("want the app ready and registered once resumed")
+	}
+	if s := health(); s != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("want SERVING once resumed, got %v", s)
+	}
+	ping(nethttp.StatusOK)
+
+	if err = app.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// stopping does not deregister the drained instance again
+	if err = app.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// failRegistry fails the first deregistration.
+type failRegistry struct {
+	mockRegistry
+	failed int32
+}
+
+func (r *failRegistry) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
+	if atomic.CompareAndSwapInt32(&r.failed, 0, 1) {
+		return fmt.Errorf("deregister failed")
+	}
+	return r.mockRegistry.Deregister(ctx, service)
+}
+
+func TestApp_DrainDeregisterFailed(t *testing.T) {
+	r := &failRegistry{mockRegistry: mockRegistry{service: make(map[string]*registry.ServiceInstance)}}
+	app := New(Server(http.NewServer()), Registrar(r), DrainSignal())
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+	time.Sleep(time.Second)
+	if err := app.Drain(context.Background()); err == nil {
+		t.Fatal("want the error of the deregistration")
+	}
+	if !app.Ready() {
+		t.Fatal("want the failed drain rolled back")
+	}
+	if err := app.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	if len(r.service) != 0 {
+		t.Fatalf("want the instance deregistered by Stop, got %v", r.service)
+	}
+}
+
+func TestApp_RegisterFailed(t *testing.T) {
+	var stopped int32
+	r := &mockRegistry{service: make(map[string]*registry.ServiceInstance)}
+	app := New(
+		ID(""),
+		Server(http.NewServer()),
+		Registrar(r),
+		AfterStop(func(context.Context) error {
+			atomic.AddInt32(&stopped, 1)
+			return nil
+		}),
+	)
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "no service id" {
+			t.Fatalf("want the error of the registration, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the app stopped once the registration failed")
+	}
+	if atomic.LoadInt32(&stopped) != 1 {
+		t.Fatal("want the AfterStop hooks run once the registration failed")
+	}
+}
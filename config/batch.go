@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// batchWatcher fires its observer once per merge with all the watched keys
+// that changed.
+type batchWatcher struct {
+	keys     []string
+	observer func(changes map[string]Value)
+
+	lock sync.Mutex
+	// values is the last seen value of the keys, missing keys are absent.
+	values map[string]interface{}
+}
+
+func newBatchWatcher(keys []string, o func(changes map[string]Value), r Reader) *batchWatcher {
+	w := &batchWatcher{keys: keys, observer: o}
+	w.values = w.read(r)
+	return w
+}
+
+func (w *batchWatcher) read(r Reader) map[string]interface{} {
+	values := make(map[string]interface{}, len(w.keys))
+	for _, k := range w.keys {
+		if v, ok := r.Value(k); ok {
+			values[k] = v.Load()
+		}
+	}
+	return values
+}
+
+// reset takes a new snapshot of the keys without notifying.
+func (w *batchWatcher) reset(r Reader) {
+	w.lock.Lock()
+	w.values = w.read(r)
+	w.lock.Unlock()
+}
+
+// initial takes a new snapshot of the keys and notifies all of them.
+func (w *batchWatcher) initial(r Reader) {
+	w.reset(r)
+	w.lock.Lock()
+	changes := make(map[string]Value, len(w.values))
+	for k, n := range w.values {
+		changes[k] = newValue(n)
+	}
+	w.lock.Unlock()
+	w.observer(changes)
+}
+
+// notify compares the keys with the last seen values and invokes the observer
+// once with the changed ones, the removed keys have a value of ErrNotFound.
+func (w *batchWatcher) notify(r Reader) {
+	w.lock.Lock()
+	prev := w.values
+	next := w.read(r)
+	w.values = next
+	w.lock.Unlock()
+
+	changes := make(map[string]Value)
+	for _, k := range w.keys {
+		n, ok := next[k]
+		p, had := prev[k]
+		switch {
+		case ok && (!had || !reflect.DeepEqual(p, n)):
+			changes[k] = newValue(n)
+		case !ok && had:
+			changes[k] = &errValue{err: ErrNotFound}
+		}
+	}
+	if len(changes) > 0 {
+		w.observer(changes)
+	}
+}
+
+// WatchAll registers a single observer of keys invoked once per reload with
+// all the keys that changed together, e.g. to recompute the state derived
+// from several keys. It returns ErrNotFound listing the missing keys, and
+// registers nothing then.
+func (c *config) WatchAll(keys []string, o func(changes map[string]Value)) error {
+	var missing []string
+	for _, k := range keys {
+		if c.Value(k).Load() == nil {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, strings.Join(missing, ", "))
+	}
+	w := newBatchWatcher(append([]string(nil), keys...), o, c.reader)
+	c.batches.Store(w, struct{}{})
+	if c.opts.watchInitial {
+		w.initial(c.reader)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_WatchAll(t *testing.T) {
+	src := newTestPushSource(`{"a":1,"b":"x","c":true,"other":1}`)
+	c := New(WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	err := c.WatchAll([]string{"a", "missing1", "b", "missing2"}, func(map[string]Value) {})
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "missing1, missing2") {
+		t.Fatalf("want ErrNotFound listing the missing keys, got %v", err)
+	}
+
+	batches := make(chan map[string]Value, 4)
+	if err = c.WatchAll([]string{"a", "b", "c"}, func(changes map[string]Value) { batches <- changes }); err != nil {
+		t.Fatal(err)
+	}
+	next := func() map[string]Value {
+		select {
+		case changes := <-batches:
+			return changes
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for the batch observer")
+			return nil
+		}
+	}
+
+	src.push(`{"a":2,"b":"y","c":true,"other":2}`)
+	changes := next()
+	if len(changes) != 2 {
+		t.Fatalf("want the changes of a and b at once, got %v", changes)
+	}
+	if v, _ := changes["a"].Int(); v != 2 {
+		t.Fatalf("want a=2, got %d", v)
+	}
+	if v, _ := changes["b"].String(); v != "y" {
+		t.Fatalf("want b=y, got %s", v)
+	}
+
+	src.push(`{"a":2,"b":"y","c":true,"other":3}`)
+	select {
+	case changes = <-batches:
+		t.Fatalf("want no batch without watched changes, got %v", changes)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the deleted source removes all the keys
+	src.next <- []*KeyValue{{Key: "json"}}
+	changes = next()
+	for _, k := range []string{"a", "b", "c"} {
+		if v, ok := changes[k]; !ok || !errors.Is(v.Scan(new(interface{})), ErrNotFound) {
+			t.Fatalf("want the removal of %s, got %v", k, changes)
+		}
+	}
+}
+
+func TestConfig_WatchAllNull(t *testing.T) {
+	src := newTestPushSource(`{"a":1,"b":2}`)
+	c := New(WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	batches := make(chan map[string]Value, 1)
+	if err := c.WatchAll([]string{"a", "b"}, func(changes map[string]Value) { batches <- changes }); err != nil {
+		t.Fatal(err)
+	}
+	src.push(`{"a":null,"b":2}`)
+	select {
+	case changes := <-batches:
+		if v, ok := changes["a"]; !ok || v.Load() != nil {
+			t.Fatalf("want the null a, got %v", changes)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the batch observer")
+	}
+}
+
+func TestConfig_WatchAllInitial(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(`{"a":1,"b":2}`)), WithWatchInitial())
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	var got map[string]Value
+	if err := c.WatchAll([]string{"a", "b"}, func(changes map[string]Value) { got = changes }); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want the initial values of a and b, got %v", got)
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+// Check is the dry run of Load for the deploy pipelines: it loads, merges and
+// resolves the sources of opts and validates the result against the schema
+// set by WithSchema, then discards it. It returns the first error, the
+// sources are not watched and no goroutine is started.
+func Check(opts ...Option) error {
+	return CheckScan(nil, opts...)
+}
+
+// CheckScan is Check scanning the result into v and validating v with the
+// validator set by WithValidator like LoadAndScan, if v is not nil.
+func CheckScan(v interface{}, opts ...Option) error {
+	c := New(opts...).(*config)
+	defer c.cancel()
+	for i, src := range c.opts.sources {
+		kvs, err := src.Load()
+		if err != nil {
+			return err
+		}
+		if err = c.merge(i, kvs...); err != nil {
+			return err
+		}
+	}
+	if err := c.reader.Resolve(); err != nil {
+		return err
+	}
+	if err := c.validateSchema(); err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	if err := c.Scan(v); err != nil {
+		return err
+	}
+	if c.opts.validator == nil {
+		return nil
+	}
+	if err := c.opts.validator(v); err != nil {
+		return newValidationError(err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+type testCheckSource struct {
+	testJSONSource
+	watched bool
+}
+
+func (s *testCheckSource) Watch() (Watcher, error) {
+	s.watched = true
+	return s.testJSONSource.Watch()
+}
+
+func TestCheck(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	validator := func(v interface{}) error {
+		if v.(*struct{ Port int }).Port == 0 {
+			return errInvalid
+		}
+		return nil
+	}
+	tests := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{"valid", `{"port":8000,"addr":"${host:0.0.0.0}:${port}"}`, nil},
+		{"validator", `{"port":0}`, errInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := &testCheckSource{testJSONSource: *newTestJSONSource(test.data)}
+			goroutines := runtime.NumGoroutine()
+			var v struct{ Port int }
+			err := CheckScan(&v, WithSource(src), WithValidator(validator))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("want %v, got %v", test.err, err)
+			}
+			if src.watched {
+				t.Fatal("the source should not be watched")
+			}
+			if n := runtime.NumGoroutine(); n > goroutines {
+				t.Fatalf("want no goroutine started, got %d more", n-goroutines)
+			}
+		})
+	}
+	if err := Check(WithSource(newTestJSONSource(`{`))); err == nil {
+		t.Fatal("want the decode error")
+	}
+}
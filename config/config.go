@@ -2,16 +2,20 @@ package config
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/encoding"
 	"github.com/go-kratos/kratos/v2/log"
 
 	// init encoding
 	_ "github.com/go-kratos/kratos/v2/encoding/json"
 	_ "github.com/go-kratos/kratos/v2/encoding/proto"
+	_ "github.com/go-kratos/kratos/v2/encoding/toml"
 	_ "github.com/go-kratos/kratos/v2/encoding/xml"
 	_ "github.com/go-kratos/kratos/v2/encoding/yaml"
 )
@@ -28,12 +32,29 @@ var (
 // Observer is config observer.
 type Observer func(string, Value)
 
+// ChangeObserver is config observer receiving both the previous and the
+// current value, old is nil when fired by WithWatchInitial.
+type ChangeObserver func(key string, old, new Value)
+
 // Config is a config interface.
 type Config interface {
 	Load() error
+	LoadContext(ctx context.Context) error
+	Reload() error
 	Scan(v ...interface{}) error
+	LoadAndScan(v interface{}) error
+	ScanKey(key string, v interface{}) error
 	Value(key string) Value
+	Snapshot() ReadView
+	Bytes(format string) ([]byte, error)
 	Watch(key string, o Observer) error
+	WatchPrefix(prefix string, o Observer) error
+	WatchChange(key string, o ChangeObserver) error
+	WatchAll(keys []string, o func(changes map[string]Value)) error
+	Unwatch(key string) error
+	Explain(key string) []SourceOrigin
+	Set(key string, value interface{}) error
+	Delete(key string) error
 	Close() error
 }
 
@@ -42,86 +63,393 @@ type config struct {
 	reader    Reader
 	cached    sync.Map
 	observers sync.Map
+	changes   sync.Map
+	prefixes  sync.Map
+	batches   sync.Map // *batchWatcher -> struct{}
 	watchers  []Watcher
 	log       *log.Helper
+	// lock serializes merging between watchers and Reload.
+	lock sync.Mutex
+	// scanType is the type validated on reload, set by LoadAndScan.
+	scanType reflect.Type
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 // New new a config with options.
 func New(opts ...Option) Config {
 	o := options{
-		logger:   log.GetLogger(),
-		decoder:  defaultDecoder,
-		resolver: defaultResolver,
+		logger: log.GetLogger(),
 	}
 	for _, opt := range opts {
 		opt(&o)
 	}
+	if o.decoder == nil {
+		o.decoder = newDefaultDecoder(o.delimiter())
+	}
+	if o.resolver == nil {
+		o.resolver = newDefaultResolver(o.delimiter())
+	}
+	for _, d := range o.decryptions {
+		o.postResolvers = append(o.postResolvers, newDecryptResolver(d.prefix, o.delimiter(), d.decrypt))
+	}
+	if o.filterLevel {
+		o.logger = log.NewFilter(o.logger, log.FilterLevel(o.logLevel))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return &config{
 		opts:   o,
 		reader: newReader(o),
 		log:    log.NewHelper(o.logger),
+		ctx:    ctx,
+		cancel: cancel,
 	}
 }
 
-func (c *config) watch(w Watcher) {
-	for {
+type nextResult struct {
+	kvs []*KeyValue
+	err error
+}
+
+// next waits for the next changes of w, it returns the context error
+// once the config is closed even if w.Next is still blocked, the goroutine
+// calling w.Next exits once w.Stop unblocks it, see Watcher.
+func (c *config) next(w Watcher) ([]*KeyValue, error) {
+	ch := make(chan nextResult, 1)
+	go func() {
 		kvs, err := w.Next()
+		ch <- nextResult{kvs: kvs, err: err}
+	}()
+	select {
+	case <-c.ctx.Done():
+		return nil, c.ctx.Err()
+	case r := <-ch:
+		return r.kvs, r.err
+	}
+}
+
+func (c *config) watch(i int, w Watcher) {
+	defer c.wg.Done()
+	if c.opts.reloadDebounce > 0 {
+		c.watchDebounce(i, w)
+		return
+	}
+	for {
+		kvs, err := c.next(w)
 		if errors.Is(err, context.Canceled) {
 			c.log.Infof("watcher's ctx cancel : %v", err)
 			return
 		}
 		if err != nil {
-			time.Sleep(time.Second)
 			c.log.Errorf("failed to watch next config: %v", err)
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
 			continue
 		}
-		if err := c.reader.Merge(kvs...); err != nil {
-			c.log.Errorf("failed to merge next config: %v", err)
-			continue
+		c.update(i, kvs)
+	}
+}
+
+// watchDebounce collects the changes of w for the debounce window from the
+// first one and merges them at once. w.Next is called by a goroutine of its
+// own, so that no change is lost when the window ends.
+func (c *config) watchDebounce(i int, w Watcher) {
+	results := make(chan nextResult)
+	go func() {
+		for {
+			kvs, err := w.Next()
+			select {
+			case <-c.ctx.Done():
+				return
+			case results <- nextResult{kvs: kvs, err: err}:
+			}
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			if err != nil {
+				select {
+				case <-c.ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+			}
 		}
-		if err := c.reader.Resolve(); err != nil {
-			c.log.Errorf("failed to resolve next config: %v", err)
-			continue
+	}()
+	var (
+		pending []*KeyValue
+		window  <-chan time.Time
+	)
+	for {
+		select {
+		case <-c.ctx.Done():
+			c.log.Infof("watcher's ctx cancel : %v", c.ctx.Err())
+			return
+		case r := <-results:
+			if errors.Is(r.err, context.Canceled) {
+				c.log.Infof("watcher's ctx cancel : %v", r.err)
+				return
+			}
+			if r.err != nil {
+				c.log.Errorf("failed to watch next config: %v", r.err)
+				continue
+			}
+			pending = coalesce(pending, r.kvs)
+			if window == nil {
+				window = time.After(c.opts.reloadDebounce)
+			}
+		case <-window:
+			c.update(i, pending)
+			pending, window = nil, nil
 		}
-		c.cached.Range(func(key, value interface{}) bool {
-			k := key.(string)
-			v := value.(Value)
-			if n, ok := c.reader.Value(k); ok && reflect.TypeOf(n.Load()) == reflect.TypeOf(v.Load()) && !reflect.DeepEqual(n.Load(), v.Load()) {
-				v.Store(n.Load())
-				if o, ok := c.observers.Load(k); ok {
-					o.(Observer)(k, v)
-				}
+	}
+}
+
+// coalesce returns pending with the KeyValues of the same keys as kvs replaced
+// and the others appended.
+func coalesce(pending, kvs []*KeyValue) []*KeyValue {
+next:
+	for _, kv := range kvs {
+		for i, p := range pending {
+			if p.Key == kv.Key {
+				pending[i] = kv
+				continue next
 			}
-			return true
-		})
+		}
+		pending = append(pending, kv)
+	}
+	return pending
+}
+
+// update merges the changes of the i-th source, fires the observers and
+// reports the result to the hook set by WithOnChange.
+func (c *config) update(i int, kvs []*KeyValue) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var prev map[string]interface{}
+	if c.opts.onChange != nil {
+		prev = readFlatten(c.reader, "", c.opts.delimiter())
+	}
+	err := c.apply(i, kvs)
+	if c.opts.onChange == nil {
+		return
+	}
+	evt := ChangeEvent{Source: sourceName(c.opts.sources[i]), Err: err}
+	if err == nil {
+		evt.Keys = diffKeys(prev, readFlatten(c.reader, "", c.opts.delimiter()))
+	}
+	c.opts.onChange(evt)
+}
+
+func (c *config) apply(i int, kvs []*KeyValue) error {
+	restore := c.snapshot()
+	if err := c.dropOverrides(kvs); err != nil {
+		c.log.Errorf("failed to merge next config: %v", err)
+		return err
+	}
+	if err := c.merge(i, kvs...); err != nil {
+		restore()
+		c.log.Errorf("failed to merge next config: %v", err)
+		return err
+	}
+	if err := c.reader.Resolve(); err != nil {
+		restore()
+		c.log.Errorf("failed to resolve next config: %v", err)
+		return err
+	}
+	if err := c.validate(); err != nil {
+		restore()
+		c.log.Errorf("failed to validate next config: %v", err)
+		return err
+	}
+	c.notify()
+	return nil
+}
+
+// merge merges kvs loaded from the i-th source, honoring its priority.
+func (c *config) merge(i int, kvs ...*KeyValue) error {
+	r, ok := c.reader.(*reader)
+	if !ok {
+		return c.reader.Merge(kvs...)
 	}
+	var priority int
+	if p, ok := c.opts.sources[i].(Prioritized); ok {
+		priority = p.Priority()
+	}
+	return r.mergeSource(i+1, priority, kvs...)
+}
+
+// notify refreshes the cached values from the reader and
+// fires the observers of every key that changed.
+func (c *config) notify() {
+	c.cached.Range(func(key, value interface{}) bool {
+		k := key.(string)
+		v := value.(Value)
+		if n, ok := c.reader.Value(k); ok && reflect.TypeOf(n.Load()) == reflect.TypeOf(v.Load()) && !reflect.DeepEqual(n.Load(), v.Load()) {
+			old := &atomicValue{}
+			old.Store(v.Load())
+			v.Store(n.Load())
+			if o, ok := c.observers.Load(k); ok {
+				o.(Observer)(k, v)
+			}
+			if o, ok := c.changes.Load(k); ok {
+				o.(ChangeObserver)(k, old, v)
+			}
+		}
+		return true
+	})
+	c.prefixes.Range(func(key, value interface{}) bool {
+		value.(*prefixWatcher).notify(c.reader)
+		return true
+	})
+	c.batches.Range(func(key, _ interface{}) bool {
+		key.(*batchWatcher).notify(c.reader)
+		return true
+	})
 }
 
 func (c *config) Load() error {
-	for _, src := range c.opts.sources {
-		kvs, err := src.Load()
-		if err != nil {
+	return c.LoadContext(context.Background())
+}
+
+// LoadContext loads every source like Load, it aborts with the context error
+// if ctx is done before all sources are loaded, leaving the config unchanged.
+func (c *config) LoadContext(ctx context.Context) (err error) {
+	var (
+		restore  = c.snapshot()
+		watchers []Watcher
+	)
+	defer func() {
+		if err == nil {
+			return
+		}
+		restore()
+		for _, w := range watchers {
+			_ = w.Stop()
+		}
+	}()
+	for i, src := range c.opts.sources {
+		var kvs []*KeyValue
+		if kvs, err = loadSource(ctx, src); err != nil {
 			return err
 		}
-		for _, v := range kvs {
-			c.log.Debugf("config loaded: %s format: %s", v.Key, v.Format)
+		if c.opts.verbose {
+			for _, v := range kvs {
+				c.log.Debugf("config loaded: %s format: %s", v.Key, v.Format)
+			}
 		}
-		if err = c.reader.Merge(kvs...); err != nil {
+		if err = c.merge(i, kvs...); err != nil {
 			c.log.Errorf("failed to merge config source: %v", err)
 			return err
 		}
-		w, err := src.Watch()
-		if err != nil {
+		var w Watcher
+		if c.opts.pollInterval > 0 {
+			w = newPollWatcher(src, c.opts.pollInterval, kvs)
+		} else if w, err = src.Watch(); err != nil {
 			c.log.Errorf("failed to watch config source: %v", err)
 			return err
 		}
+		watchers = append(watchers, w)
+	}
+	if err = c.reader.Resolve(); err != nil {
+		c.log.Errorf("failed to resolve config source: %v", err)
+		return err
+	}
+	if err = c.validateSchema(); err != nil {
+		c.log.Errorf("failed to validate config source: %v", err)
+		return err
+	}
+	for i, w := range watchers {
 		c.watchers = append(c.watchers, w)
-		go c.watch(w)
+		c.wg.Add(1)
+		go c.watch(i, w)
+	}
+	c.prefixes.Range(func(key, value interface{}) bool {
+		if c.opts.watchInitial {
+			value.(*prefixWatcher).initial(c.reader)
+		} else {
+			value.(*prefixWatcher).reset(c.reader)
+		}
+		return true
+	})
+	c.batches.Range(func(key, _ interface{}) bool {
+		if c.opts.watchInitial {
+			key.(*batchWatcher).initial(c.reader)
+		} else {
+			key.(*batchWatcher).reset(c.reader)
+		}
+		return true
+	})
+	if c.opts.watchInitial {
+		c.observers.Range(func(key, value interface{}) bool {
+			k := key.(string)
+			value.(Observer)(k, c.Value(k))
+			return true
+		})
+		c.changes.Range(func(key, value interface{}) bool {
+			k := key.(string)
+			value.(ChangeObserver)(k, nil, c.Value(k))
+			return true
+		})
+	}
+	return nil
+}
+
+// loadSource loads src, returning the context error if ctx is done first.
+func loadSource(ctx context.Context, src Source) ([]*KeyValue, error) {
+	type result struct {
+		kvs []*KeyValue
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		kvs, err := src.Load()
+		ch <- result{kvs: kvs, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.kvs, r.err
+	}
+}
+
+// Reload re-reads every source, merges and resolves the result, then fires
+// the observers of changed keys the same way a watcher update does.
+func (c *config) Reload() error {
+	loaded := make([][]*KeyValue, len(c.opts.sources))
+	for i, src := range c.opts.sources {
+		kvs, err := src.Load()
+		if err != nil {
+			return err
+		}
+		loaded[i] = kvs
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	restore := c.snapshot()
+	for i := range c.opts.sources {
+		if err := c.merge(i, loaded[i]...); err != nil {
+			restore()
+			c.log.Errorf("failed to merge reloaded config: %v", err)
+			return err
+		}
 	}
 	if err := c.reader.Resolve(); err != nil {
-		c.log.Errorf("failed to resolve config source: %v", err)
+		restore()
+		c.log.Errorf("failed to resolve reloaded config: %v", err)
 		return err
 	}
+	if err := c.validate(); err != nil {
+		restore()
+		c.log.Errorf("failed to validate reloaded config: %v", err)
+		return err
+	}
+	c.notify()
 	return nil
 }
 
@@ -129,10 +457,15 @@ func (c *config) Value(key string) Value {
 	if v, ok := c.cached.Load(key); ok {
 		return v.(Value)
 	}
-	if v, ok := c.reader.Value(key); ok {
+	v, ok := c.reader.Value(key)
+	if ok {
 		c.cached.Store(key, v)
 		return v
 	}
+	if v != nil {
+		// the reader reports why the lookup failed
+		return v
+	}
 	return &errValue{err: ErrNotFound}
 }
 
@@ -142,22 +475,127 @@ func (c *config) Scan(vs ...interface{}) error {
 		return err
 	}
 	for _, v := range vs {
-		if err := unmarshalJSON(data, v); err != nil {
+		if err := unmarshalJSON(data, v, c.opts.scan()); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// LoadAndScan loads the config, scans it into v and validates v
+// with the validator set by WithValidator.
+func (c *config) LoadAndScan(v interface{}) error {
+	if err := c.Load(); err != nil {
+		return err
+	}
+	if err := c.Scan(v); err != nil {
+		return err
+	}
+	if c.opts.validator == nil {
+		return nil
+	}
+	if err := c.opts.validator(v); err != nil {
+		return newValidationError(err)
+	}
+	if t := reflect.TypeOf(v); t.Kind() == reflect.Ptr {
+		c.lock.Lock()
+		c.scanType = t.Elem()
+		c.lock.Unlock()
+	}
+	return nil
+}
+
+// ScanKey decodes only the subtree at key into v.
+func (c *config) ScanKey(key string, v interface{}) error {
+	val, ok := c.reader.Value(key)
+	if !ok {
+		if ev, isErr := val.(*errValue); isErr {
+			return ev.err
+		}
+		return ErrNotFound
+	}
+	data, err := marshalJSON(convertMap(val.Load()))
+	if err != nil {
+		return err
+	}
+	return unmarshalJSON(data, v, c.opts.scan())
+}
+
+// Bytes returns the merged config encoded with the codec registered for format.
+func (c *config) Bytes(format string) ([]byte, error) {
+	codec := encoding.GetCodec(format)
+	if codec == nil {
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	data, err := c.reader.Source()
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return codec.Marshal(values)
+}
+
 func (c *config) Watch(key string, o Observer) error {
-	if v := c.Value(key); v.Load() == nil {
+	v := c.Value(key)
+	if v.Load() == nil {
 		return ErrNotFound
 	}
 	c.observers.Store(key, o)
+	if c.opts.watchInitial {
+		o(key, v)
+	}
+	return nil
+}
+
+// WatchChange registers an observer that receives the previous value
+// along with the current one whenever key changes.
+func (c *config) WatchChange(key string, o ChangeObserver) error {
+	v := c.Value(key)
+	if v.Load() == nil {
+		return ErrNotFound
+	}
+	c.changes.Store(key, o)
+	if c.opts.watchInitial {
+		o(key, nil, v)
+	}
+	return nil
+}
+
+// WatchPrefix registers an observer that fires whenever any key under prefix
+// changes, the observer receives the full key of the changed descendant.
+// Unlike Watch, the prefix does not need to exist yet.
+func (c *config) WatchPrefix(prefix string, o Observer) error {
+	w := newPrefixWatcher(prefix, c.opts.delimiter(), o, c.reader)
+	c.prefixes.Store(prefix, w)
+	if c.opts.watchInitial {
+		w.initial(c.reader)
+	}
+	return nil
+}
+
+// Unwatch removes the observers registered for key by Watch, WatchChange
+// or WatchPrefix, it returns ErrNotFound if no observer was registered.
+func (c *config) Unwatch(key string) error {
+	_, ok := c.observers.LoadAndDelete(key)
+	if _, loaded := c.changes.LoadAndDelete(key); loaded {
+		ok = true
+	}
+	if _, loaded := c.prefixes.LoadAndDelete(key); loaded {
+		ok = true
+	}
+	if !ok {
+		return ErrNotFound
+	}
 	return nil
 }
 
+// Close stops all the watchers and waits for the watch goroutines to exit.
 func (c *config) Close() error {
+	c.cancel()
+	defer c.wg.Wait()
 	for _, w := range c.watchers {
 		if err := w.Stop(); err != nil {
 			return err
@@ -1,9 +1,16 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 )
@@ -141,6 +148,7 @@ func TestConfig(t *testing.T) {
 	cf.opts = opts
 	cf.reader = newReader(opts)
 	cf.log = log.NewHelper(opts.logger)
+	cf.ctx, cf.cancel = context.WithCancel(context.Background())
 
 	err = cf.Load()
 	if err != nil {
@@ -188,3 +196,679 @@ func TestConfig(t *testing.T) {
 		t.Fatal(`len(testConf.Endpoints) is not equal to 2`)
 	}
 }
+
+type testPushSource struct {
+	data string
+	next chan []*KeyValue
+}
+
+func newTestPushSource(data string) *testPushSource {
+	return &testPushSource{data: data, next: make(chan []*KeyValue)}
+}
+
+func (p *testPushSource) Load() ([]*KeyValue, error) {
+	return []*KeyValue{{Key: "json", Value: []byte(p.data), Format: "json"}}, nil
+}
+
+func (p *testPushSource) Watch() (Watcher, error) {
+	return &testPushWatcher{next: p.next, exit: make(chan struct{})}, nil
+}
+
+func (p *testPushSource) push(data string) {
+	p.next <- []*KeyValue{{Key: "json", Value: []byte(data), Format: "json"}}
+}
+
+type testPushWatcher struct {
+	next chan []*KeyValue
+	exit chan struct{}
+}
+
+func (w *testPushWatcher) Next() ([]*KeyValue, error) {
+	select {
+	case kvs := <-w.next:
+		return kvs, nil
+	case <-w.exit:
+		return nil, context.Canceled
+	}
+}
+
+func (w *testPushWatcher) Stop() error {
+	close(w.exit)
+	return nil
+}
+
+func TestConfig_WatchPrefix(t *testing.T) {
+	src := newTestPushSource(`{"features":{"a":true,"b":{"c":1}},"other":1}`)
+	c := New(WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var (
+		lock    sync.Mutex
+		changed = make(map[string][]string)
+		done    = make(chan struct{}, 8)
+	)
+	record := func(name string) Observer {
+		return func(key string, _ Value) {
+			lock.Lock()
+			changed[name] = append(changed[name], key)
+			lock.Unlock()
+			done <- struct{}{}
+		}
+	}
+	if err := c.WatchPrefix("features", record("features")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WatchPrefix("features.b", record("features.b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WatchPrefix("missing", record("missing")); err != nil {
+		t.Fatal(err)
+	}
+
+	src.push(`{"features":{"a":true,"b":{"c":2}},"other":2,"missing":{"x":"y"}}`)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for prefix observers")
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if !reflect.DeepEqual(changed["features"], []string{"features.b.c"}) {
+		t.Fatalf("unexpected features changes: %v", changed["features"])
+	}
+	if !reflect.DeepEqual(changed["features.b"], []string{"features.b.c"}) {
+		t.Fatalf("unexpected features.b changes: %v", changed["features.b"])
+	}
+	if !reflect.DeepEqual(changed["missing"], []string{"missing.x"}) {
+		t.Fatalf("unexpected missing changes: %v", changed["missing"])
+	}
+}
+
+func TestConfig_WatchPrefixNull(t *testing.T) {
+	src := newTestPushSource(`{"features":{"x":1}}`)
+	c := New(WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	values := make(chan Value, 1)
+	if err := c.WatchPrefix("features", func(key string, v Value) {
+		if key == "features.x" {
+			values <- v
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	src.push(`{"features":{"x":null}}`)
+	select {
+	case v := <-values:
+		if v.Load() != nil {
+			t.Fatalf("want the null value, got %v", v.Load())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the null leaf")
+	}
+}
+
+func TestConfig_Unwatch(t *testing.T) {
+	src := newTestPushSource(`{"a":1,"b":{"c":1}}`)
+	c := New(WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	fired := make(chan string, 8)
+	if err := c.Watch("a", func(key string, _ Value) { fired <- key }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WatchPrefix("b", func(key string, _ Value) { fired <- key }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unwatch("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unwatch("a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	src.push(`{"a":2,"b":{"c":2}}`)
+	select {
+	case key := <-fired:
+		if key != "b.c" {
+			t.Fatalf("unexpected observer fired for key %s", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for prefix observer")
+	}
+	if err := c.Unwatch("b"); err != nil {
+		t.Fatal(err)
+	}
+
+	src.push(`{"a":3,"b":{"c":3}}`)
+	select {
+	case key := <-fired:
+		t.Fatalf("unexpected observer fired for key %s", key)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestConfig_WatchInitial(t *testing.T) {
+	c := New(WithSource(newTestPushSource(`{"a":1,"b":{"c":"x"}}`)), WithWatchInitial())
+	var prefixed []string
+	if err := c.WatchPrefix("b", func(key string, _ Value) { prefixed = append(prefixed, key) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if !reflect.DeepEqual(prefixed, []string{"b.c"}) {
+		t.Fatalf("unexpected prefix keys: %v", prefixed)
+	}
+
+	var got int64
+	if err := c.Watch("a", func(_ string, v Value) { got, _ = v.Int() }); err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Fatalf("observer was not fired with initial value, got %d", got)
+	}
+}
+
+func TestConfig_Reload(t *testing.T) {
+	src := newTestPushSource(`{"a":1}`)
+	c := New(WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var got int64
+	if err := c.Watch("a", func(_ string, v Value) { got, _ = v.Int() }); err != nil {
+		t.Fatal(err)
+	}
+	src.data = `{"a":2}`
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got != 2 {
+		t.Fatalf("observer was not fired on reload, got %d", got)
+	}
+	if v, _ := c.Value("a").Int(); v != 2 {
+		t.Fatalf("value was not reloaded, got %d", v)
+	}
+}
+
+func TestConfig_Bytes(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(`{"a":{"b":"c"}}`)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	data, err := c.Bytes("yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a:\n    b: c\n" {
+		t.Fatalf("unexpected yaml: %q", data)
+	}
+	data, err = c.Bytes("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"a":{"b":"c"}}` {
+		t.Fatalf("unexpected json: %s", data)
+	}
+	if _, err = c.Bytes("unknown"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
+
+func TestConfig_ScanKey(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(_testJSON)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var grpc struct {
+		Addr string `json:"addr"`
+		Port int    `json:"port"`
+	}
+	if err := c.ScanKey("server.grpc", &grpc); err != nil {
+		t.Fatal(err)
+	}
+	if grpc.Addr != "0.0.0.0" || grpc.Port != 10080 {
+		t.Fatalf("unexpected server.grpc: %+v", grpc)
+	}
+
+	var endpoints []string
+	if err := c.ScanKey("endpoints", &endpoints); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(endpoints, []string{"www.aaa.com", "www.bbb.org"}) {
+		t.Fatalf("unexpected endpoints: %v", endpoints)
+	}
+
+	if err := c.ScanKey("server.notexist", &grpc); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+}
+
+type testFieldError struct{ field string }
+
+func (e testFieldError) Error() string     { return "is required" }
+func (e testFieldError) Namespace() string { return e.field }
+
+func TestConfig_LoadAndScan(t *testing.T) {
+	type conf struct {
+		Port int `json:"port"`
+	}
+	validator := func(v interface{}) error {
+		if v.(*conf).Port == 0 {
+			return testFieldError{field: "conf.Port"}
+		}
+		return nil
+	}
+
+	c := New(WithSource(newTestJSONSource(`{"port":0}`)), WithValidator(validator))
+	var bad conf
+	err := c.LoadAndScan(&bad)
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expect ValidationError, got %v", err)
+	}
+	if ve.Field != "conf.Port" {
+		t.Fatalf("unexpected field: %s", ve.Field)
+	}
+	c.Close()
+
+	src := newTestPushSource(`{"port":80}`)
+	c = New(WithSource(src), WithValidator(validator))
+	var good conf
+	if err = c.LoadAndScan(&good); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if good.Port != 80 {
+		t.Fatalf("unexpected port: %d", good.Port)
+	}
+
+	src.data = `{"port":0}`
+	if err = c.Reload(); !errors.As(err, &ve) {
+		t.Fatalf("expect ValidationError, got %v", err)
+	}
+	if port, _ := c.Value("port").Int(); port != 80 {
+		t.Fatalf("rejected reload should retain the old value, got %d", port)
+	}
+}
+
+type testSlowWatcher struct {
+	delay time.Duration
+}
+
+func (w *testSlowWatcher) Next() ([]*KeyValue, error) {
+	// ignores Stop and keeps returning after delay
+	time.Sleep(w.delay)
+	return nil, nil
+}
+
+func (w *testSlowWatcher) Stop() error { return nil }
+
+type testSlowSource struct{ delay time.Duration }
+
+func (s *testSlowSource) Load() ([]*KeyValue, error) {
+	return []*KeyValue{{Key: "json", Value: []byte(`{"a":1}`), Format: "json"}}, nil
+}
+
+func (s *testSlowSource) Watch() (Watcher, error) {
+	return &testSlowWatcher{delay: s.delay}, nil
+}
+
+func TestConfig_CloseWithoutLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	c := New(WithSource(&testSlowSource{delay: 50 * time.Millisecond}))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(120 * time.Millisecond)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// wait for the pending Next to return
+	time.Sleep(100 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("goroutine leak after Close: before %d, after %d", before, after)
+	}
+}
+
+type testStopWatcher struct {
+	stop     chan struct{}
+	returned chan struct{}
+}
+
+func (w *testStopWatcher) Next() ([]*KeyValue, error) {
+	<-w.stop
+	close(w.returned)
+	return nil, context.Canceled
+}
+
+func (w *testStopWatcher) Stop() error {
+	close(w.stop)
+	return nil
+}
+
+type testStopSource struct{ w *testStopWatcher }
+
+func (s *testStopSource) Load() ([]*KeyValue, error) {
+	return []*KeyValue{{Key: "json", Value: []byte(`{"a":1}`), Format: "json"}}, nil
+}
+
+func (s *testStopSource) Watch() (Watcher, error) {
+	return s.w, nil
+}
+
+func TestConfig_CloseUnblocksNext(t *testing.T) {
+	w := &testStopWatcher{stop: make(chan struct{}), returned: make(chan struct{})}
+	c := New(WithSource(&testStopSource{w: w}))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.returned:
+	case <-time.After(time.Second):
+		t.Fatal("Close should stop the watcher blocked in Next")
+	}
+}
+
+func TestConfig_WatchChange(t *testing.T) {
+	src := newTestPushSource(`{"pool":10}`)
+	c := New(WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var oldSize, newSize int64
+	if err := c.WatchChange("pool", func(_ string, old, new Value) {
+		oldSize, _ = old.Int()
+		newSize, _ = new.Int()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	src.data = `{"pool":20}`
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if oldSize != 10 || newSize != 20 {
+		t.Fatalf("unexpected change from %d to %d", oldSize, newSize)
+	}
+	if err := c.WatchChange("notexist", func(string, Value, Value) {}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+}
+
+func TestConfig_SourcePriority(t *testing.T) {
+	var (
+		high = newTestPushSource(`{"k":"high","high":1}`)
+		low  = newTestPushSource(`{"k":"low","low":1}`)
+		mid  = newTestPushSource(`{"k":"mid","mid":1}`)
+	)
+	c := New(
+		WithSourcePriority(10, high),
+		WithSourcePriority(1, low),
+		WithSourcePriority(5, mid),
+	)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if k, _ := c.Value("k").String(); k != "high" {
+		t.Fatalf("expect k from the highest priority source, got %s", k)
+	}
+	for _, key := range []string{"high", "low", "mid"} {
+		if _, err := c.Value(key).Int(); err != nil {
+			t.Fatalf("expect %s to be merged: %v", key, err)
+		}
+	}
+
+	// a later update of a lower priority source must not clobber the key
+	low.data = `{"k":"low2","low":2}`
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if k, _ := c.Value("k").String(); k != "high" {
+		t.Fatalf("expect k from the highest priority source, got %s", k)
+	}
+	if v, _ := c.Value("low").Int(); v != 2 {
+		t.Fatalf("expect low to be updated, got %d", v)
+	}
+}
+
+func TestConfig_OnChange(t *testing.T) {
+	src := newTestPushSource(`{"a":1,"b":{"c":1,"d":1}}`)
+	events := make(chan ChangeEvent, 2)
+	c := New(WithSource(src), WithOnChange(func(evt ChangeEvent) { events <- evt }))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	src.push(`{"a":1,"b":{"c":2,"d":1},"e":1}`)
+	evt := <-events
+	if evt.Err != nil {
+		t.Fatal(evt.Err)
+	}
+	if evt.Source != "*config.testPushSource" {
+		t.Fatalf("unexpected source: %s", evt.Source)
+	}
+	if !reflect.DeepEqual(evt.Keys, []string{"b.c", "e"}) {
+		t.Fatalf("unexpected keys: %v", evt.Keys)
+	}
+
+	src.push(`{bad json`)
+	if evt = <-events; evt.Err == nil {
+		t.Fatal("expect merge error in event")
+	}
+}
+
+type testBlockingSource struct {
+	release chan struct{}
+}
+
+func (s *testBlockingSource) Load() ([]*KeyValue, error) {
+	<-s.release
+	return []*KeyValue{{Key: "blocking", Value: []byte(`{"b":1}`), Format: "json"}}, nil
+}
+
+func (s *testBlockingSource) Watch() (Watcher, error) {
+	return newTestWatcher(nil, nil), nil
+}
+
+func TestConfig_LoadContext(t *testing.T) {
+	blocking := &testBlockingSource{release: make(chan struct{})}
+	defer close(blocking.release)
+	c := New(WithSource(newTestJSONSource(`{"a":1}`), blocking))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.LoadContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect context.DeadlineExceeded, got %v", err)
+	}
+	if _, err := c.Value("a").Int(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("partially loaded config should not leak, got %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfig_ValueScan(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(`{"redis":{"addr":"127.0.0.1:6379","db":1}}`)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var redis struct {
+		Addr string `json:"addr"`
+		DB   int    `json:"db"`
+	}
+	if err := c.Value("redis").Scan(&redis); err != nil {
+		t.Fatal(err)
+	}
+	if redis.Addr != "127.0.0.1:6379" || redis.DB != 1 {
+		t.Fatalf("unexpected redis config: %+v", redis)
+	}
+	if err := c.Value("notexist").Scan(&redis); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+}
+
+func TestConfig_KeyDelimiter(t *testing.T) {
+	c := New(
+		WithSource(newTestJSONSource(`{"a":{"b":{"c":"abc"}},"hosts":{"api.example.com":{"port":443}},"ref":"${hosts/api.example.com/port}","db":{"password":"enc:secret"}}`)),
+		WithKeyDelimiter("/"),
+		WithDecryption("enc:", func(b []byte) ([]byte, error) { return []byte("plain-" + string(b)), nil }),
+	)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if v, _ := c.Value("a/b/c").String(); v != "abc" {
+		t.Fatalf("unexpected a/b/c: %s", v)
+	}
+	if v, _ := c.Value("hosts/api.example.com/port").Int(); v != 443 {
+		t.Fatalf("unexpected port: %d", v)
+	}
+	if v, _ := c.Value("ref").String(); v != "443" {
+		t.Fatalf("unexpected ref: %s", v)
+	}
+	if v, _ := c.Value("db/password").String(); v != "plain-secret" {
+		t.Fatalf("unexpected db/password: %s", v)
+	}
+	if _, err := c.Value("a.b.c").String(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+}
+
+func TestConfig_LogLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+		skip []string
+	}{
+		{"default", nil, []string{"watcher's ctx cancel"}, []string{"config loaded"}},
+		{"verbose", []Option{WithVerbose()}, []string{"config loaded", "watcher's ctx cancel"}, nil},
+		{"level", []Option{WithVerbose(), WithLogLevel(log.LevelWarn)}, nil, []string{"config loaded", "watcher's ctx cancel"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			opts := append([]Option{WithSource(newTestJSONSource(`{"a":1}`)), WithLogger(log.NewStdLogger(buf))}, test.opts...)
+			c := New(opts...)
+			if err := c.Load(); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Close(); err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range test.want {
+				if !strings.Contains(buf.String(), s) {
+					t.Fatalf("want %q logged, got %q", s, buf.String())
+				}
+			}
+			for _, s := range test.skip {
+				if strings.Contains(buf.String(), s) {
+					t.Fatalf("want %q not logged, got %q", s, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_ReloadDebounce(t *testing.T) {
+	src := newTestPushSource(`{"a":0}`)
+	events := make(chan ChangeEvent, 3)
+	c := New(
+		WithSource(src),
+		WithReloadDebounce(50*time.Millisecond),
+		WithOnChange(func(e ChangeEvent) { events <- e }),
+	)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for i := 1; i <= 3; i++ {
+		src.push(fmt.Sprintf(`{"a":%d}`, i))
+	}
+	select {
+	case e := <-events:
+		if e.Err != nil {
+			t.Fatal(e.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the changes are not merged")
+	}
+	if v, _ := c.Value("a").Int(); v != 3 {
+		t.Fatalf("want the last change, got %d", v)
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("want the changes merged once, got %+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestConfig_ScanDisallowUnknownFields(t *testing.T) {
+	type server struct {
+		Addr string `json:"addr"`
+	}
+	type conf struct {
+		Server server `json:"server"`
+	}
+	source := newTestJSONSource(`{"server":{"addr":":8000","timout":"1s"}}`)
+
+	c := New(WithSource(source))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	var lenient conf
+	if err := c.Scan(&lenient); err != nil || lenient.Server.Addr != ":8000" {
+		t.Fatalf("want the unknown keys ignored by default, got %+v, %v", lenient, err)
+	}
+
+	c = New(WithSource(source), WithScanDisallowUnknownFields())
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	var strict conf
+	if err := c.Scan(&strict); err == nil || !strings.Contains(err.Error(), `"timout"`) {
+		t.Fatalf("want the error naming the unknown key, got %v", err)
+	}
+	var s server
+	if err := c.ScanKey("server", &s); err == nil || !strings.Contains(err.Error(), `"timout"`) {
+		t.Fatalf("want the error of ScanKey naming the unknown key, got %v", err)
+	}
+	if err := c.Snapshot().Scan(&strict); err == nil {
+		t.Fatal("want the error of the snapshot scan")
+	}
+	var m map[string]interface{}
+	if err := c.Scan(&m); err != nil {
+		t.Fatalf("want the maps scanned, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// redacted is the value logged in place of the redacted values.
+const redacted = "***"
+
+// origin is the KeyValue of a source which set the value of a key.
+type origin struct {
+	source int
+	key    string
+}
+
+// conflict is a key set by a KeyValue overridden by another one.
+type conflict struct {
+	key        string
+	prev, next origin
+	prevValue  interface{}
+	nextValue  interface{}
+}
+
+// conflicts tracks the origins of the keys merged, to report the keys whose
+// values are overridden by the KeyValues of another source or key.
+type conflicts struct {
+	origins map[string]origin
+	found   []conflict
+}
+
+func newConflicts(origins map[string]origin) *conflicts {
+	c := &conflicts{origins: make(map[string]origin, len(origins))}
+	for k, o := range origins {
+		c.origins[k] = o
+	}
+	return c
+}
+
+// track records the values of next merged into merged from the KeyValue o,
+// the appended slices of the merge strategies other than replace do not
+// override their values.
+func (c *conflicts) track(merged, next map[string]interface{}, o origin, delimiter string, s MergeStrategy) {
+	prev := make(map[string]interface{})
+	flatten("", delimiter, merged, prev)
+	values := make(map[string]interface{})
+	flatten("", delimiter, next, values)
+	for k, v := range values {
+		if p, ok := c.origins[k]; ok && p != o {
+			old := prev[k]
+			_, oldSlice := old.([]interface{})
+			_, newSlice := v.([]interface{})
+			if !reflect.DeepEqual(old, v) && (s == MergeReplace || !oldSlice || !newSlice) {
+				c.found = append(c.found, conflict{key: k, prev: p, next: o, prevValue: old, nextValue: v})
+			}
+		}
+		c.origins[k] = o
+	}
+}
+
+// unlogged returns the conflicts of the keys not logged yet sorted by key,
+// marking them logged, it is called with the lock held.
+func (r *reader) unlogged(found []conflict) []conflict {
+	if r.conflicted == nil {
+		r.conflicted = make(map[string]struct{})
+	}
+	var res []conflict
+	for _, c := range found {
+		if _, ok := r.conflicted[c.key]; ok {
+			continue
+		}
+		r.conflicted[c.key] = struct{}{}
+		res = append(res, c)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].key < res[j].key })
+	return res
+}
+
+// logConflicts logs the conflicts at the warn level.
+func (r *reader) logConflicts(found []conflict) {
+	if len(found) == 0 {
+		return
+	}
+	helper := log.NewHelper(r.opts.logger)
+	for _, c := range found {
+		prev, next := c.prevValue, c.nextValue
+		if r.opts.redactConflict != nil && r.opts.redactConflict(c.key) {
+			prev, next = redacted, redacted
+		}
+		helper.Warnf("config key %s of %s (source %d) = %v is overridden by %s (source %d) = %v",
+			c.key, c.prev.key, c.prev.source, prev, c.next.key, c.next.source, next)
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+func TestConfig_ConflictLogging(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+		skip []string
+	}{
+		{"default", nil, nil, []string{"overridden"}},
+		{
+			"conflicts",
+			[]Option{WithConflictLogging()},
+			[]string{
+				"WARN msg=config key a.port of json (source 1) = 8080 is overridden by json (source 2) = 9090",
+				"config key a.password of json (source 1) = secret is overridden by json (source 2) = changed",
+			},
+			[]string{"a.name", "a.same"},
+		},
+		{
+			"redacted",
+			[]Option{WithConflictLogging(), WithConflictRedaction(func(key string) bool {
+				return strings.HasSuffix(key, "password")
+			})},
+			[]string{"config key a.password of json (source 1) = *** is overridden by json (source 2) = ***"},
+			[]string{"secret", "changed"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			src := newTestPushSource(`{"a":{"port":9090,"password":"changed","same":1}}`)
+			opts := append([]Option{
+				WithSource(
+					newTestJSONSource(`{"a":{"port":8080,"password":"secret","name":"kratos","same":1}}`),
+					src,
+				),
+				WithLogger(log.NewStdLogger(buf)),
+			}, test.opts...)
+			c := New(opts...)
+			if err := c.Load(); err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			if port, err := c.Value("a.port").Int(); err != nil || port != 9090 {
+				t.Fatalf("want the port overridden 9090, got %d, %v", port, err)
+			}
+			for _, s := range test.want {
+				if !strings.Contains(buf.String(), s) {
+					t.Fatalf("want %q logged, got %q", s, buf.String())
+				}
+			}
+			for _, s := range test.skip {
+				if strings.Contains(buf.String(), s) {
+					t.Fatalf("want %q not logged, got %q", s, buf.String())
+				}
+			}
+			// the conflict is found again once the key is deleted and set again
+			src.next <- []*KeyValue{{Key: "json"}}
+			src.push(`{"a":{"port":7070}}`)
+			time.Sleep(100 * time.Millisecond)
+			if port, err := c.Value("a.port").Int(); err != nil || port != 7070 {
+				t.Fatalf("want the port overridden 7070, got %d, %v", port, err)
+			}
+			if n := strings.Count(buf.String(), "a.port"); test.want != nil && n != 1 {
+				t.Fatalf("want the conflict of a key logged once, got %d", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	// defaultTypes caches whether the types have fields of default tags.
+	defaultTypes sync.Map
+)
+
+// setDefaults sets the zero fields of the struct v absent from data, the
+// json it is decoded from, to the values of their default tags, e.g.
+//
+//	Timeout time.Duration `json:"timeout" default:"1s"`
+//	Hosts   []string      `json:"hosts" default:"a,b"`
+//
+// so that the keys set to zero in the config still win. The elements of
+// slices are comma separated, and the pointers and the nested structs absent
+// from data are allocated if they have fields of default tags.
+func setDefaults(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || !hasDefaults(rv.Type()) {
+		return nil
+	}
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return applyDefaults(rv.Elem(), raw)
+}
+
+func applyDefaults(v reflect.Value, raw interface{}) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			if raw != nil || !hasDefaults(v.Type().Elem()) {
+				return nil
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return applyDefaults(v.Elem(), raw)
+	case reflect.Struct:
+		m, _ := raw.(map[string]interface{})
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			def, hasDef := f.Tag.Lookup("default")
+			if f.PkgPath != "" || !hasDef && !hasDefaults(f.Type) {
+				continue
+			}
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name := strings.Split(tag, ",")[0]
+			// the fields of the embedded structs are the ones of the parent
+			if f.Anonymous && name == "" {
+				if err := applyDefaults(v.Field(i), raw); err != nil {
+					return err
+				}
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			value, ok := lookupKey(m, name)
+			if hasDef && !ok {
+				if v.Field(i).IsZero() {
+					if err := setDefault(v.Field(i), def); err != nil {
+						return fmt.Errorf("config: invalid default of field %s: %w", f.Name, err)
+					}
+				}
+				continue
+			}
+			if err := applyDefaults(v.Field(i), value); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		s, _ := raw.([]interface{})
+		for i := 0; i < v.Len() && i < len(s); i++ {
+			if err := applyDefaults(v.Index(i), s[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setDefault sets v to the value s parsed per its type.
+func setDefault(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := setDefault(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var elems []string
+		if s != "" {
+			elems = strings.Split(s, ",")
+		}
+		slice := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+		for i, e := range elems {
+			if err := setDefault(slice.Index(i), strings.TrimSpace(e)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// hasDefaults reports whether t has fields of default tags, nested or not.
+func hasDefaults(t reflect.Type) bool {
+	if has, ok := defaultTypes.Load(t); ok {
+		return has.(bool)
+	}
+	has := typeHasDefaults(t, make(map[reflect.Type]bool))
+	defaultTypes.Store(t, has)
+	return has
+}
+
+// typeHasDefaults walks t, the types already visited are the recursive ones
+// whose fields are walked by the first visit.
+func typeHasDefaults(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return typeHasDefaults(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if _, ok := f.Tag.Lookup("default"); ok || typeHasDefaults(f.Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
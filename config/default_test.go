@@ -0,0 +1,63 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testDefaultServer struct {
+	Addr    string        `json:"addr" default:"0.0.0.0:8000"`
+	Timeout time.Duration `json:"timeout" default:"1s"`
+}
+
+type testDefaultConfig struct {
+	Name    string             `json:"name" default:"kratos"`
+	Debug   bool               `json:"debug" default:"true"`
+	Hosts   []string           `json:"hosts" default:"a, b"`
+	Ratio   *float64           `json:"ratio" default:"0.5"`
+	HTTP    testDefaultServer  `json:"http"`
+	GRPC    *testDefaultServer `json:"grpc"`
+	Servers []testDefaultServer
+}
+
+func TestScanDefaults(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(`{
+		"debug": false,
+		"http": {"addr": ":80"},
+		"servers": [{"timeout": 2000000000}]
+	}`)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	var v testDefaultConfig
+	if err := c.Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+	ratio := 0.5
+	want := testDefaultConfig{
+		Name:    "kratos",
+		Debug:   false,
+		Hosts:   []string{"a", "b"},
+		Ratio:   &ratio,
+		HTTP:    testDefaultServer{Addr: ":80", Timeout: time.Second},
+		GRPC:    &testDefaultServer{Addr: "0.0.0.0:8000", Timeout: time.Second},
+		Servers: []testDefaultServer{{Addr: "0.0.0.0:8000", Timeout: 2 * time.Second}},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("want %+v, got %+v", want, v)
+	}
+}
+
+func TestScanDefaultsInvalid(t *testing.T) {
+	var v struct {
+		Port int `json:"port" default:"http"`
+	}
+	c := New(WithSource(newTestJSONSource(`{}`)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Scan(&v); err == nil {
+		t.Fatal("want the invalid default error")
+	}
+}
@@ -7,14 +7,41 @@ import (
 	"github.com/go-kratos/kratos/v2/config"
 )
 
+// Option is env source option.
+type Option func(*env)
+
+// WithKeyTransform with the function mapping a prefix-trimmed
+// variable name to the config key, e.g. SERVER_HTTP_ADDR to server.http.addr.
+func WithKeyTransform(f func(string) string) Option {
+	return func(e *env) {
+		e.transform = f
+	}
+}
+
 type env struct {
-	prefixs []string
+	prefixs   []string
+	transform func(string) string
 }
 
 func NewSource(prefixs ...string) config.Source {
 	return &env{prefixs: prefixs}
 }
 
+// NewNestedSource new an env source that maps variables like APP_SERVER_HTTP_ADDR
+// with prefix APP to nested config keys like server.http.addr.
+func NewNestedSource(prefix string, opts ...Option) config.Source {
+	e := &env{prefixs: []string{prefix}, transform: NestedKey}
+	for _, o := range opts {
+		o(e)
+	}
+	return e
+}
+
+// NestedKey lowercases the variable name and replaces "_" with ".".
+func NestedKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", ".")
+}
+
 func (e *env) Load() (kv []*config.KeyValue, err error) {
 	return e.load(os.Environ()), nil
 }
@@ -38,6 +65,9 @@ func (e *env) load(envStrings []string) []*config.KeyValue {
 			k = strings.TrimPrefix(k, p)
 			k = strings.TrimPrefix(k, "_")
 		}
+		if e.transform != nil {
+			k = e.transform(k)
+		}
 
 		if len(k) != 0 {
 			kv = append(kv, &config.KeyValue{
@@ -387,6 +387,47 @@ func Test_env_load(t *testing.T) {
 	}
 }
 
+func TestNestedSource(t *testing.T) {
+	var (
+		path     = filepath.Join(t.TempDir(), "test_config")
+		filename = filepath.Join(path, "test.json")
+		data     = []byte(`{"server":{"http":{"addr":"127.0.0.1","port":"8000"}}}`)
+	)
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, data, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("NESTED_SERVER_HTTP_ADDR", "0.0.0.0")
+	defer os.Unsetenv("NESTED_SERVER_HTTP_ADDR")
+
+	c := config.New(config.WithSource(
+		file.NewSource(path),
+		NewNestedSource("NESTED"),
+	))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if addr, _ := c.Value("server.http.addr").String(); addr != "0.0.0.0" {
+		t.Fatalf("server.http.addr is not overridden by env, got %s", addr)
+	}
+	if port, _ := c.Value("server.http.port").String(); port != "8000" {
+		t.Fatalf("server.http.port is not kept from file, got %s", port)
+	}
+}
+
+func Test_NestedKey(t *testing.T) {
+	e := &env{prefixs: []string{"APP"}, transform: NestedKey}
+	got := e.load([]string{"APP_SERVER_HTTP_ADDR=0.0.0.0", "OTHER=1"})
+	want := []*config.KeyValue{{Key: "server.http.addr", Value: []byte("0.0.0.0")}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("env.load() = %v, want %v", got, want)
+	}
+}
+
 func Test_matchPrefix(t *testing.T) {
 	type args struct {
 		prefixes []string
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// SourceOrigin is a source setting a key, see Config.Explain.
+type SourceOrigin struct {
+	// Source is the name of the source, see ChangeEvent.Source.
+	Source string
+	// Key is the key of the KeyValue of the source setting the key,
+	// e.g. the file name.
+	Key      string
+	Priority int
+	// Value is the value set by the source before merging.
+	Value interface{}
+	// Winner reports whether the merged value is the one of the source.
+	Winner bool
+	// Raw and Resolved are the merged value before and after the
+	// placeholders are resolved, they are only set on the winner.
+	Raw      interface{}
+	Resolved interface{}
+}
+
+// Explain returns the sources setting key in merge order, with the winner
+// holding the merged value. It decodes the latest KeyValues the reader keeps
+// for every source again, so it costs nothing until called, it is meant for
+// debugging rather than hot paths.
+func (c *config) Explain(key string) []SourceOrigin {
+	r, ok := c.reader.(*reader)
+	if !ok {
+		return nil
+	}
+	return r.explain(key, func(source int) string {
+		switch source {
+		case 0:
+			return "merge"
+		case overrideSource:
+			return "set"
+		}
+		return sourceName(c.opts.sources[source-1])
+	})
+}
+
+func (r *reader) explain(key string, name func(source int) string) []SourceOrigin {
+	delimiter := r.opts.delimiter()
+	r.lock.Lock()
+	entries := make([]*entry, len(r.entries))
+	copy(entries, r.entries)
+	raw, rawOK := readValue(r.raw, key, delimiter)
+	resolved, resolvedOK := readValue(r.values, key, delimiter)
+	r.lock.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].priority < entries[j].priority
+	})
+	var origins []SourceOrigin
+	for _, e := range entries {
+		next := make(map[string]interface{})
+		if err := r.opts.decoder(e.kv, next); err != nil {
+			continue
+		}
+		v, ok := readValue(convertMap(next).(map[string]interface{}), key, delimiter)
+		if !ok {
+			continue
+		}
+		origins = append(origins, SourceOrigin{
+			Source:   name(e.source),
+			Key:      e.kv.Key,
+			Priority: e.priority,
+			Value:    v.Load(),
+		})
+	}
+	if len(origins) == 0 || !rawOK {
+		return origins
+	}
+	// the incremental merges apply the changed KeyValues last, so the winner
+	// is the last source of the merged value, or the last one if the value
+	// is merged from several of them, e.g. by MergeAppend.
+	winner := len(origins) - 1
+	for i := winner; i >= 0; i-- {
+		if reflect.DeepEqual(origins[i].Value, raw.Load()) {
+			winner = i
+			break
+		}
+	}
+	origins[winner].Winner = true
+	origins[winner].Raw = raw.Load()
+	if resolvedOK {
+		origins[winner].Resolved = resolved.Load()
+	}
+	return origins
+}
@@ -0,0 +1,50 @@
+package config
+
+import "testing"
+
+func TestConfig_Explain(t *testing.T) {
+	var (
+		high = newTestPushSource(`{"addr":"${host}:9000"}`)
+		low  = newTestPushSource(`{"addr":"0.0.0.0:8000","host":"127.0.0.1"}`)
+	)
+	c := New(
+		WithSourcePriority(10, high),
+		WithSourcePriority(1, low),
+	)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	origins := c.Explain("addr")
+	if len(origins) != 2 {
+		t.Fatalf("want 2 sources, got %+v", origins)
+	}
+	if o := origins[0]; o.Priority != 1 || o.Value != "0.0.0.0:8000" || o.Winner {
+		t.Fatalf("want the low priority source first, got %+v", o)
+	}
+	o := origins[1]
+	if o.Priority != 10 || !o.Winner || o.Source != "*config.testPushSource" || o.Key != "json" {
+		t.Fatalf("want the high priority source winning, got %+v", o)
+	}
+	if o.Raw != "${host}:9000" || o.Resolved != "127.0.0.1:9000" {
+		t.Fatalf("want the value before and after resolving, got %v and %v", o.Raw, o.Resolved)
+	}
+
+	// an incremental merge applies the changed source last
+	c = New(WithSource(high, low))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.(*config).apply(0, []*KeyValue{{Key: "json", Value: []byte(`{"addr":"1.1.1.1:9000"}`), Format: "json"}}); err != nil {
+		t.Fatal(err)
+	}
+	origins = c.Explain("addr")
+	if len(origins) != 2 || !origins[0].Winner || origins[0].Resolved != "1.1.1.1:9000" {
+		t.Fatalf("want the changed source winning, got %+v", origins)
+	}
+	if origins := c.Explain("none"); len(origins) != 0 {
+		t.Fatalf("want no source, got %+v", origins)
+	}
+}
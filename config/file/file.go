@@ -2,22 +2,51 @@ package file
 
 import (
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/encoding"
 )
 
 var _ config.Source = (*file)(nil)
 
+// Option is file source option.
+type Option func(*file)
+
+// WithRecursive with subdirectories of a directory source loaded and watched
+// recursively, only files of a registered encoding format are loaded and
+// every KeyValue is keyed by its path relative to the source directory.
+func WithRecursive() Option {
+	return func(f *file) {
+		f.recursive = true
+	}
+}
+
+// WithPathPrefix with the content of files in subdirectories nested under
+// the relative directory, e.g. regions/eu.yaml is loaded under "regions".
+// It only applies together with WithRecursive.
+func WithPathPrefix() Option {
+	return func(f *file) {
+		f.pathPrefix = true
+	}
+}
+
 type file struct {
-	path string
+	path       string
+	recursive  bool
+	pathPrefix bool
 }
 
 // NewSource new a file source.
-func NewSource(path string) config.Source {
-	return &file{path: path}
+func NewSource(path string, opts ...Option) config.Source {
+	f := &file{path: path}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
 }
 
 func (f *file) loadFile(path string) (*config.KeyValue, error) {
@@ -42,6 +71,9 @@ func (f *file) loadFile(path string) (*config.KeyValue, error) {
 }
 
 func (f *file) loadDir(path string) (kvs []*config.KeyValue, err error) {
+	if f.recursive {
+		return f.walkDir(path)
+	}
 	files, err := os.ReadDir(f.path)
 	if err != nil {
 		return nil, err
@@ -60,6 +92,53 @@ func (f *file) loadDir(path string) (kvs []*config.KeyValue, err error) {
 	return
 }
 
+// walkDir loads every supported file under path recursively.
+func (f *file) walkDir(path string) (kvs []*config.KeyValue, err error) {
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// ignore hidden files and directories
+		if p != path && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !supported(p) {
+			return nil
+		}
+		kv, err := f.loadRelFile(p)
+		if err != nil {
+			return err
+		}
+		kvs = append(kvs, kv)
+		return nil
+	})
+	return
+}
+
+// loadRelFile loads the file at path keyed by its path relative to the
+// source directory, nesting its content under the relative directory if
+// WithPathPrefix is set.
+func (f *file) loadRelFile(path string) (*config.KeyValue, error) {
+	kv, err := f.loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(f.path, path)
+	if err != nil {
+		return nil, err
+	}
+	kv.Key = filepath.ToSlash(rel)
+	if dir := filepath.Dir(rel); f.pathPrefix && dir != "." {
+		if kv.Value, err = nest(kv, strings.Split(filepath.ToSlash(dir), "/")); err != nil {
+			return nil, err
+		}
+	}
+	return kv, nil
+}
+
 func (f *file) Load() (kvs []*config.KeyValue, err error) {
 	fi, err := os.Stat(f.path)
 	if err != nil {
@@ -78,3 +157,20 @@ func (f *file) Load() (kvs []*config.KeyValue, err error) {
 func (f *file) Watch() (config.Watcher, error) {
 	return newWatcher(f)
 }
+
+func supported(path string) bool {
+	return encoding.GetCodec(format(filepath.Base(path))) != nil
+}
+
+// nest re-encodes the content of kv nested under the given keys.
+func nest(kv *config.KeyValue, keys []string) ([]byte, error) {
+	codec := encoding.GetCodec(kv.Format)
+	var value interface{}
+	if err := codec.Unmarshal(kv.Value, &value); err != nil {
+		return nil, err
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = map[string]interface{}{keys[i]: value}
+	}
+	return codec.Marshal(value)
+}
@@ -339,3 +339,67 @@ func TestMergeDataRace(t *testing.T) {
 	close(startCh)
 	wg.Wait()
 }
+
+func TestRecursiveDir(t *testing.T) {
+	var (
+		path    = t.TempDir()
+		regions = filepath.Join(path, "regions")
+	)
+	if err := os.MkdirAll(regions, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "app.json"), []byte(`{"name":"app"}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(regions, "us.yaml"), []byte("us:\n  addr: us.example.com\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(regions, "README"), []byte("ignored"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config.New(config.WithSource(NewSource(path, WithRecursive(), WithPathPrefix())))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if name, _ := c.Value("name").String(); name != "app" {
+		t.Fatalf("unexpected name: %s", name)
+	}
+	if addr, _ := c.Value("regions.us.addr").String(); addr != "us.example.com" {
+		t.Fatalf("unexpected regions.us.addr: %s", addr)
+	}
+
+	waitFor := func(cond func() bool) {
+		for i := 0; i < 100; i++ {
+			if cond() {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatal("timeout waiting for config change")
+	}
+
+	if err := os.WriteFile(filepath.Join(regions, "eu.json"), []byte(`{"eu":{"addr":"eu.example.com"}}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool {
+		addr, _ := c.Value("regions.eu.addr").String()
+		return addr == "eu.example.com"
+	})
+
+	if err := os.Remove(filepath.Join(regions, "us.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool {
+		var conf struct {
+			Regions map[string]interface{} `json:"regions"`
+		}
+		if err := c.Scan(&conf); err != nil {
+			t.Fatal(err)
+		}
+		_, ok := conf.Regions["us"]
+		return !ok
+	})
+}
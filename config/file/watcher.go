@@ -2,8 +2,10 @@ package file
 
 import (
 	"context"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/go-kratos/kratos/v2/config"
@@ -12,6 +14,9 @@ import (
 type watcher struct {
 	f  *file
 	fw *fsnotify.Watcher
+	// known holds the relative keys loaded in recursive mode,
+	// used to delete the keys of removed files.
+	known map[string]struct{}
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -28,10 +33,21 @@ func newWatcher(f *file) (config.Watcher, error) {
 		return nil, err
 	}
 	ctx, cancel := context.WithCancel(context.Background())
-	return &watcher{f: f, fw: fw, ctx: ctx, cancel: cancel}, nil
+	w := &watcher{f: f, fw: fw, ctx: ctx, cancel: cancel}
+	if fi, err := os.Stat(f.path); err == nil && fi.IsDir() && f.recursive {
+		w.known = make(map[string]struct{})
+		if _, err := w.addDir(f.path); err != nil {
+			fw.Close()
+			return nil, err
+		}
+	}
+	return w, nil
 }
 
 func (w *watcher) Next() ([]*config.KeyValue, error) {
+	if w.known != nil {
+		return w.nextRecursive()
+	}
 	select {
 	case <-w.ctx.Done():
 		return nil, w.ctx.Err()
@@ -61,6 +77,100 @@ func (w *watcher) Next() ([]*config.KeyValue, error) {
 	}
 }
 
+// nextRecursive waits for a change under the source directory, it loads
+// created or written files, watches and loads created directories and
+// deletes the keys of removed files and directories.
+func (w *watcher) nextRecursive() ([]*config.KeyValue, error) {
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case err := <-w.fw.Errors:
+			return nil, err
+		case event := <-w.fw.Events:
+			if strings.HasPrefix(filepath.Base(event.Name), ".") {
+				continue
+			}
+			fi, err := os.Stat(event.Name)
+			if os.IsNotExist(err) {
+				if kvs := w.deleted(event.Name); len(kvs) > 0 {
+					return kvs, nil
+				}
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if fi.IsDir() {
+				if event.Op&fsnotify.Create == 0 {
+					continue
+				}
+				kvs, err := w.addDir(event.Name)
+				if err != nil {
+					return nil, err
+				}
+				if len(kvs) > 0 {
+					return kvs, nil
+				}
+				continue
+			}
+			if !supported(event.Name) {
+				continue
+			}
+			kv, err := w.f.loadRelFile(event.Name)
+			if err != nil {
+				return nil, err
+			}
+			w.known[kv.Key] = struct{}{}
+			return []*config.KeyValue{kv}, nil
+		}
+	}
+}
+
+// addDir watches path and its subdirectories, returning the files found.
+func (w *watcher) addDir(path string) ([]*config.KeyValue, error) {
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != w.f.path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return w.fw.Add(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	kvs, err := w.f.walkDir(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range kvs {
+		w.known[kv.Key] = struct{}{}
+	}
+	return kvs, nil
+}
+
+// deleted returns the deletion KeyValues of the file or directory at path.
+func (w *watcher) deleted(path string) []*config.KeyValue {
+	rel, err := filepath.Rel(w.f.path, path)
+	if err != nil {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+	var kvs []*config.KeyValue
+	for key := range w.known {
+		if key == rel || strings.HasPrefix(key, rel+"/") {
+			delete(w.known, key)
+			kvs = append(kvs, &config.KeyValue{Key: key})
+		}
+	}
+	return kvs
+}
+
 func (w *watcher) Stop() error {
 	w.cancel()
 	return w.fw.Close()
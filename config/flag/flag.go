@@ -0,0 +1,94 @@
+package flag
+
+import (
+	"encoding/json"
+	"flag"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+// priority is higher than the ones of the other sources, only the keys
+// overridden by Config.Set win.
+const priority = math.MaxInt32 - 1
+
+var (
+	_ config.Source      = (*source)(nil)
+	_ config.Prioritized = (*source)(nil)
+)
+
+type source struct {
+	fs *flag.FlagSet
+}
+
+// NewSource new a source of the flags of fs set on the command line, nil is
+// flag.CommandLine, e.g. -server.http.addr=:8080 is the key server.http.addr.
+// fs must be parsed before the config is loaded, the flags left to their
+// defaults are not loaded, so that they do not override the other sources.
+// The values of the flags of flag.Getter are typed, e.g. int or bool, the
+// durations are strings like "1s".
+func NewSource(fs *flag.FlagSet) config.Source {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	return &source{fs: fs}
+}
+
+func (s *source) Load() ([]*config.KeyValue, error) {
+	values := make(map[string]interface{})
+	s.fs.Visit(func(f *flag.Flag) {
+		set(values, strings.Split(f.Name, "."), value(f))
+	})
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return []*config.KeyValue{{
+		Key:    "flag",
+		Value:  data,
+		Format: "json",
+	}}, nil
+}
+
+func (s *source) Watch() (config.Watcher, error) {
+	return newWatcher(), nil
+}
+
+func (s *source) Priority() int {
+	return priority
+}
+
+func (s *source) String() string {
+	return "flag"
+}
+
+func value(f *flag.Flag) interface{} {
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		return f.Value.String()
+	}
+	switch v := g.Get().(type) {
+	case time.Duration:
+		return v.String()
+	case nil:
+		return f.Value.String()
+	default:
+		return v
+	}
+}
+
+// set sets the value of the nested keys, a key set by a shorter flag is
+// replaced by the map of the longer ones.
+func set(values map[string]interface{}, keys []string, v interface{}) {
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := values[k].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			values[k] = next
+		}
+		values = next
+	}
+	values[keys[len(keys)-1]] = v
+}
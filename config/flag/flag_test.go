@@ -0,0 +1,68 @@
+package flag
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+func TestSource(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("server.http.addr", ":8000", "")
+	fs.Int("server.http.port", 8000, "")
+	fs.Bool("debug", false, "")
+	fs.Duration("server.http.timeout", time.Second, "")
+	fs.String("unset", "default", "")
+	if err := fs.Parse([]string{"-server.http.addr=:8080", "-server.http.port=8080", "-debug", "-server.http.timeout=2s"}); err != nil {
+		t.Fatal(err)
+	}
+	file := &jsonSource{data: `{"server":{"http":{"addr":":80","network":"tcp"}},"unset":"file"}`, priority: 100}
+	c := config.New(config.WithSource(NewSource(fs), file))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if v, _ := c.Value("server.http.addr").String(); v != ":8080" {
+		t.Fatalf("want the flag over the file, got %q", v)
+	}
+	if v, _ := c.Value("server.http.network").String(); v != "tcp" {
+		t.Fatalf("want the key of the file kept, got %q", v)
+	}
+	if v, err := c.Value("server.http.port").Int(); err != nil || v != 8080 {
+		t.Fatalf("want the int flag, got %v %v", v, err)
+	}
+	if v, err := c.Value("debug").Bool(); err != nil || !v {
+		t.Fatalf("want the bool flag, got %v %v", v, err)
+	}
+	var v struct {
+		Unset string `json:"unset"`
+	}
+	if err := c.Scan(&v); err != nil || v.Unset != "file" {
+		t.Fatalf("want the unset flag not loaded, got %q %v", v.Unset, err)
+	}
+	var d struct {
+		Server struct {
+			HTTP struct {
+				Timeout string `json:"timeout"`
+			} `json:"http"`
+		} `json:"server"`
+	}
+	if err := c.Scan(&d); err != nil || d.Server.HTTP.Timeout != "2s" {
+		t.Fatalf("want the duration flag, got %q %v", d.Server.HTTP.Timeout, err)
+	}
+}
+
+type jsonSource struct {
+	data     string
+	priority int
+}
+
+func (s *jsonSource) Load() ([]*config.KeyValue, error) {
+	return []*config.KeyValue{{Key: "file", Value: []byte(s.data), Format: "json"}}, nil
+}
+
+func (s *jsonSource) Watch() (config.Watcher, error) { return newWatcher(), nil }
+func (s *jsonSource) Priority() int                  { return s.priority }
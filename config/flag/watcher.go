@@ -0,0 +1,30 @@
+package flag
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+type watcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var _ config.Watcher = (*watcher)(nil)
+
+func newWatcher() config.Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &watcher{ctx: ctx, cancel: cancel}
+}
+
+// Next will be blocked until the Stop method is called, the flags do not change.
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	<-w.ctx.Done()
+	return nil, w.ctx.Err()
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
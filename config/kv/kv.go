@@ -0,0 +1,135 @@
+package kv
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/encoding"
+)
+
+var _ config.Source = (*kv)(nil)
+
+// Pair is a key value pair of the store.
+// A Pair with a nil Value marks Key as deleted.
+type Pair struct {
+	Key   string
+	Value []byte
+}
+
+// KV is the key value store of the backends also serving the service
+// discovery, e.g. etcd or consul, which the adapters implement.
+type KV interface {
+	// Get returns the pairs of the keys with prefix.
+	Get(ctx context.Context, prefix string) ([]*Pair, error)
+	// Watch returns a watcher of the changes of the keys with prefix.
+	Watch(ctx context.Context, prefix string) (Watcher, error)
+}
+
+// Watcher watches the keys of a store for changes.
+type Watcher interface {
+	// Next blocks until the keys change and returns the changed pairs.
+	Next() ([]*Pair, error)
+	// Stop stops the watch, a pending Next returns an error.
+	Stop() error
+}
+
+// Option is kv source option.
+type Option func(*kv)
+
+// WithContext with the context of the store requests.
+func WithContext(ctx context.Context) Option {
+	return func(s *kv) {
+		s.ctx = ctx
+	}
+}
+
+type kv struct {
+	store  KV
+	prefix string
+	ctx    context.Context
+}
+
+// NewSource new a source of the keys with prefix of the store, every
+// KeyValue is keyed by the path of the key relative to the prefix.
+// A key of a registered encoding format, e.g. app.yaml, is decoded by it,
+// others are config values keyed by their path, e.g. server/http/addr is
+// server.http.addr.
+func NewSource(store KV, prefix string, opts ...Option) config.Source {
+	s := &kv{
+		store:  store,
+		prefix: prefix,
+		ctx:    context.Background(),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *kv) Load() ([]*config.KeyValue, error) {
+	pairs, err := s.store.Get(s.ctx, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	return s.keyValues(pairs), nil
+}
+
+func (s *kv) Watch() (config.Watcher, error) {
+	w, err := s.store.Watch(s.ctx, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &watcher{s: s, w: w}, nil
+}
+
+func (s *kv) String() string {
+	return "kv:" + s.prefix
+}
+
+func (s *kv) keyValues(pairs []*Pair) []*config.KeyValue {
+	kvs := make([]*config.KeyValue, 0, len(pairs))
+	for _, p := range pairs {
+		kvs = append(kvs, s.keyValue(p))
+	}
+	return kvs
+}
+
+// keyValue returns the KeyValue of p keyed by its path relative to the prefix,
+// the key itself is keyed by its base name.
+func (s *kv) keyValue(p *Pair) *config.KeyValue {
+	key := strings.Trim(strings.TrimPrefix(p.Key, s.prefix), "/")
+	if key == "" {
+		key = path.Base(p.Key)
+	}
+	format := strings.TrimPrefix(path.Ext(key), ".")
+	if encoding.GetCodec(format) == nil {
+		format = ""
+		key = strings.ReplaceAll(key, "/", ".")
+	}
+	return &config.KeyValue{
+		Key:    key,
+		Value:  p.Value,
+		Format: format,
+	}
+}
+
+type watcher struct {
+	s *kv
+	w Watcher
+}
+
+var _ config.Watcher = (*watcher)(nil)
+
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	pairs, err := w.w.Next()
+	if err != nil {
+		return nil, err
+	}
+	return w.s.keyValues(pairs), nil
+}
+
+func (w *watcher) Stop() error {
+	return w.w.Stop()
+}
@@ -0,0 +1,114 @@
+package kv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+type memoryKV struct {
+	pairs []*Pair
+	ch    chan []*Pair
+}
+
+func (m *memoryKV) Get(ctx context.Context, prefix string) ([]*Pair, error) {
+	return m.pairs, nil
+}
+
+func (m *memoryKV) Watch(ctx context.Context, prefix string) (Watcher, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &memoryWatcher{ch: m.ch, ctx: ctx, cancel: cancel}, nil
+}
+
+type memoryWatcher struct {
+	ch     chan []*Pair
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (w *memoryWatcher) Next() ([]*Pair, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case pairs := <-w.ch:
+		return pairs, nil
+	}
+}
+
+func (w *memoryWatcher) Stop() error {
+	w.cancel()
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	store := &memoryKV{pairs: []*Pair{
+		{Key: "/app/config.yaml", Value: []byte("server:\n  name: kratos\n")},
+		{Key: "/app/server/http/addr", Value: []byte("0.0.0.0:8000")},
+	}}
+	kvs, err := NewSource(store, "/app/").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []config.KeyValue{
+		{Key: "config.yaml", Format: "yaml"},
+		{Key: "server.http.addr"},
+	}
+	if len(kvs) != len(want) {
+		t.Fatalf("want %d KeyValues, got %d", len(want), len(kvs))
+	}
+	for i, kv := range kvs {
+		if kv.Key != want[i].Key || kv.Format != want[i].Format {
+			t.Fatalf("want %s of format %q, got %s of format %q", want[i].Key, want[i].Format, kv.Key, kv.Format)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	store := &memoryKV{
+		pairs: []*Pair{{Key: "/app/server/http/addr", Value: []byte("0.0.0.0:8000")}},
+		ch:    make(chan []*Pair),
+	}
+	c := config.New(config.WithSource(NewSource(store, "/app")))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if v, _ := c.Value("server.http.addr").String(); v != "0.0.0.0:8000" {
+		t.Fatalf("want 0.0.0.0:8000, got %q", v)
+	}
+
+	store.ch <- []*Pair{{Key: "/app/server/http/addr", Value: []byte("0.0.0.0:9000")}}
+	waitValue(t, c, "0.0.0.0:9000")
+	store.ch <- []*Pair{{Key: "/app/server/http/addr"}}
+	deadline := time.Now().Add(time.Second)
+	for {
+		var v map[string]interface{}
+		if err := c.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := v["server"]; !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("want the deleted key removed, got %v", v)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitValue(t *testing.T, c config.Config, want string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		v, _ := c.Value("server.http.addr").String()
+		if v == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("want %q, got %q", want, v)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -2,8 +2,8 @@ package config
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/encoding"
 	"github.com/go-kratos/kratos/v2/log"
@@ -18,11 +18,70 @@ type Resolver func(map[string]interface{}) error
 // Option is config option.
 type Option func(*options)
 
+// MergeStrategy controls how slices are merged when a later source
+// sets a key that already holds a slice.
+type MergeStrategy int
+
+const (
+	// MergeReplace replaces the slice wholesale, it is the default.
+	MergeReplace MergeStrategy = iota
+	// MergeAppend appends the elements of the later slice.
+	MergeAppend
+	// MergeUnion appends only the elements not already present.
+	// Map elements sharing the same value of the union key are merged instead.
+	MergeUnion
+)
+
 type options struct {
 	sources  []Source
 	decoder  Decoder
 	resolver Resolver
 	logger   log.Logger
+
+	watchInitial bool
+	merge        MergeStrategy
+	unionKey     string
+	validator    Validator
+	schema       *Schema
+	// postResolvers run in order after resolver.
+	postResolvers []Resolver
+	decryptions   []decryption
+	pollInterval  time.Duration
+	// reloadDebounce is the window of the changes merged at once.
+	reloadDebounce time.Duration
+	onChange       func(ChangeEvent)
+	keyDelimiter   string
+	// logLevel is the level of the config logs if filterLevel is set.
+	logLevel    log.Level
+	filterLevel bool
+	verbose     bool
+	// disallowUnknownFields makes Scan fail on the keys unknown to the target.
+	disallowUnknownFields bool
+	// weakTypes makes Scan convert the scalars to the types of the target.
+	weakTypes bool
+	// conflictLogging logs the keys overridden by another source.
+	conflictLogging bool
+	redactConflict  func(key string) bool
+}
+
+// decryption is a decrypt of the values starting with prefix, see
+// WithDecryption.
+type decryption struct {
+	prefix  string
+	decrypt func([]byte) ([]byte, error)
+}
+
+// scan returns the options of Scan.
+func (o options) scan() scanOptions {
+	return scanOptions{disallowUnknown: o.disallowUnknownFields, weakTypes: o.weakTypes}
+}
+
+// delimiter returns the key delimiter, defaults to ".".
+func (o options) delimiter() string {
+	if o.keyDelimiter == "" {
+		return "."
+	}
+	return o.keyDelimiter
 }
 
 // WithSource with config source.
@@ -32,6 +91,16 @@ func WithSource(s ...Source) Option {
 	}
 }
 
+// WithSourcePriority appends config sources merged with the given priority,
+// see Prioritized.
+func WithSourcePriority(priority int, s ...Source) Option {
+	return func(o *options) {
+		for _, src := range s {
+			o.sources = append(o.sources, &prioritySource{Source: src, priority: priority})
+		}
+	}
+}
+
 // WithDecoder with config decoder.
 // DefaultDecoder behavior:
 // If KeyValue.Format is non-empty, then KeyValue.Value will be deserialized into map[string]interface{}
@@ -43,6 +112,22 @@ func WithDecoder(d Decoder) Option {
 	}
 }
 
+// WithDecoders with an ordered chain of config decoders. Every decoder
+// receives the KeyValue and the map filled by the previous ones, so later
+// decoders win for conflicting keys. WithDecoder is a one-element chain.
+func WithDecoders(ds ...Decoder) Option {
+	return func(o *options) {
+		o.decoder = func(kv *KeyValue, target map[string]interface{}) error {
+			for _, d := range ds {
+				if err := d(kv, target); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+}
+
 // WithResolver with config resolver.
 func WithResolver(r Resolver) Option {
 	return func(o *options) {
@@ -57,12 +142,164 @@ func WithLogger(l log.Logger) Option {
 	}
 }
 
+// WithLogLevel with the config logs below level dropped, e.g. LevelWarn keeps
+// only the failures, without affecting the logger itself.
+func WithLogLevel(level log.Level) Option {
+	return func(o *options) {
+		o.logLevel = level
+		o.filterLevel = true
+	}
+}
+
+// WithVerbose with every loaded KeyValue logged at the debug level.
+func WithVerbose() Option {
+	return func(o *options) {
+		o.verbose = true
+	}
+}
+
+// WithScanDisallowUnknownFields with Scan and ScanKey failing on the keys of
+// the config unknown to the target, naming the first one, e.g. to catch the
+// typos of the keys. Every value scanned must cover the whole config or key,
+// the unknown keys are ignored by default.
+func WithScanDisallowUnknownFields() Option {
+	return func(o *options) {
+		o.disallowUnknownFields = true
+	}
+}
+
+// WithWeakTypeConversion with Scan and ScanKey converting the strings, the
+// numbers and the bools of the config to the types of the target fields where
+// it is unambiguous, e.g. "8080" to an int or "true" to a bool, as the
+// environment variables are all strings. The strings of the durations are
+// parsed, e.g. "1s", and the values failing conversion are errors naming
+// their key. The types are strict by default.
+func WithWeakTypeConversion() Option {
+	return func(o *options) {
+		o.weakTypes = true
+	}
+}
+
+// WithConflictLogging with a warning logged once per key when a merge
+// overrides the value of the key set by another source or KeyValue, naming
+// both of them and their values, e.g. to surface an environment variable
+// accidentally overriding a file. It is off by default.
+func WithConflictLogging() Option {
+	return func(o *options) {
+		o.conflictLogging = true
+	}
+}
+
+// WithConflictRedaction with the values of the keys matched by redact logged as
+// "***" by WithConflictLogging, e.g. the passwords.
+func WithConflictRedaction(redact func(key string) bool) Option {
+	return func(o *options) {
+		o.redactConflict = redact
+	}
+}
+
+// WithWatchInitial with config observers fired once with the current value.
+// Observers registered before Load are fired after Load succeeds and before
+// it returns, observers registered afterwards are fired before Watch returns.
+func WithWatchInitial() Option {
+	return func(o *options) {
+		o.watchInitial = true
+	}
+}
+
+// WithMergeStrategy with the strategy used to merge slices across sources.
+// Maps are always merged key by key, the strategy only applies once both
+// sides of a key are slices. For MergeAppend and MergeUnion the configuration
+// is rebuilt from the latest KeyValue of every source and key on each merge, so
+// repeated loads of the same source are deterministic. Placeholders are
+// resolved after merging.
+func WithMergeStrategy(s MergeStrategy) Option {
+	return func(o *options) {
+		o.merge = s
+	}
+}
+
+// WithMergeUnionKey with the field identifying map elements for MergeUnion,
+// e.g. "name" merges {"name":"a","port":1} with {"name":"a","port":2}.
+func WithMergeUnionKey(key string) Option {
+	return func(o *options) {
+		o.unionKey = key
+	}
+}
+
+// WithValidator with the validator run by LoadAndScan and on every hot reload
+// afterwards, a reload failing validation is rejected and the previous
+// config is retained.
+func WithValidator(v Validator) Option {
+	return func(o *options) {
+		o.validator = v
+	}
+}
+
+// WithDecryption with a resolver run after the placeholder resolver, which
+// replaces every string value starting with prefix, e.g. "enc:AES256:", by
+// the plaintext decrypt returns for the rest of the value. Since it runs in
+// Reader.Resolve both the initial load and hot reloads are decrypted, and a
+// decryption failure aborts the resolve.
+func WithDecryption(prefix string, decrypt func([]byte) ([]byte, error)) Option {
+	return func(o *options) {
+		o.decryptions = append(o.decryptions, decryption{prefix: prefix, decrypt: decrypt})
+	}
+}
+
+// WithPollInterval with every source watched by polling its Load at the
+// given interval instead of its own Watch, see NewPollWatcher.
+func WithPollInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = d
+	}
+}
+
+// WithReloadDebounce with the changes pushed by a source watcher within d of
+// the first one merged at once, the latest KeyValue of every key wins, which
+// coalesces the bursts of events of editors saving files several times or of
+// etcd. Zero merges every change as it is pushed.
+func WithReloadDebounce(d time.Duration) Option {
+	return func(o *options) {
+		o.reloadDebounce = d
+	}
+}
+
+// WithOnChange with a hook called once for every change pushed by a source
+// watcher after it is merged and resolved, or failed to.
+func WithOnChange(f func(ChangeEvent)) Option {
+	return func(o *options) {
+		o.onChange = f
+	}
+}
+
+// WithKeyDelimiter with the delimiter splitting key paths in Value lookups,
+// placeholders, the keys of the KeyValues without a format decoded by the
+// default decoder and the keys named by decryption failures, defaults to
+// ".", e.g. with "/" the key "a/b.com/c"
+// reads c under the key "b.com" of a.
+func WithKeyDelimiter(d string) Option {
+	return func(o *options) {
+		o.keyDelimiter = d
+	}
+}
+
 // defaultDecoder decode config from source KeyValue
 // to target map[string]interface{} using src.Format codec.
-func defaultDecoder(src *KeyValue, target map[string]interface{}) error {
+var defaultDecoder = newDefaultDecoder(".")
+
+// newDefaultDecoder returns the default decoder splitting the keys of the
+// KeyValues without a format with delimiter.
+func newDefaultDecoder(delimiter string) Decoder {
+	return func(src *KeyValue, target map[string]interface{}) error {
+		return decodeKeyValue(src, target, delimiter)
+	}
+}
+
+func decodeKeyValue(src *KeyValue, target map[string]interface{}, delimiter string) error {
 	if src.Format == "" {
 		// expand key "aaa.bbb" into map[aaa]map[bbb]interface{}
-		keys := strings.Split(src.Key, ".")
+		keys := strings.Split(src.Key, delimiter)
 		for i, k := range keys {
 			if i == len(keys)-1 {
 				target[k] = src.Value
@@ -81,15 +318,41 @@ func defaultDecoder(src *KeyValue, target map[string]interface{}) error {
 }
 
 // defaultResolver resolve placeholder in map value,
-// placeholder format in ${key:default}.
-func defaultResolver(input map[string]interface{}) error {
-	mapper := func(name string) string {
+// placeholder format in ${key:default} or ${key:-default}.
+// The ":" form falls back only when key is absent, the ":-" form also falls
+// back when the value is empty. Defaults may contain nested placeholders,
+// and "$$" is an escaped literal "$".
+var defaultResolver = newDefaultResolver(".")
+
+// newDefaultResolver returns the default resolver splitting placeholder
+// keys with delimiter.
+func newDefaultResolver(delimiter string) Resolver {
+	return func(input map[string]interface{}) error {
+		return resolvePlaceholders(input, delimiter)
+	}
+}
+
+func resolvePlaceholders(input map[string]interface{}, delimiter string) error {
+	var mapper func(name string) string
+	mapper = func(name string) string {
 		args := strings.SplitN(strings.TrimSpace(name), ":", 2) //nolint:gomnd
-		if v, has := readValue(input, args[0]); has {
-			s, _ := v.String()
-			return s
-		} else if len(args) > 1 { // default value
-			return args[1]
+		var (
+			def, hasDef = "", len(args) > 1
+			orEmpty     bool
+		)
+		if hasDef {
+			def = args[1]
+			if strings.HasPrefix(def, "-") {
+				def, orEmpty = def[1:], true
+			}
+		}
+		if v, has := readValue(input, args[0], delimiter); has {
+			if s, _ := v.String(); s != "" || !orEmpty {
+				return s
+			}
+		}
+		if hasDef {
+			return expand(def, mapper)
 		}
 		return ""
 	}
@@ -123,13 +386,104 @@ func defaultResolver(input map[string]interface{}) error {
 	return resolve(input)
 }
 
+// newDecryptResolver returns a resolver replacing the string values starting
+// with prefix by their plaintext, naming the failed keys joined with delimiter,
+// see WithDecryption.
+func newDecryptResolver(prefix, delimiter string, decrypt func([]byte) ([]byte, error)) Resolver {
+	var resolve func(path string, v interface{}) (interface{}, error)
+	resolve = func(path string, v interface{}) (interface{}, error) {
+		switch vt := v.(type) {
+		case string:
+			if !strings.HasPrefix(vt, prefix) {
+				return vt, nil
+			}
+			plain, err := decrypt([]byte(strings.TrimPrefix(vt, prefix)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt config key %s: %w", path, err)
+			}
+			return string(plain), nil
+		case map[string]interface{}:
+			for k, sub := range vt {
+				p := k
+				if path != "" {
+					p = path + delimiter + k
+				}
+				r, err := resolve(p, sub)
+				if err != nil {
+					return nil, err
+				}
+				vt[k] = r
+			}
+		case []interface{}:
+			for i, sub := range vt {
+				r, err := resolve(fmt.Sprintf("%s[%d]", path, i), sub)
+				if err != nil {
+					return nil, err
+				}
+				vt[i] = r
+			}
+		}
+		return v, nil
+	}
+	return func(input map[string]interface{}) error {
+		_, err := resolve("", input)
+		return err
+	}
+}
+
+// expand replaces every ${...} placeholder in s using mapping,
+// and unescapes "$$" into "$".
 func expand(s string, mapping func(string) string) string {
-	r := regexp.MustCompile(`\${(.*?)}`)
-	re := r.FindAllStringSubmatch(s, -1)
-	for _, i := range re {
-		if len(i) == 2 { //nolint:gomnd
-			s = strings.ReplaceAll(s, i[0], mapping(i[1]))
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	var buf strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] == '$' && i+1 < len(s) {
+			switch s[i+1] {
+			case '$':
+				buf.WriteByte('$')
+				i += 2
+				continue
+			case '{':
+				if end := placeholderEnd(s, i+2); end >= 0 {
+					buf.WriteString(mapping(s[i+2 : end]))
+					i = end + 1
+					continue
+				}
+			}
+		}
+		buf.WriteByte(s[i])
+		i++
+	}
+	return buf.String()
+}
+
+// placeholderEnd returns the index of the brace closing the placeholder
+// whose body starts at start, or -1 if it is not closed. Braces are only
+// balanced within the default part so that the default may be nested.
+func placeholderEnd(s string, start int) int {
+	var (
+		depth   int
+		inValue bool
+	)
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case ':':
+			inValue = true
+		case '$':
+			if i+1 < len(s) && s[i+1] == '$' {
+				i++
+			} else if inValue && i+1 < len(s) && s[i+1] == '{' {
+				depth++
+				i++
+			}
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
 		}
 	}
-	return s
+	return -1
 }
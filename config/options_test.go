@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -39,6 +41,23 @@ func TestDefaultDecoder(t *testing.T) {
 	}, target) {
 		t.Fatal(`target is not equal to map[string]interface{}{"service": map[string]interface{}{"name": map[string]interface{}{"alias": []byte("2233")}}}`)
 	}
+
+	src = &KeyValue{
+		Key:    "hosts/api.example.com",
+		Value:  []byte("443"),
+		Format: "",
+	}
+	target = make(map[string]interface{})
+	if err = newDefaultDecoder("/")(src, target); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(map[string]interface{}{
+		"hosts": map[string]interface{}{
+			"api.example.com": []byte("443"),
+		},
+	}, target) {
+		t.Fatalf("unexpected target with delimiter /: %v", target)
+	}
 }
 
 func TestDefaultResolver(t *testing.T) {
@@ -66,6 +85,9 @@ func TestDefaultResolver(t *testing.T) {
 				"value2": "$PORT",
 				"value3": "abc${PORT}foo${COUNT}bar",
 				"value4": "${foo${bar}}",
+				"value5": "${EMPTY:-foobar}",
+				"value6": "${NOTEXIST:-${ALSONOTEXIST:-${PORT}}}",
+				"value8": "${NOTEXIST:-a$$b}",
 			},
 		},
 		"test": map[string]interface{}{
@@ -143,6 +165,21 @@ func TestDefaultResolver(t *testing.T) {
 			path:   "foo.bar.value4",
 			expect: "}",
 		},
+		{
+			name:   "test ${EMPTY:-foobar}",
+			path:   "foo.bar.value5",
+			expect: "foobar",
+		},
+		{
+			name:   "test nested default",
+			path:   "foo.bar.value6",
+			expect: portString,
+		},
+		{
+			name:   "test escaped $$ in default",
+			path:   "foo.bar.value8",
+			expect: "a$b",
+		},
 	}
 
 	for _, test := range tests {
@@ -197,3 +234,54 @@ func TestDefaultResolver(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultResolver_Escape(t *testing.T) {
+	data := map[string]interface{}{
+		"PORT":    "8080",
+		"escaped": "$${PORT}",
+		"price":   "$$10",
+	}
+	if err := defaultResolver(data); err != nil {
+		t.Fatal(err)
+	}
+	if data["escaped"] != "${PORT}" {
+		t.Fatalf("expect ${PORT}, got %v", data["escaped"])
+	}
+	if data["price"] != "$10" {
+		t.Fatalf("expect $10, got %v", data["price"])
+	}
+}
+
+func TestDecryptResolver(t *testing.T) {
+	decrypt := func(b []byte) ([]byte, error) {
+		if string(b) == "bad" {
+			return nil, errors.New("bad ciphertext")
+		}
+		return []byte("plain-" + string(b)), nil
+	}
+	r := newReader(options{decoder: defaultDecoder, resolver: defaultResolver, postResolvers: []Resolver{newDecryptResolver("enc:", ".", decrypt)}})
+	if err := r.Merge(&KeyValue{Key: "b", Value: []byte(`{"db": {"password": "enc:secret", "user": "root"}, "keys": ["enc:k1"]}`), Format: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{"db.password": "plain-secret", "db.user": "root", "keys[0]": "plain-k1"} {
+		if v, _ := r.Value(path); v.Load() != want {
+			t.Fatalf("expect %s to be %s, got %v", path, want, v.Load())
+		}
+	}
+
+	if err := r.Merge(&KeyValue{Key: "b", Value: []byte(`{"db": {"password": "enc:bad"}}`), Format: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	err := r.Resolve()
+	if err == nil || !strings.Contains(err.Error(), "db.password") {
+		t.Fatalf("expect decrypt error naming db.password, got %v", err)
+	}
+
+	err = newDecryptResolver("enc:", "/", decrypt)(map[string]interface{}{"db": map[string]interface{}{"password": "enc:bad"}})
+	if err == nil || !strings.Contains(err.Error(), "db/password") {
+		t.Fatalf("expect decrypt error naming db/password, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+)
+
+const (
+	// overrideSource is the source of the keys set by Set.
+	overrideSource = -1
+	// overridePriority is the priority of the keys set by Set, higher than
+	// the ones of the sources.
+	overridePriority = math.MaxInt32
+)
+
+// Set overrides the value of key in memory, e.g. a feature flag toggled by an
+// admin API. The override wins over all the sources whatever their priority,
+// until a source watcher pushes a change setting key or Delete is called, and
+// it is kept by Reload. The value is visible to Value and the observers of
+// key are fired once Set returns, key is a path of map keys, e.g.
+// "features.new_ui".
+func (c *config) Set(key string, value interface{}) error {
+	keys := strings.Split(key, c.opts.delimiter())
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = map[string]interface{}{keys[i]: value}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.override(&KeyValue{Key: key, Value: data, Format: "json"})
+}
+
+// Delete removes the override of key set by Set, the value of the sources is
+// restored.
+func (c *config) Delete(key string) error {
+	return c.override(&KeyValue{Key: key})
+}
+
+func (c *config) override(kv *KeyValue) error {
+	r, ok := c.reader.(*reader)
+	if !ok {
+		return c.reader.Merge(kv)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	restore := c.snapshot()
+	if err := r.mergeSource(overrideSource, overridePriority, kv); err != nil {
+		restore()
+		return err
+	}
+	if err := c.reader.Resolve(); err != nil {
+		restore()
+		return err
+	}
+	if err := c.validate(); err != nil {
+		restore()
+		return err
+	}
+	c.notify()
+	return nil
+}
+
+// dropOverrides removes the overrides of the keys set by kvs, the changes
+// pushed by a source watcher.
+func (c *config) dropOverrides(kvs []*KeyValue) error {
+	r, ok := c.reader.(*reader)
+	if !ok {
+		return nil
+	}
+	r.lock.Lock()
+	var keys []string
+	for _, e := range r.entries {
+		if e.source == overrideSource {
+			keys = append(keys, e.kv.Key)
+		}
+	}
+	r.lock.Unlock()
+	if len(keys) == 0 {
+		return nil
+	}
+	var dropped []*KeyValue
+	for _, kv := range kvs {
+		if kv.Value == nil {
+			continue
+		}
+		next := make(map[string]interface{})
+		if err := r.opts.decoder(kv, next); err != nil {
+			return err
+		}
+		values := convertMap(next).(map[string]interface{})
+		for _, key := range keys {
+			if _, ok := readValue(values, key, r.opts.delimiter()); ok {
+				dropped = append(dropped, &KeyValue{Key: key})
+			}
+		}
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+	return r.mergeSource(overrideSource, overridePriority, dropped...)
+}
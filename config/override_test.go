@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfig_Set(t *testing.T) {
+	src := newTestPushSource(`{"features":{"a":false,"b":false}}`)
+	c := New(WithSourcePriority(100, src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	changed := make(chan bool, 3)
+	if err := c.Watch("features.a", func(key string, v Value) {
+		b, _ := v.Bool()
+		changed <- b
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Set("features.a", true); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := c.Value("features.a").Bool(); !v {
+		t.Fatal("want the override")
+	}
+	if v := <-changed; !v {
+		t.Fatal("want the observer fired with the override")
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := c.Value("features.a").Bool(); !v {
+		t.Fatal("want the override kept by Reload")
+	}
+	if err := c.Delete("features.a"); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-changed; v {
+		t.Fatal("want the value of the source restored")
+	}
+
+	// a push of the key drops the override
+	if err := c.Set("features.b", true); err != nil {
+		t.Fatal(err)
+	}
+	src.push(`{"features":{"a":false}}`)
+	time.Sleep(50 * time.Millisecond)
+	if v, _ := c.Value("features.b").Bool(); !v {
+		t.Fatal("want the override kept by the push of other keys")
+	}
+	src.push(`{"features":{"b":false}}`)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if v, _ := c.Value("features.b").Bool(); !v {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("want the override dropped by the push of the key")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if origins := c.Explain("features.b"); len(origins) != 1 || origins[0].Priority != 100 {
+		t.Fatalf("want the source only, got %+v", origins)
+	}
+}
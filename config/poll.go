@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+var _ Watcher = (*pollWatcher)(nil)
+
+type pollWatcher struct {
+	src      Source
+	interval time.Duration
+	last     []*KeyValue
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewPollWatcher returns a watcher that reloads src every interval and
+// emits the loaded KeyValues only when they differ from the last load,
+// which lets a source without change notifications support hot reload.
+func NewPollWatcher(src Source, interval time.Duration) Watcher {
+	last, _ := src.Load()
+	return newPollWatcher(src, interval, last)
+}
+
+func newPollWatcher(src Source, interval time.Duration, last []*KeyValue) *pollWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &pollWatcher{
+		src:      src,
+		interval: interval,
+		last:     last,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (w *pollWatcher) Next() ([]*KeyValue, error) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-ticker.C:
+			kvs, err := w.src.Load()
+			if err != nil {
+				return nil, err
+			}
+			if reflect.DeepEqual(kvs, w.last) {
+				continue
+			}
+			w.last = kvs
+			return kvs, nil
+		}
+	}
+}
+
+func (w *pollWatcher) Stop() error {
+	w.cancel()
+	return nil
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testPollSource struct {
+	lock sync.Mutex
+	data string
+}
+
+func (s *testPollSource) Load() ([]*KeyValue, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return []*KeyValue{{Key: "json", Value: []byte(s.data), Format: "json"}}, nil
+}
+
+func (s *testPollSource) Watch() (Watcher, error) {
+	return nil, errors.New("watch is not supported")
+}
+
+func (s *testPollSource) set(data string) {
+	s.lock.Lock()
+	s.data = data
+	s.lock.Unlock()
+}
+
+func TestPollWatcher(t *testing.T) {
+	src := &testPollSource{data: `{"a":1}`}
+	w := NewPollWatcher(src, 10*time.Millisecond)
+
+	result := make(chan []*KeyValue, 1)
+	go func() {
+		kvs, _ := w.Next()
+		result <- kvs
+	}()
+	select {
+	case <-result:
+		t.Fatal("identical reloads should not emit changes")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	src.set(`{"a":2}`)
+	select {
+	case kvs := <-result:
+		if string(kvs[0].Value) != `{"a":2}` {
+			t.Fatalf("unexpected value: %s", kvs[0].Value)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for changes")
+	}
+
+	if err := w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Next(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expect context.Canceled, got %v", err)
+	}
+}
+
+func TestConfig_PollInterval(t *testing.T) {
+	src := &testPollSource{data: `{"a":1}`}
+	c := New(WithSource(src), WithPollInterval(10*time.Millisecond))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	changed := make(chan int64, 1)
+	if err := c.Watch("a", func(_ string, v Value) {
+		i, _ := v.Int()
+		changed <- i
+	}); err != nil {
+		t.Fatal(err)
+	}
+	src.set(`{"a":2}`)
+	select {
+	case i := <-changed:
+		if i != 2 {
+			t.Fatalf("unexpected value: %d", i)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for changes")
+	}
+}
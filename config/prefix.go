@@ -0,0 +1,118 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+type prefixWatcher struct {
+	prefix    string
+	delimiter string
+	observer  Observer
+
+	lock   sync.Mutex
+	values map[string]interface{}
+}
+
+func newPrefixWatcher(prefix, delimiter string, o Observer, r Reader) *prefixWatcher {
+	return &prefixWatcher{
+		prefix:    prefix,
+		delimiter: delimiter,
+		observer:  o,
+		values:    readFlatten(r, prefix, delimiter),
+	}
+}
+
+// reset takes a new snapshot of the subtree without notifying.
+func (w *prefixWatcher) reset(r Reader) {
+	w.lock.Lock()
+	w.values = readFlatten(r, w.prefix, w.delimiter)
+	w.lock.Unlock()
+}
+
+// initial takes a new snapshot of the subtree and notifies every key in it.
+func (w *prefixWatcher) initial(r Reader) {
+	w.reset(r)
+	w.lock.Lock()
+	values := w.values
+	w.lock.Unlock()
+	for k, n := range values {
+		w.observer(k, newValue(n))
+	}
+}
+
+// notify compares the current subtree under prefix with the last seen one
+// and invokes the observer for every descendant key that changed.
+func (w *prefixWatcher) notify(r Reader) {
+	w.lock.Lock()
+	prev := w.values
+	next := readFlatten(r, w.prefix, w.delimiter)
+	w.values = next
+	w.lock.Unlock()
+
+	for k, n := range next {
+		if p, ok := prev[k]; ok && reflect.DeepEqual(p, n) {
+			continue
+		}
+		w.observer(k, newValue(n))
+	}
+	for k := range prev {
+		if _, ok := next[k]; !ok {
+			w.observer(k, &errValue{err: ErrNotFound})
+		}
+	}
+}
+
+// readFlatten reads the subtree at prefix and flattens it into
+// full delimited keys mapped to leaf values, an empty prefix reads the
+// whole config.
+func readFlatten(r Reader, prefix, delimiter string) map[string]interface{} {
+	values := make(map[string]interface{})
+	if prefix == "" {
+		data, err := r.Source()
+		if err != nil {
+			return values
+		}
+		var all map[string]interface{}
+		if err = json.Unmarshal(data, &all); err == nil {
+			flatten("", delimiter, all, values)
+		}
+		return values
+	}
+	if v, ok := r.Value(prefix); ok {
+		flatten(prefix, delimiter, v.Load(), values)
+	}
+	return values
+}
+
+func flatten(key, delimiter string, value interface{}, dst map[string]interface{}) {
+	if m, ok := value.(map[string]interface{}); ok {
+		for k, v := range m {
+			if key != "" {
+				k = key + delimiter + k
+			}
+			flatten(k, delimiter, v, dst)
+		}
+		return
+	}
+	dst[key] = value
+}
+
+// diffKeys returns the sorted keys added, changed or removed from prev to next.
+func diffKeys(prev, next map[string]interface{}) []string {
+	var keys []string
+	for k, n := range next {
+		if p, ok := prev[k]; !ok || !reflect.DeepEqual(p, n) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range prev {
+		if _, ok := next[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/gob"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -22,45 +25,157 @@ type Reader interface {
 }
 
 type reader struct {
-	opts   options
+	opts options
+	// raw holds the merged values before placeholders are resolved,
+	// so that every Resolve starts from the original sources.
+	raw    map[string]interface{}
 	values map[string]interface{}
-	lock   sync.Mutex
+	// entries holds the latest KeyValue of every source and key, it is used
+	// to rebuild the values for priorities, non-replace merging and deletes.
+	// It is replaced rather than mutated so that snapshots can share it.
+	entries []*entry
+	// origins is the KeyValue which set each flattened key of raw, and
+	// conflicted the keys whose conflicts are logged, if conflict logging is on.
+	origins    map[string]origin
+	conflicted map[string]struct{}
+	lock       sync.Mutex
+}
+
+// entry is a KeyValue merged from a source with a priority.
+type entry struct {
+	source   int
+	priority int
+	kv       *KeyValue
 }
 
 func newReader(opts options) Reader {
 	return &reader{
 		opts:   opts,
+		raw:    make(map[string]interface{}),
 		values: make(map[string]interface{}),
 		lock:   sync.Mutex{},
 	}
 }
 
 func (r *reader) Merge(kvs ...*KeyValue) error {
+	return r.mergeSource(0, 0, kvs...)
+}
+
+// mergeSource merges kvs loaded from the given source with a priority,
+// KeyValues of a higher priority always override those of a lower one
+// regardless of merge order, and KeyValues of the same priority are merged
+// in the order first seen.
+func (r *reader) mergeSource(source, priority int, kvs ...*KeyValue) error {
 	r.lock.Lock()
-	merged, err := cloneMap(r.values)
+	entries := latest(r.entries, source, priority, kvs)
+	incremental := r.opts.merge == MergeReplace && !hasDeleted(kvs) && !hasPriority(entries)
+	merged, err := cloneMap(r.raw)
+	var conflicts *conflicts
+	if r.opts.conflictLogging {
+		conflicts = newConflicts(r.origins)
+	}
 	r.lock.Unlock()
 	if err != nil {
 		return err
 	}
-	for _, kv := range kvs {
+	sources := make([]int, len(kvs))
+	for i := range sources {
+		sources[i] = source
+	}
+	if !incremental {
+		all := make([]*entry, len(entries))
+		copy(all, entries)
+		sort.SliceStable(all, func(i, j int) bool {
+			return all[i].priority < all[j].priority
+		})
+		merged = make(map[string]interface{})
+		kvs, sources = kvs[:0:0], sources[:0]
+		for _, e := range all {
+			kvs = append(kvs, e.kv)
+			sources = append(sources, e.source)
+		}
+		if conflicts != nil {
+			conflicts = newConflicts(nil)
+		}
+	}
+	for i, kv := range kvs {
 		next := make(map[string]interface{})
 		if err := r.opts.decoder(kv, next); err != nil {
 			return err
 		}
-		if err := mergo.Map(&merged, convertMap(next), mergo.WithOverride); err != nil {
-			return err
+		if conflicts != nil {
+			conflicts.track(merged, convertMap(next).(map[string]interface{}),
+				origin{source: sources[i], key: kv.Key}, r.opts.delimiter(), r.opts.merge)
+		}
+		if r.opts.merge == MergeReplace {
+			if err := mergo.Map(&merged, convertMap(next), mergo.WithOverride); err != nil {
+				return err
+			}
+			continue
 		}
+		mergeMap(merged, convertMap(next).(map[string]interface{}), r.opts.merge, r.opts.unionKey)
 	}
+	var found []conflict
 	r.lock.Lock()
+	r.entries = entries
+	r.raw = merged
 	r.values = merged
+	if conflicts != nil {
+		r.origins = conflicts.origins
+		found = r.unlogged(conflicts.found)
+	}
 	r.lock.Unlock()
+	r.logConflicts(found)
 	return nil
 }
 
+// latest returns a copy of entries with the entries of source having the
+// same keys as kvs replaced, new keys appended and deleted keys removed.
+func latest(entries []*entry, source, priority int, kvs []*KeyValue) []*entry {
+	res := make([]*entry, 0, len(entries)+len(kvs))
+	res = append(res, entries...)
+next:
+	for _, kv := range kvs {
+		for i, old := range res {
+			if old.source != source || old.kv.Key != kv.Key {
+				continue
+			}
+			if kv.Value == nil {
+				res = append(res[:i], res[i+1:]...)
+			} else {
+				res[i] = &entry{source: source, priority: priority, kv: kv}
+			}
+			continue next
+		}
+		if kv.Value != nil {
+			res = append(res, &entry{source: source, priority: priority, kv: kv})
+		}
+	}
+	return res
+}
+
+func hasDeleted(kvs []*KeyValue) bool {
+	for _, kv := range kvs {
+		if kv.Value == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPriority(entries []*entry) bool {
+	for _, e := range entries {
+		if e.priority != 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *reader) Value(path string) (Value, bool) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	return readValue(r.values, path)
+	return readValue(r.values, path, r.opts.delimiter())
 }
 
 func (r *reader) Source() ([]byte, error) {
@@ -72,7 +187,20 @@ func (r *reader) Source() ([]byte, error) {
 func (r *reader) Resolve() error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	return r.opts.resolver(r.values)
+	resolved, err := cloneMap(r.raw)
+	if err != nil {
+		return err
+	}
+	if err = r.opts.resolver(resolved); err != nil {
+		return err
+	}
+	for _, resolver := range r.opts.postResolvers {
+		if err = resolver(resolved); err != nil {
+			return err
+		}
+	}
+	r.values = resolved
+	return nil
 }
 
 func cloneMap(src map[string]interface{}) (map[string]interface{}, error) {
@@ -122,32 +250,125 @@ func convertMap(src interface{}) interface{} {
 	}
 }
 
+func mergeMap(dst, src map[string]interface{}, s MergeStrategy, unionKey string) {
+	for k, sv := range src {
+		dv, ok := dst[k]
+		if !ok {
+			dst[k] = sv
+			continue
+		}
+		switch st := sv.(type) {
+		case map[string]interface{}:
+			if dm, ok := dv.(map[string]interface{}); ok {
+				mergeMap(dm, st, s, unionKey)
+				continue
+			}
+		case []interface{}:
+			if ds, ok := dv.([]interface{}); ok {
+				dst[k] = mergeSlice(ds, st, s, unionKey)
+				continue
+			}
+		}
+		dst[k] = sv
+	}
+}
+
+func mergeSlice(dst, src []interface{}, s MergeStrategy, unionKey string) []interface{} {
+	switch s {
+	case MergeAppend:
+		return append(dst, src...)
+	case MergeUnion:
+	next:
+		for _, sv := range src {
+			for i, dv := range dst {
+				if reflect.DeepEqual(dv, sv) {
+					continue next
+				}
+				if unionKey == "" {
+					continue
+				}
+				sm, ok1 := sv.(map[string]interface{})
+				dm, ok2 := dv.(map[string]interface{})
+				if !ok1 || !ok2 {
+					continue
+				}
+				if id, ok := sm[unionKey]; ok && reflect.DeepEqual(id, dm[unionKey]) {
+					mergeMap(dm, sm, s, unionKey)
+					dst[i] = dm
+					continue next
+				}
+			}
+			dst = append(dst, sv)
+		}
+		return dst
+	default:
+		return src
+	}
+}
+
 // readValue read Value in given map[string]interface{}
 // by the given path, will return false if not found.
-func readValue(values map[string]interface{}, path string) (Value, bool) {
-	var (
-		next = values
-		keys = strings.Split(path, ".")
-		last = len(keys) - 1
-	)
-	for idx, key := range keys {
-		value, ok := next[key]
+// Slice elements are addressed with index segments such as "servers[0].addr",
+// indexing a non-slice value returns an errValue holding ErrTypeAssert.
+func readValue(values map[string]interface{}, path, delimiter string) (Value, bool) {
+	var next interface{} = values
+	for _, key := range strings.Split(path, delimiter) {
+		m, ok := next.(map[string]interface{})
 		if !ok {
 			return nil, false
 		}
-		if idx == last {
-			av := &atomicValue{}
-			av.Store(value)
-			return av, true
+		if value, ok := m[key]; ok {
+			next = value
+			continue
 		}
-		switch vm := value.(type) {
-		case map[string]interface{}:
-			next = vm
-		default:
+		name, indexes, ok := parseIndexKey(key)
+		if !ok {
 			return nil, false
 		}
+		value, ok := m[name]
+		if !ok {
+			return nil, false
+		}
+		for _, i := range indexes {
+			s, ok := value.([]interface{})
+			if !ok {
+				return &errValue{err: ErrTypeAssert}, false
+			}
+			if i >= len(s) {
+				return nil, false
+			}
+			value = s[i]
+		}
+		next = value
+	}
+	return newValue(next), true
+}
+
+// parseIndexKey splits the key "name[1][2]" into name and indexes,
+// it returns false if the key has no valid non-negative indexes.
+func parseIndexKey(key string) (string, []int, bool) {
+	i := strings.IndexByte(key, '[')
+	if i <= 0 {
+		return "", nil, false
+	}
+	var (
+		name    = key[:i]
+		rest    = key[i:]
+		indexes []int
+	)
+	for len(rest) > 0 {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return "", nil, false
+		}
+		n, err := strconv.Atoi(rest[1:end])
+		if err != nil || n < 0 {
+			return "", nil, false
+		}
+		indexes = append(indexes, n)
+		rest = rest[end+1:]
 	}
-	return nil, false
+	return name, indexes, true
 }
 
 func marshalJSON(v interface{}) ([]byte, error) {
@@ -157,9 +378,33 @@ func marshalJSON(v interface{}) ([]byte, error) {
 	return json.Marshal(v)
 }
 
-func unmarshalJSON(data []byte, v interface{}) error {
+// scanOptions are the options decoding the config into the scanned values.
+type scanOptions struct {
+	// disallowUnknown makes the keys unknown to the value errors.
+	disallowUnknown bool
+	// weakTypes converts the strings, the numbers and the bools of the config
+	// to the types of the value.
+	weakTypes bool
+}
+
+// unmarshalJSON decodes data into v, the keys unknown to v are errors if
+// disallowUnknown is set and are ignored otherwise.
+func unmarshalJSON(data []byte, v interface{}, o scanOptions) error {
 	if m, ok := v.(proto.Message); ok {
-		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+		return protojson.UnmarshalOptions{DiscardUnknown: !o.disallowUnknown}.Unmarshal(data, m)
+	}
+	if o.weakTypes {
+		var err error
+		if data, err = weakDecode(data, reflect.TypeOf(v)); err != nil {
+			return err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if o.disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
 	}
-	return json.Unmarshal(data, v)
+	return setDefaults(data, v)
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
@@ -208,3 +209,163 @@ func TestReader_Source(t *testing.T) {
 		t.Fatal("[]byte(`{\"a\":{\"b\":{\"X\":1}}}`) is not equal to b")
 	}
 }
+
+func TestReader_ResolveRepeated(t *testing.T) {
+	r := newReader(options{decoder: defaultDecoder, resolver: defaultResolver})
+	if err := r.Merge(&KeyValue{Key: "b", Value: []byte(`{"port": "8080", "addr": "${port}", "raw": "$${port}"}`), Format: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Merge(&KeyValue{Key: "b", Value: []byte(`{"port": "9090"}`), Format: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := r.Value("addr"); v.Load() != "9090" {
+		t.Fatalf("addr should follow port, got %v", v.Load())
+	}
+	if v, _ := r.Value("raw"); v.Load() != "${port}" {
+		t.Fatalf("escaped placeholder should stay literal, got %v", v.Load())
+	}
+}
+
+func TestReader_MergeStrategy(t *testing.T) {
+	base := &KeyValue{Key: "base", Value: []byte(`{"list": [1, 2], "servers": [{"name": "a", "port": 1}]}`), Format: "json"}
+	override := &KeyValue{Key: "override", Value: []byte(`{"list": [2, 3], "servers": [{"name": "a", "port": 2}, {"name": "b", "port": 3}]}`), Format: "json"}
+	tests := []struct {
+		name     string
+		opts     options
+		list     []interface{}
+		services []interface{}
+	}{
+		{
+			name: "replace",
+			opts: options{decoder: defaultDecoder, merge: MergeReplace},
+			list: []interface{}{float64(2), float64(3)},
+			services: []interface{}{
+				map[string]interface{}{"name": "a", "port": float64(2)},
+				map[string]interface{}{"name": "b", "port": float64(3)},
+			},
+		},
+		{
+			name: "append",
+			opts: options{decoder: defaultDecoder, merge: MergeAppend},
+			list: []interface{}{float64(1), float64(2), float64(2), float64(3)},
+			services: []interface{}{
+				map[string]interface{}{"name": "a", "port": float64(1)},
+				map[string]interface{}{"name": "a", "port": float64(2)},
+				map[string]interface{}{"name": "b", "port": float64(3)},
+			},
+		},
+		{
+			name: "union by key",
+			opts: options{decoder: defaultDecoder, merge: MergeUnion, unionKey: "name"},
+			list: []interface{}{float64(1), float64(2), float64(3)},
+			services: []interface{}{
+				map[string]interface{}{"name": "a", "port": float64(2)},
+				map[string]interface{}{"name": "b", "port": float64(3)},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newReader(test.opts)
+			// merge twice to make sure repeated loads are deterministic
+			for i := 0; i < 2; i++ {
+				if err := r.Merge(base, override); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if v, _ := r.Value("list"); !reflect.DeepEqual(test.list, v.Load()) {
+				t.Fatalf("expect list %v, got %v", test.list, v.Load())
+			}
+			if v, _ := r.Value("servers"); !reflect.DeepEqual(test.services, v.Load()) {
+				t.Fatalf("expect servers %v, got %v", test.services, v.Load())
+			}
+		})
+	}
+}
+
+func TestReader_ValueIndex(t *testing.T) {
+	r := newReader(options{decoder: defaultDecoder, resolver: defaultResolver})
+	if err := r.Merge(&KeyValue{Key: "b", Value: []byte(`{"servers": [{"addr": "a"}, {"addr": "b"}], "matrix": [[1, 2]], "name": "x"}`), Format: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	v, ok := r.Value("servers[1].addr")
+	if !ok || v.Load() != "b" {
+		t.Fatalf("unexpected servers[1].addr: %v", v)
+	}
+	v, ok = r.Value("matrix[0][1]")
+	if !ok || v.Load() != float64(2) {
+		t.Fatalf("unexpected matrix[0][1]: %v", v)
+	}
+	for _, path := range []string{"servers[2].addr", "servers[-1].addr", "servers[x]", "notexist[0]"} {
+		if _, ok = r.Value(path); ok {
+			t.Fatalf("expect %s not found", path)
+		}
+	}
+	v, ok = r.Value("name[0]")
+	if ok {
+		t.Fatal("expect name[0] not found")
+	}
+	if _, err := v.String(); !errors.Is(err, ErrTypeAssert) {
+		t.Fatalf("expect ErrTypeAssert, got %v", err)
+	}
+}
+
+func TestReader_MergeDecoders(t *testing.T) {
+	var o options
+	WithDecoders(defaultDecoder, func(kv *KeyValue, v map[string]interface{}) error {
+		// sees the output of the previous decoder
+		if name, ok := v["name"].(string); ok {
+			v["name"] = name + "-decorated"
+		}
+		v["source"] = kv.Key
+		return nil
+	})(&o)
+	r := newReader(o)
+	if err := r.Merge(&KeyValue{Key: "b", Value: []byte(`{"name": "kratos", "source": "json"}`), Format: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := r.Value("name"); v.Load() != "kratos-decorated" {
+		t.Fatalf("unexpected name: %v", v.Load())
+	}
+	if v, _ := r.Value("source"); v.Load() != "b" {
+		t.Fatalf("unexpected source: %v", v.Load())
+	}
+}
+
+func TestReader_MergeDeleted(t *testing.T) {
+	r := newReader(options{decoder: defaultDecoder, resolver: defaultResolver})
+	if err := r.Merge(
+		&KeyValue{Key: "a", Value: []byte(`{"a": 1, "shared": "a"}`), Format: "json"},
+		&KeyValue{Key: "b", Value: []byte(`{"b": 1, "shared": "b"}`), Format: "json"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Merge(&KeyValue{Key: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Value("b"); ok {
+		t.Fatal("expect b to be removed")
+	}
+	if v, _ := r.Value("shared"); v.Load() != "a" {
+		t.Fatalf("expect shared to fall back to a, got %v", v.Load())
+	}
+}
+
+func TestReader_MergeTOML(t *testing.T) {
+	r := newReader(options{decoder: defaultDecoder, resolver: defaultResolver})
+	if err := r.Merge(&KeyValue{Key: "app.toml", Value: []byte("[server.http]\naddr = \"0.0.0.0\"\n\n[[endpoints]]\nname = \"a\"\n"), Format: "toml"}); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := r.Value("server.http.addr"); !ok || v.Load() != "0.0.0.0" {
+		t.Fatalf("unexpected server.http.addr: %v", v)
+	}
+	if v, ok := r.Value("endpoints[0].name"); !ok || v.Load() != "a" {
+		t.Fatalf("unexpected endpoints[0].name: %v", v)
+	}
+}
@@ -0,0 +1,433 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Schema is the subset of JSON Schema validating the merged config, derived
+// from the tags of a config struct by SchemaOf or parsed from a JSON Schema
+// document by ParseSchema. Minimum and Maximum bound numbers, MinLength and
+// MaxLength strings and MinItems and MaxItems arrays.
+type Schema struct {
+	// Type is one of object, array, string, integer, number and boolean,
+	// any type is allowed if it is empty.
+	Type       string             `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Enum       []interface{}      `json:"enum,omitempty"`
+	Minimum    *float64           `json:"minimum,omitempty"`
+	Maximum    *float64           `json:"maximum,omitempty"`
+	MinLength  *int               `json:"minLength,omitempty"`
+	MaxLength  *int               `json:"maxLength,omitempty"`
+	MinItems   *int               `json:"minItems,omitempty"`
+	MaxItems   *int               `json:"maxItems,omitempty"`
+}
+
+// ParseSchema parses a JSON Schema document, the keywords not in Schema are ignored.
+func ParseSchema(data []byte) (*Schema, error) {
+	s := new(Schema)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+var schemas sync.Map
+
+// SchemaOf derives the schema of the config struct v from the json names and
+// the schema tags of its fields, e.g.
+//
+//	Port  int    `json:"port" schema:"required,min=1,max=65535"`
+//	Level string `json:"level" schema:"enum=debug|info|warn|error"`
+//
+// min and max bound the value of numbers, the length of strings and the
+// number of items of slices. The types decoding themselves, e.g. the well
+// known protobuf types, are of any type.
+func SchemaOf(v interface{}) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	if s, ok := schemas.Load(t); ok {
+		return s.(*Schema), nil
+	}
+	s, err := schemaOf(t)
+	if err != nil {
+		return nil, err
+	}
+	schemas.Store(t, s)
+	return s, nil
+}
+
+// Validate validates the config struct v against the schema derived from it
+// by SchemaOf, the zero values are missing, so that a required field must not
+// be zero. It is the Validator of the schema tags, e.g. WithValidator(Validate).
+func Validate(v interface{}) error {
+	s, err := SchemaOf(v)
+	if err != nil {
+		return err
+	}
+	// the json names of the protobuf messages are the names of the fields
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if err = json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return s.Validate(pruneZero(value))
+}
+
+// WithSchema with the schema the merged config is validated against by Load
+// and on every hot reload afterwards, a reload failing validation is rejected
+// and the previous config is retained.
+func WithSchema(s *Schema) Option {
+	return func(o *options) {
+		o.schema = s
+	}
+}
+
+// Validate validates the value v against the schema, it returns the
+// ValidationError of the first violation with the dotted path of the key.
+func (s *Schema) Validate(v interface{}) error {
+	return s.validate("", v)
+}
+
+func (s *Schema) validate(path string, v interface{}) error {
+	if s.Type != "" && !isType(s.Type, v) {
+		return schemaError(path, "must be of type %s", s.Type)
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		return schemaError(path, "must be one of %v", s.Enum)
+	}
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return s.validateObject(path, value)
+	case []interface{}:
+		if err := checkBounds(path, "items", len(value), s.MinItems, s.MaxItems); err != nil {
+			return err
+		}
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range value {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	case string:
+		return checkBounds(path, "length", len(value), s.MinLength, s.MaxLength)
+	default:
+		if f, ok := toFloat(v); ok {
+			if s.Minimum != nil && f < *s.Minimum {
+				return schemaError(path, "must be >= %v", *s.Minimum)
+			}
+			if s.Maximum != nil && f > *s.Maximum {
+				return schemaError(path, "must be <= %v", *s.Maximum)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(path string, m map[string]interface{}) error {
+	for _, name := range s.Required {
+		if _, ok := lookupKey(m, name); !ok {
+			return schemaError(joinPath(path, name), "is required")
+		}
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v, ok := lookupKey(m, name); ok && v != nil {
+			if err := s.Properties[name].validate(joinPath(path, name), v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookupKey returns the value of name, matched case-insensitively like the
+// json field names and regardless of underscores like the protobuf ones, e.g.
+// readTimeout is read_timeout, if there is no exact match.
+func lookupKey(m map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := m[name]; ok {
+		return v, v != nil
+	}
+	name = foldKey(name)
+	for k, v := range m {
+		if foldKey(k) == name {
+			return v, v != nil
+		}
+	}
+	return nil, false
+}
+
+func foldKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func schemaError(path, format string, args ...interface{}) error {
+	return &ValidationError{Field: path, Err: fmt.Errorf(format, args...)}
+}
+
+func checkBounds(path, what string, n int, min, max *int) error {
+	if min != nil && n < *min {
+		return schemaError(path, "%s must be >= %d", what, *min)
+	}
+	if max != nil && n > *max {
+		return schemaError(path, "%s must be <= %d", what, *max)
+	}
+	return nil
+}
+
+func isType(typ string, v interface{}) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := toFloat(v)
+		return ok
+	case "integer":
+		f, ok := toFloat(v)
+		return ok && f == float64(int64(f))
+	}
+	return true
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	f, isNumber := toFloat(v)
+	for _, e := range enum {
+		if ef, ok := toFloat(e); ok && isNumber {
+			if ef == f {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// toFloat returns the float of the numbers decoded by the different codecs.
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// pruneZero removes the zero values of the maps.
+func pruneZero(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			item = pruneZero(item)
+			if item == nil || isEmpty(item) || reflect.ValueOf(item).IsZero() {
+				delete(value, k)
+				continue
+			}
+			value[k] = item
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = pruneZero(item)
+		}
+	}
+	return v
+}
+
+func isEmpty(v interface{}) bool {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return len(value) == 0
+	case []interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func schemaOf(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshaler) || strings.HasPrefix(t.PkgPath(), "google.golang.org/protobuf/types/known/") {
+		return &Schema{}, nil
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Map:
+		return &Schema{Type: "object"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string"}, nil
+		}
+		items, err := schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	}
+	return &Schema{}, nil
+}
+
+func structSchema(t reflect.Type) (*Schema, error) {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		fs, err := schemaOf(f.Type)
+		if err != nil {
+			return nil, err
+		}
+		required, err := parseSchemaTag(fs, f.Type, f.Tag.Get("schema"))
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid schema tag of field %s: %w", f.Name, err)
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+	return s, nil
+}
+
+// parseSchemaTag sets the constraints of the tag on s, it reports whether the field is required.
+func parseSchemaTag(s *Schema, t reflect.Type, tag string) (required bool, err error) {
+	if tag == "" {
+		return false, nil
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		key, value := opt, ""
+		if i := strings.IndexByte(opt, '='); i >= 0 {
+			key, value = opt[:i], opt[i+1:]
+		}
+		switch key {
+		case "required":
+			required = true
+		case "min", "max":
+			var n float64
+			if n, err = strconv.ParseFloat(value, 64); err != nil {
+				return false, err
+			}
+			setBound(s, key == "min", n)
+		case "enum":
+			for _, e := range strings.Split(value, "|") {
+				var v interface{} = e
+				if s.Type == "integer" || s.Type == "number" {
+					if v, err = strconv.ParseFloat(e, 64); err != nil {
+						return false, err
+					}
+				}
+				s.Enum = append(s.Enum, v)
+			}
+		default:
+			return false, errors.New("unknown option " + key)
+		}
+	}
+	return required, nil
+}
+
+func setBound(s *Schema, min bool, n float64) {
+	i := int(n)
+	switch s.Type {
+	case "string":
+		if min {
+			s.MinLength = &i
+		} else {
+			s.MaxLength = &i
+		}
+	case "array":
+		if min {
+			s.MinItems = &i
+		} else {
+			s.MaxItems = &i
+		}
+	default:
+		if min {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	}
+}
+
+// validateSchema validates the merged config against the schema set by WithSchema.
+func (c *config) validateSchema() error {
+	if c.opts.schema == nil {
+		return nil
+	}
+	var values interface{}
+	if r, ok := c.reader.(*reader); ok {
+		r.lock.Lock()
+		values = r.values
+		r.lock.Unlock()
+	} else {
+		data, err := c.reader.Source()
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(data, &values); err != nil {
+			return err
+		}
+	}
+	return c.opts.schema.Validate(values)
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+type testSchemaServer struct {
+	Addr    string `json:"addr" schema:"required,min=1"`
+	Port    int    `json:"port" schema:"min=1,max=65535"`
+	Network string `json:"network" schema:"enum=tcp|unix"`
+}
+
+type testSchemaConfig struct {
+	Servers []testSchemaServer `json:"servers" schema:"required,min=1"`
+	Level   string             `json:"log_level" schema:"enum=debug|info"`
+}
+
+func TestSchema(t *testing.T) {
+	s, err := SchemaOf(&testSchemaConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		data  string
+		field string
+	}{
+		{"valid", `{"servers":[{"addr":"0.0.0.0","port":8000,"network":"tcp"}],"logLevel":"info"}`, ""},
+		{"required", `{"log_level":"info"}`, "servers"},
+		{"items", `{"servers":[]}`, "servers"},
+		{"nested required", `{"servers":[{"port":8000}]}`, "servers[0].addr"},
+		{"max", `{"servers":[{"addr":"a"},{"addr":"b","port":70000}]}`, "servers[1].port"},
+		{"type", `{"servers":[{"addr":"a","port":"8000"}]}`, "servers[0].port"},
+		{"enum", `{"servers":[{"addr":"a"}],"log_level":"trace"}`, "log_level"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := New(WithSource(newTestJSONSource(test.data)), WithSchema(s))
+			err := c.Load()
+			if test.field == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var ve *ValidationError
+			if !errors.As(err, &ve) || ve.Field != test.field {
+				t.Fatalf("want the violation of %s, got %v", test.field, err)
+			}
+		})
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	s, err := ParseSchema([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {"name": {"type": "string", "maxLength": 3}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Validate(map[string]interface{}{"name": "kratos"})
+	if err == nil || err.Error() != "config validation failed on field name: length must be <= 3" {
+		t.Fatalf("want the violation of name, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(`{"servers":[{"addr":"","port":8000}]}`)), WithValidator(Validate))
+	var v testSchemaConfig
+	var ve *ValidationError
+	if err := c.LoadAndScan(&v); !errors.As(err, &ve) || ve.Field != "servers[0].addr" {
+		t.Fatalf("want the empty addr missing, got %v", err)
+	}
+	v.Servers[0].Addr = "0.0.0.0"
+	if err := Validate(&v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import "encoding/json"
+
+var _ ReadView = (*view)(nil)
+
+// ReadView is an immutable view of the merged config at one point in time,
+// related keys read from the same view are always consistent.
+type ReadView interface {
+	Value(key string) Value
+	Scan(v interface{}) error
+}
+
+type view struct {
+	values    map[string]interface{}
+	delimiter string
+	scan      scanOptions
+}
+
+// Snapshot returns a view of the current config unaffected by later merges.
+// The resolved values are never mutated once published, so taking a
+// snapshot does not copy them.
+func (c *config) Snapshot() ReadView {
+	if r, ok := c.reader.(*reader); ok {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		return &view{values: r.values, delimiter: c.opts.delimiter(), scan: c.opts.scan()}
+	}
+	values := make(map[string]interface{})
+	if data, err := c.reader.Source(); err == nil {
+		_ = json.Unmarshal(data, &values)
+	}
+	return &view{values: values, delimiter: c.opts.delimiter(), scan: c.opts.scan()}
+}
+
+func (v *view) Value(key string) Value {
+	if val, ok := readValue(v.values, key, v.delimiter); ok {
+		return val
+	} else if val != nil {
+		return val
+	}
+	return &errValue{err: ErrNotFound}
+}
+
+func (v *view) Scan(obj interface{}) error {
+	data, err := marshalJSON(convertMap(v.values))
+	if err != nil {
+		return err
+	}
+	return unmarshalJSON(data, obj, v.scan)
+}
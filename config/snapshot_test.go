@@ -0,0 +1,41 @@
+package config
+
+import "testing"
+
+func TestConfig_Snapshot(t *testing.T) {
+	src := newTestPushSource(`{"server":{"host":"a","port":1}}`)
+	c := New(WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	snap := c.Snapshot()
+	src.data = `{"server":{"host":"b","port":2}}`
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	host, _ := snap.Value("server.host").String()
+	port, _ := snap.Value("server.port").Int()
+	if host != "a" || port != 1 {
+		t.Fatalf("snapshot should not see the reload, got %s:%d", host, port)
+	}
+	var conf struct {
+		Server struct {
+			Host string `json:"host"`
+		} `json:"server"`
+	}
+	if err := snap.Scan(&conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Server.Host != "a" {
+		t.Fatalf("unexpected scanned host: %s", conf.Server.Host)
+	}
+	if host, _ = c.Snapshot().Value("server.host").String(); host != "b" {
+		t.Fatalf("new snapshot should see the reload, got %s", host)
+	}
+	if _, err := snap.Value("notexist").String(); err != ErrNotFound {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+}
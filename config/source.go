@@ -1,6 +1,10 @@
 package config
 
+import "fmt"
+
 // KeyValue is config key value.
+// A KeyValue with a nil Value marks Key as deleted, the content previously
+// merged for Key is removed on the next merge.
 type KeyValue struct {
 	Key    string
 	Value  []byte
@@ -13,8 +17,47 @@ type Source interface {
 	Watch() (Watcher, error)
 }
 
-// Watcher watches a source for changes.
+// Watcher watches a source for changes. Stop must unblock a pending Next,
+// which returns an error then, e.g. context.Canceled, as Close stops the
+// watchers and waits for them, and a Next never returning leaks the goroutine
+// calling it.
 type Watcher interface {
 	Next() ([]*KeyValue, error)
 	Stop() error
 }
+
+// Prioritized is implemented by sources with an explicit merge priority.
+// Keys of a source with a higher priority are never overridden by a source
+// with a lower one, sources of the same priority are merged in load order.
+// Sources without a priority have priority 0.
+type Prioritized interface {
+	Priority() int
+}
+
+type prioritySource struct {
+	Source
+	priority int
+}
+
+func (s *prioritySource) Priority() int { return s.priority }
+
+// ChangeEvent describes a change pushed by a source watcher.
+type ChangeEvent struct {
+	// Source is the name of the source, its String method if it
+	// implements fmt.Stringer or its type otherwise.
+	Source string
+	// Keys are the sorted keys changed by the merge.
+	Keys []string
+	// Err is the error that rejected the change.
+	Err error
+}
+
+func sourceName(s Source) string {
+	if p, ok := s.(*prioritySource); ok {
+		s = p.Source
+	}
+	if n, ok := s.(fmt.Stringer); ok {
+		return n.String()
+	}
+	return fmt.Sprintf("%T", s)
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Validator validates a scanned config struct.
+type Validator func(interface{}) error
+
+// ValidationError is returned when the scanned config fails validation.
+type ValidationError struct {
+	// Field is the path of the failing field, it is empty if the
+	// validator error does not carry one.
+	Field string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("config validation failed: %v", e.Err)
+	}
+	return fmt.Sprintf("config validation failed on field %s: %v", e.Field, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// newValidationError wraps err and extracts the failing field path
+// from errors exposing Namespace() or Field(), such as the field errors
+// of go-playground/validator.
+func newValidationError(err error) *ValidationError {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return ve
+	}
+	var (
+		ns    interface{ Namespace() string }
+		field interface{ Field() string }
+	)
+	e := &ValidationError{Err: err}
+	if errors.As(err, &ns) {
+		e.Field = ns.Namespace()
+	} else if errors.As(err, &field) {
+		e.Field = field.Field()
+	}
+	return e
+}
+
+// validate validates the current config against the schema, then scans it
+// into a new instance of the type registered by LoadAndScan and runs the
+// validator against it.
+func (c *config) validate() error {
+	if err := c.validateSchema(); err != nil {
+		return err
+	}
+	if c.opts.validator == nil || c.scanType == nil {
+		return nil
+	}
+	v := reflect.New(c.scanType).Interface()
+	if err := c.Scan(v); err != nil {
+		return err
+	}
+	if err := c.opts.validator(v); err != nil {
+		return newValidationError(err)
+	}
+	return nil
+}
+
+// snapshot saves the reader state and returns a function restoring it,
+// which is used to roll back a merge rejected by the validator.
+func (c *config) snapshot() func() {
+	r, ok := c.reader.(*reader)
+	if !ok {
+		return func() {}
+	}
+	r.lock.Lock()
+	raw, values, entries := r.raw, r.values, r.entries
+	r.lock.Unlock()
+	return func() {
+		r.lock.Lock()
+		r.raw, r.values, r.entries = raw, values, entries
+		r.lock.Unlock()
+	}
+}
@@ -24,6 +24,11 @@ type Value interface {
 	Float() (float64, error)
 	String() (string, error)
 	Duration() (time.Duration, error)
+	BoolDefault(bool) bool
+	IntDefault(int64) int64
+	FloatDefault(float64) float64
+	StringDefault(string) string
+	DurationDefault(time.Duration) time.Duration
 	Slice() ([]Value, error)
 	Map() (map[string]Value, error)
 	Scan(interface{}) error
@@ -61,30 +66,37 @@ func (v *atomicValue) Int() (int64, error) {
 	return 0, fmt.Errorf("type assert to %v failed", reflect.TypeOf(v.Load()))
 }
 
+// newValue returns the Value of val, the Value of nil loads nil.
+func newValue(val interface{}) *atomicValue {
+	a := &atomicValue{}
+	if val != nil {
+		a.Store(val)
+	}
+	return a
+}
+
+// Slice returns the Values of the elements, ErrTypeAssert if it is not a slice.
 func (v *atomicValue) Slice() ([]Value, error) {
 	if vals, ok := v.Load().([]interface{}); ok {
-		var slices []Value
+		slices := make([]Value, 0, len(vals))
 		for _, val := range vals {
-			a := &atomicValue{}
-			a.Store(val)
-			slices = append(slices, a)
+			slices = append(slices, newValue(val))
 		}
 		return slices, nil
 	}
-	return nil, fmt.Errorf("type assert to %v failed", reflect.TypeOf(v.Load()))
+	return nil, fmt.Errorf("type assert to %v failed: %w", reflect.TypeOf(v.Load()), ErrTypeAssert)
 }
 
+// Map returns the Values of the keys, ErrTypeAssert if it is not a map.
 func (v *atomicValue) Map() (map[string]Value, error) {
 	if vals, ok := v.Load().(map[string]interface{}); ok {
-		m := make(map[string]Value)
+		m := make(map[string]Value, len(vals))
 		for key, val := range vals {
-			a := &atomicValue{}
-			a.Store(val)
-			m[key] = a
+			m[key] = newValue(val)
 		}
 		return m, nil
 	}
-	return nil, fmt.Errorf("type assert to %v failed", reflect.TypeOf(v.Load()))
+	return nil, fmt.Errorf("type assert to %v failed: %w", reflect.TypeOf(v.Load()), ErrTypeAssert)
 }
 
 func (v *atomicValue) Float() (float64, error) {
@@ -127,6 +139,41 @@ func (v *atomicValue) Duration() (time.Duration, error) {
 	return time.Duration(val), nil
 }
 
+func (v *atomicValue) BoolDefault(def bool) bool {
+	if val, err := v.Bool(); err == nil {
+		return val
+	}
+	return def
+}
+
+func (v *atomicValue) IntDefault(def int64) int64 {
+	if val, err := v.Int(); err == nil {
+		return val
+	}
+	return def
+}
+
+func (v *atomicValue) FloatDefault(def float64) float64 {
+	if val, err := v.Float(); err == nil {
+		return val
+	}
+	return def
+}
+
+func (v *atomicValue) StringDefault(def string) string {
+	if val, err := v.String(); err == nil {
+		return val
+	}
+	return def
+}
+
+func (v *atomicValue) DurationDefault(def time.Duration) time.Duration {
+	if val, err := v.Duration(); err == nil {
+		return val
+	}
+	return def
+}
+
 func (v *atomicValue) Scan(obj interface{}) error {
 	data, err := json.Marshal(v.Load())
 	if err != nil {
@@ -142,13 +189,18 @@ type errValue struct {
 	err error
 }
 
-func (v errValue) Bool() (bool, error)              { return false, v.err }
-func (v errValue) Int() (int64, error)              { return 0, v.err }
-func (v errValue) Float() (float64, error)          { return 0.0, v.err }
-func (v errValue) Duration() (time.Duration, error) { return 0, v.err }
-func (v errValue) String() (string, error)          { return "", v.err }
-func (v errValue) Scan(interface{}) error           { return v.err }
-func (v errValue) Load() interface{}                { return nil }
-func (v errValue) Store(interface{})                {}
-func (v errValue) Slice() ([]Value, error)          { return nil, v.err }
-func (v errValue) Map() (map[string]Value, error)   { return nil, v.err }
+func (v errValue) Bool() (bool, error)                             { return false, v.err }
+func (v errValue) Int() (int64, error)                             { return 0, v.err }
+func (v errValue) Float() (float64, error)                         { return 0.0, v.err }
+func (v errValue) Duration() (time.Duration, error)                { return 0, v.err }
+func (v errValue) String() (string, error)                         { return "", v.err }
+func (v errValue) Scan(interface{}) error                          { return v.err }
+func (v errValue) BoolDefault(def bool) bool                       { return def }
+func (v errValue) IntDefault(def int64) int64                      { return def }
+func (v errValue) FloatDefault(def float64) float64                { return def }
+func (v errValue) StringDefault(def string) string                 { return def }
+func (v errValue) DurationDefault(def time.Duration) time.Duration { return def }
+func (v errValue) Load() interface{}                               { return nil }
+func (v errValue) Store(interface{})                               {}
+func (v errValue) Slice() ([]Value, error)                         { return nil, v.err }
+func (v errValue) Map() (map[string]Value, error)                  { return nil, v.err }
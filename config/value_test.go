@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -206,6 +207,34 @@ func Test_atomicValue_Map(t *testing.T) {
 	}
 }
 
+func Test_atomicValue_Traverse(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(`{"servers":[{"addr":"0.0.0.0:8000","tls":null}]}`)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	servers, err := c.Value("servers").Slice()
+	if err != nil || len(servers) != 1 {
+		t.Fatalf("want 1 server, got %v %v", servers, err)
+	}
+	server, err := servers[0].Map()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr, _ := server["addr"].String(); addr != "0.0.0.0:8000" {
+		t.Fatalf("want the addr, got %q", addr)
+	}
+	if v := server["tls"].Load(); v != nil {
+		t.Fatalf("want nil, got %v", v)
+	}
+	if _, err = server["addr"].Map(); !errors.Is(err, ErrTypeAssert) {
+		t.Fatalf("want ErrTypeAssert, got %v", err)
+	}
+	if _, err = server["addr"].Slice(); !errors.Is(err, ErrTypeAssert) {
+		t.Fatalf("want ErrTypeAssert, got %v", err)
+	}
+}
+
 func Test_atomicValue_Scan(t *testing.T) {
 	var err error
 	v := atomicValue{}
@@ -223,3 +252,44 @@ func Test_atomicValue_Scan(t *testing.T) {
 		t.Fatal(`err is not nil`)
 	}
 }
+
+func Test_atomicValue_Default(t *testing.T) {
+	v := atomicValue{}
+	v.Store("5s")
+	if s := v.StringDefault("x"); s != "5s" {
+		t.Fatalf(`s is not equal to "5s": %s`, s)
+	}
+	if i := v.IntDefault(10); i != 10 {
+		t.Fatalf(`i is not equal to 10: %d`, i)
+	}
+	if b := v.BoolDefault(true); !b {
+		t.Fatal(`b is not equal to true`)
+	}
+	v = atomicValue{}
+	v.Store(int64(3))
+	if d := v.DurationDefault(time.Second); d != 3 {
+		t.Fatalf(`d is not equal to 3: %v`, d)
+	}
+	if f := v.FloatDefault(1.5); f != 3 {
+		t.Fatalf(`f is not equal to 3: %v`, f)
+	}
+}
+
+func Test_errValue_Default(t *testing.T) {
+	var v Value = &errValue{err: ErrNotFound}
+	if s := v.StringDefault("x"); s != "x" {
+		t.Fatalf(`s is not equal to "x": %s`, s)
+	}
+	if i := v.IntDefault(10); i != 10 {
+		t.Fatalf(`i is not equal to 10: %d`, i)
+	}
+	if b := v.BoolDefault(true); !b {
+		t.Fatal(`b is not equal to true`)
+	}
+	if d := v.DurationDefault(5 * time.Second); d != 5*time.Second {
+		t.Fatalf(`d is not equal to 5s: %v`, d)
+	}
+	if f := v.FloatDefault(1.5); f != 1.5 {
+		t.Fatalf(`f is not equal to 1.5: %v`, f)
+	}
+}
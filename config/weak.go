@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// weakDecode returns data, the json decoded into a value of type t, with the
+// strings, the numbers and the bools converted to the types of the fields.
+func weakDecode(data []byte, t reflect.Type) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	converted, err := weakConvert(raw, t, "")
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(converted)
+}
+
+// weakConvert converts raw to the json of the type t, key is the path of raw
+// reported by the errors.
+func weakConvert(raw interface{}, t reflect.Type, key string) (interface{}, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType {
+		if s, ok := raw.(string); ok {
+			d, err := time.ParseDuration(strings.TrimSpace(s))
+			if err != nil {
+				return nil, weakError(raw, t, key)
+			}
+			return int64(d), nil
+		}
+	}
+	// the types decoding themselves take the values as they are
+	if t.Implements(jsonUnmarshalerType) || reflect.PtrTo(t).Implements(jsonUnmarshalerType) ||
+		t.Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return raw, nil
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return raw, nil
+		}
+		fields := make(map[string]reflect.Type)
+		structFields(t, fields)
+		for k, v := range m {
+			ft, ok := lookupField(fields, k)
+			if !ok {
+				continue
+			}
+			cv, err := weakConvert(v, ft, joinPath(key, k))
+			if err != nil {
+				return nil, err
+			}
+			m[k] = cv
+		}
+		return m, nil
+	case reflect.Map:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return raw, nil
+		}
+		for k, v := range m {
+			cv, err := weakConvert(v, t.Elem(), joinPath(key, k))
+			if err != nil {
+				return nil, err
+			}
+			m[k] = cv
+		}
+		return m, nil
+	case reflect.Slice, reflect.Array:
+		s, ok := raw.([]interface{})
+		if !ok {
+			return raw, nil
+		}
+		for i, v := range s {
+			cv, err := weakConvert(v, t.Elem(), fmt.Sprintf("%s[%d]", key, i))
+			if err != nil {
+				return nil, err
+			}
+			s[i] = cv
+		}
+		return s, nil
+	case reflect.String:
+		switch v := raw.(type) {
+		case json.Number:
+			return v.String(), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	case reflect.Bool:
+		switch v := raw.(type) {
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, weakError(raw, t, key)
+			}
+			return b, nil
+		case json.Number:
+			// only 0 and 1 are unambiguous
+			b, err := strconv.ParseBool(v.String())
+			if err != nil {
+				return nil, weakError(raw, t, key)
+			}
+			return b, nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		switch v := raw.(type) {
+		case string:
+			s := strings.TrimSpace(v)
+			if !validNumber(s, t) {
+				return nil, weakError(raw, t, key)
+			}
+			return json.Number(s), nil
+		case bool:
+			if v {
+				return json.Number("1"), nil
+			}
+			return json.Number("0"), nil
+		}
+	}
+	return raw, nil
+}
+
+// validNumber reports whether s is a number in the range of the type t.
+func validNumber(s string, t reflect.Type) bool {
+	var err error
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(s, t.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(s, 10, t.Bits())
+	default:
+		_, err = strconv.ParseInt(s, 10, t.Bits())
+	}
+	return err == nil
+}
+
+func weakError(raw interface{}, t reflect.Type, key string) error {
+	return fmt.Errorf("config: cannot convert %q of key %s to %s", fmt.Sprint(raw), key, t)
+}
+
+// structFields collects the json names of the fields of the struct t, the
+// fields of the embedded structs are the ones of the parent unless it has
+// fields of the same names.
+func structFields(t reflect.Type, fields map[string]reflect.Type) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	for _, et := range embedded {
+		inner := make(map[string]reflect.Type)
+		structFields(et, inner)
+		for name, ft := range inner {
+			if _, ok := fields[name]; !ok {
+				fields[name] = ft
+			}
+		}
+	}
+}
+
+// lookupField returns the type of the field of the key, matched like
+// encoding/json, exactly or else case insensitively.
+func lookupField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if t, ok := fields[key]; ok {
+		return t, true
+	}
+	for name, t := range fields {
+		if strings.EqualFold(name, key) {
+			return t, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testWeakEmbed struct {
+	Debug bool `json:"debug"`
+}
+
+type testWeakConfig struct {
+	testWeakEmbed
+	Port    int               `json:"port"`
+	Ratio   float64           `json:"ratio"`
+	Count   *uint8            `json:"count"`
+	Name    string            `json:"name"`
+	Timeout time.Duration     `json:"timeout"`
+	Ports   []int             `json:"ports"`
+	Limits  map[string]int64  `json:"limits"`
+	Labels  map[string]string `json:"labels"`
+	Any     interface{}       `json:"any"`
+}
+
+func TestConfig_WeakTypeConversion(t *testing.T) {
+	data := `{
+		"debug": "true",
+		"port": "8080",
+		"ratio": " 0.5 ",
+		"count": "3",
+		"name": 42,
+		"timeout": "1.5s",
+		"ports": ["80", 443],
+		"limits": {"cpu": "2"},
+		"labels": {"enabled": true},
+		"any": "1"
+	}`
+	c := New(WithSource(newTestJSONSource(data)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	var strict testWeakConfig
+	if err := c.Scan(&strict); err == nil {
+		t.Fatal("want the types strict by default")
+	}
+
+	c = New(WithSource(newTestJSONSource(data)), WithWeakTypeConversion())
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	var got testWeakConfig
+	if err := c.Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Debug || got.Port != 8080 || got.Ratio != 0.5 || got.Count == nil || *got.Count != 3 ||
+		got.Name != "42" || got.Timeout != 1500*time.Millisecond || got.Any != "1" {
+		t.Fatalf("unexpected scanned config: %+v", got)
+	}
+	if len(got.Ports) != 2 || got.Ports[0] != 80 || got.Ports[1] != 443 {
+		t.Fatalf("unexpected ports: %v", got.Ports)
+	}
+	if got.Limits["cpu"] != 2 || got.Labels["enabled"] != "true" {
+		t.Fatalf("unexpected maps: %v %v", got.Limits, got.Labels)
+	}
+	var port int
+	if err := c.ScanKey("port", &port); err != nil || port != 8080 {
+		t.Fatalf("want the key scanned weakly, got %d, %v", port, err)
+	}
+}
+
+func TestConfig_WeakTypeConversionError(t *testing.T) {
+	tests := []struct {
+		data string
+		key  string
+	}{
+		{`{"port":"http"}`, "port"},
+		{`{"count":"256"}`, "count"},
+		{`{"debug":"yes"}`, "debug"},
+		{`{"debug":2}`, "debug"},
+		{`{"ports":["80","x"]}`, "ports[1]"},
+		{`{"limits":{"cpu":"two"}}`, "limits.cpu"},
+		{`{"timeout":"soon"}`, "timeout"},
+	}
+	for _, test := range tests {
+		c := New(WithSource(newTestJSONSource(test.data)), WithWeakTypeConversion())
+		if err := c.Load(); err != nil {
+			t.Fatal(err)
+		}
+		var v testWeakConfig
+		err := c.Scan(&v)
+		if err == nil || !strings.Contains(err.Error(), "key "+test.key+" ") {
+			t.Fatalf("%s: want an error naming the key %s, got %v", test.data, test.key, err)
+		}
+	}
+}
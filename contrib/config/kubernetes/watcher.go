@@ -13,28 +13,44 @@ import (
 type watcher struct {
 	k       *kube
 	watcher watch.Interface
+
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func newWatcher(k *kube) (config.Watcher, error) {
-	w, err := k.client.CoreV1().ConfigMaps(k.opts.Namespace).Watch(context.Background(), metav1.ListOptions{
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := k.client.CoreV1().ConfigMaps(k.opts.Namespace).Watch(ctx, metav1.ListOptions{
 		LabelSelector: k.opts.LabelSelector,
 		FieldSelector: k.opts.FieldSelector,
 	})
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 	return &watcher{
 		k:       k,
 		watcher: w,
+		ctx:     ctx,
+		cancel:  cancel,
 	}, nil
 }
 
 func (w *watcher) Next() ([]*config.KeyValue, error) {
 ResultChan:
-	ch := <-w.watcher.ResultChan()
+	var ch watch.Event
+	select {
+	case <-w.ctx.Done():
+		w.watcher.Stop()
+		return nil, w.ctx.Err()
+	case ch = <-w.watcher.ResultChan():
+	}
 	if ch.Object == nil {
+		if err := w.ctx.Err(); err != nil {
+			return nil, err
+		}
 		// 重新获取watcher
-		k8sWatcher, err := w.k.client.CoreV1().ConfigMaps(w.k.opts.Namespace).Watch(context.Background(), metav1.ListOptions{
+		k8sWatcher, err := w.k.client.CoreV1().ConfigMaps(w.k.opts.Namespace).Watch(w.ctx, metav1.ListOptions{
 			LabelSelector: w.k.opts.LabelSelector,
 			FieldSelector: w.k.opts.FieldSelector,
 		})
@@ -54,7 +70,8 @@ ResultChan:
 	return w.k.configMap(*cm), nil
 }
 
+// Stop stops the watch of the config maps and unblocks Next.
 func (w *watcher) Stop() error {
-	w.watcher.Stop()
+	w.cancel()
 	return nil
 }
@@ -5,6 +5,7 @@ go 1.16
 require (
 	github.com/go-kratos/kratos/v2 v2.2.2
 	github.com/vmihailenco/msgpack/v5 v5.3.4
+	google.golang.org/protobuf v1.27.1
 )
 
 replace github.com/go-kratos/kratos/v2 => ../../../
@@ -1,27 +1,55 @@
 package msgpack
 
 import (
-	"github.com/vmihailenco/msgpack/v5"
+	"bytes"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
 )
 
-// Name is the name registered for the msgpack compressor.
+// Name is the name registered for the msgpack codec.
 const Name = "msgpack"
 
+// structTag is the tag of the struct fields, the same as the json codec.
+const structTag = "json"
+
 func init() {
 	encoding.RegisterCodec(codec{})
 }
 
-// codec is a Codec implementation with msgpack.
+// codec is a Codec implementation with msgpack, proto messages are encoded as
+// maps of their populated fields keyed by the json names, other values are
+// encoded by reflection.
 type codec struct{}
 
 func (codec) Marshal(v interface{}) ([]byte, error) {
-	return msgpack.Marshal(v)
+	var buf bytes.Buffer
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+	enc.Reset(&buf)
+	var err error
+	if m, ok := v.(proto.Message); ok {
+		err = encodeMessage(enc, m.ProtoReflect())
+	} else {
+		enc.SetCustomStructTag(structTag)
+		err = enc.Encode(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 func (codec) Unmarshal(data []byte, v interface{}) error {
-	return msgpack.Unmarshal(data, v)
+	dec := msgpack.GetDecoder()
+	defer msgpack.PutDecoder(dec)
+	dec.Reset(bytes.NewReader(data))
+	if m, ok := v.(proto.Message); ok {
+		return decodeMessage(dec, m.ProtoReflect())
+	}
+	dec.SetCustomStructTag(structTag)
+	return dec.Decode(v)
 }
 
 func (codec) Name() string {
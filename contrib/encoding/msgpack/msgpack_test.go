@@ -3,6 +3,17 @@ package msgpack
 import (
 	"reflect"
 	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+	"google.golang.org/protobuf/types/known/typepb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/encoding/json"
+	"github.com/go-kratos/kratos/v2/errors"
 )
 
 type loginRequest struct {
@@ -15,6 +26,33 @@ type testModel struct {
 	Name string
 }
 
+type testEmbed struct {
+	Level1a int `json:"a"`
+	Level1b int `json:"b"`
+}
+
+type testMessage struct {
+	Field1 string                 `json:"a"`
+	Field2 []int64                `json:"b"`
+	Embed  *testEmbed             `json:"embed,omitempty"`
+	Extra  map[string]interface{} `json:"extra"`
+}
+
+func newType() *typepb.Type {
+	option, _ := anypb.New(wrapperspb.Bytes([]byte("123")))
+	return &typepb.Type{
+		Name: "kratos.Complex",
+		Fields: []*typepb.Field{
+			{Kind: typepb.Field_TYPE_INT64, Cardinality: typepb.Field_CARDINALITY_OPTIONAL, Number: 1, Name: "id", JsonName: "id"},
+			{Kind: typepb.Field_TYPE_STRING, Cardinality: typepb.Field_CARDINALITY_REPEATED, Number: 2, Name: "simples", Packed: true},
+		},
+		Oneofs:        []string{"a", "b"},
+		Options:       []*typepb.Option{{Name: "bytes", Value: option}},
+		SourceContext: &sourcecontextpb.SourceContext{FileName: "complex.proto"},
+		Syntax:        typepb.Syntax_SYNTAX_PROTO3,
+	}
+}
+
 func TestName(t *testing.T) {
 	c := new(codec)
 	if !reflect.DeepEqual("msgpack", c.Name()) {
@@ -61,3 +99,130 @@ func TestCodec(t *testing.T) {
 		t.Errorf("Name should be %s, but got %s", req.UserName, request.UserName)
 	}
 }
+
+func TestCodec_Registered(t *testing.T) {
+	if got := encoding.GetCodec(Name); got == nil || got.Name() != Name {
+		t.Fatalf("want the registered codec %s, got %v", Name, got)
+	}
+}
+
+func TestCodec_Struct(t *testing.T) {
+	in := &testMessage{
+		Field1: "kratos",
+		Field2: []int64{1, 2, 3},
+		Embed:  &testEmbed{Level1a: 1, Level1b: 2},
+		Extra:  map[string]interface{}{"name": "kratos", "tags": []interface{}{"a", "b"}},
+	}
+	data, err := (codec{}).Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys map[string]interface{}
+	if err = msgpack.Unmarshal(data, &keys); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := keys["a"]; !ok {
+		t.Fatalf("want the fields keyed by the json tags, got %v", keys)
+	}
+	out := new(testMessage)
+	if err = (codec{}).Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("want %+v, got %+v", in, out)
+	}
+}
+
+func TestCodec_Proto(t *testing.T) {
+	for _, in := range []proto.Message{
+		newType(),
+		errors.New(503, "UNAVAILABLE", "try again").WithMetadata(map[string]string{"region": "sh"}),
+	} {
+		data, err := (codec{}).Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := in.ProtoReflect().New().Interface()
+		if err = (codec{}).Unmarshal(data, out); err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(in, out) {
+			t.Fatalf("want %v, got %v", in, out)
+		}
+	}
+}
+
+func TestCodec_ProtoNames(t *testing.T) {
+	data, err := msgpack.Marshal(map[string]interface{}{
+		"name":           "kratos.Complex",
+		"source_context": map[string]interface{}{"file_name": "complex.proto"},
+		"unknown":        []interface{}{1, "a"},
+		"oneofs":         nil,
+		"syntax":         1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(typepb.Type)
+	if err = (codec{}).Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	want := &typepb.Type{
+		Name:          "kratos.Complex",
+		SourceContext: &sourcecontextpb.SourceContext{FileName: "complex.proto"},
+		Syntax:        typepb.Syntax_SYNTAX_PROTO3,
+	}
+	if !proto.Equal(want, out) {
+		t.Fatalf("want %v, got %v", want, out)
+	}
+}
+
+func benchmarkMarshal(b *testing.B, c encoding.Codec, v interface{}) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Marshal(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkUnmarshal(b *testing.B, c encoding.Codec, v interface{}, newValue func() interface{}) {
+	data, err := c.Marshal(v)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.Unmarshal(data, newValue()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+var benchmarkMap = map[string]interface{}{
+	"id":     int64(2233),
+	"name":   "kratos",
+	"tags":   []interface{}{"a", "b", "c"},
+	"labels": map[string]interface{}{"region": "sh", "zone": "sh-1", "weight": int64(10)},
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	codecs := []encoding.Codec{codec{}, encoding.GetCodec(json.Name)}
+	for _, c := range codecs {
+		b.Run(c.Name()+"/map", func(b *testing.B) { benchmarkMarshal(b, c, benchmarkMap) })
+		b.Run(c.Name()+"/proto", func(b *testing.B) { benchmarkMarshal(b, c, newType()) })
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	codecs := []encoding.Codec{codec{}, encoding.GetCodec(json.Name)}
+	for _, c := range codecs {
+		b.Run(c.Name()+"/map", func(b *testing.B) {
+			benchmarkUnmarshal(b, c, benchmarkMap, func() interface{} { return new(map[string]interface{}) })
+		})
+		b.Run(c.Name()+"/proto", func(b *testing.B) {
+			benchmarkUnmarshal(b, c, newType(), func() interface{} { return new(typepb.Type) })
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package msgpack
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// encodeMessage encodes the populated fields of the message as a map keyed by
+// the json names, in the order of the field numbers.
+func encodeMessage(enc *msgpack.Encoder, m protoreflect.Message) error {
+	var fds []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		fds = append(fds, fd)
+		return true
+	})
+	sort.Slice(fds, func(i, j int) bool { return fds[i].Number() < fds[j].Number() })
+	if err := enc.EncodeMapLen(len(fds)); err != nil {
+		return err
+	}
+	for _, fd := range fds {
+		if err := enc.EncodeString(fd.JSONName()); err != nil {
+			return err
+		}
+		if err := encodeField(enc, fd, m.Get(fd)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeField(enc *msgpack.Encoder, fd protoreflect.FieldDescriptor, v protoreflect.Value) error {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		if err := enc.EncodeArrayLen(list.Len()); err != nil {
+			return err
+		}
+		for i := 0; i < list.Len(); i++ {
+			if err := encodeSingular(enc, fd, list.Get(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case fd.IsMap():
+		mp := v.Map()
+		if err := enc.EncodeMapLen(mp.Len()); err != nil {
+			return err
+		}
+		var err error
+		mp.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			if err = encodeSingular(enc, fd.MapKey(), k.Value()); err != nil {
+				return false
+			}
+			err = encodeSingular(enc, fd.MapValue(), v)
+			return err == nil
+		})
+		return err
+	default:
+		return encodeSingular(enc, fd, v)
+	}
+}
+
+func encodeSingular(enc *msgpack.Encoder, fd protoreflect.FieldDescriptor, v protoreflect.Value) error {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return enc.EncodeBool(v.Bool())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return enc.EncodeInt(v.Int())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return enc.EncodeUint(v.Uint())
+	case protoreflect.FloatKind:
+		return enc.EncodeFloat32(float32(v.Float()))
+	case protoreflect.DoubleKind:
+		return enc.EncodeFloat64(v.Float())
+	case protoreflect.StringKind:
+		return enc.EncodeString(v.String())
+	case protoreflect.BytesKind:
+		return enc.EncodeBytes(v.Bytes())
+	case protoreflect.EnumKind:
+		return enc.EncodeInt(int64(v.Enum()))
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return encodeMessage(enc, v.Message())
+	default:
+		return fmt.Errorf("msgpack: unsupported field kind %v of %s", fd.Kind(), fd.FullName())
+	}
+}
+
+// decodeMessage decodes a map into the fields of the message, the keys are
+// the json or the proto names of the fields and the unknown ones are skipped.
+func decodeMessage(dec *msgpack.Decoder, m protoreflect.Message) error {
+	n, err := dec.DecodeMapLen()
+	if err != nil {
+		return err
+	}
+	fields := m.Descriptor().Fields()
+	for i := 0; i < n; i++ {
+		name, err := dec.DecodeString()
+		if err != nil {
+			return err
+		}
+		fd := fields.ByJSONName(name)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(name))
+		}
+		if fd == nil {
+			if err = dec.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = decodeField(dec, m, fd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeField(dec *msgpack.Decoder, m protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	c, err := dec.PeekCode()
+	if err != nil {
+		return err
+	}
+	if c == msgpcode.Nil {
+		m.Clear(fd)
+		return dec.DecodeNil()
+	}
+	switch {
+	case fd.IsList():
+		n, err := dec.DecodeArrayLen()
+		if err != nil {
+			return err
+		}
+		list := m.Mutable(fd).List()
+		for i := 0; i < n; i++ {
+			v, err := decodeSingular(dec, fd, list.NewElement())
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+		return nil
+	case fd.IsMap():
+		n, err := dec.DecodeMapLen()
+		if err != nil {
+			return err
+		}
+		mp := m.Mutable(fd).Map()
+		for i := 0; i < n; i++ {
+			k, err := decodeSingular(dec, fd.MapKey(), protoreflect.Value{})
+			if err != nil {
+				return err
+			}
+			v, err := decodeSingular(dec, fd.MapValue(), mp.NewValue())
+			if err != nil {
+				return err
+			}
+			mp.Set(k.MapKey(), v)
+		}
+		return nil
+	case fd.Message() != nil:
+		_, err := decodeSingular(dec, fd, m.Mutable(fd))
+		return err
+	default:
+		v, err := decodeSingular(dec, fd, protoreflect.Value{})
+		if err != nil {
+			return err
+		}
+		m.Set(fd, v)
+		return nil
+	}
+}
+
+// decodeSingular decodes a value of the kind of the field, msg is the mutable
+// message decoded into for the message kinds.
+func decodeSingular(dec *msgpack.Decoder, fd protoreflect.FieldDescriptor, msg protoreflect.Value) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		v, err := dec.DecodeBool()
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := dec.DecodeInt32()
+		return protoreflect.ValueOfInt32(v), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := dec.DecodeInt64()
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := dec.DecodeUint32()
+		return protoreflect.ValueOfUint32(v), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := dec.DecodeUint64()
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := dec.DecodeFloat32()
+		return protoreflect.ValueOfFloat32(v), err
+	case protoreflect.DoubleKind:
+		v, err := dec.DecodeFloat64()
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.StringKind:
+		v, err := dec.DecodeString()
+		return protoreflect.ValueOfString(v), err
+	case protoreflect.BytesKind:
+		v, err := dec.DecodeBytes()
+		return protoreflect.ValueOfBytes(v), err
+	case protoreflect.EnumKind:
+		v, err := dec.DecodeInt32()
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), err
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return msg, decodeMessage(dec, msg.Message())
+	default:
+		return protoreflect.Value{}, fmt.Errorf("msgpack: unsupported field kind %v of %s", fd.Kind(), fd.FullName())
+	}
+}
@@ -0,0 +1,126 @@
+package otel
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// defaultBuckets is the default bucket boundaries in seconds of the duration histogram.
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Option is metrics option.
+type Option func(*options)
+
+// WithBuckets with the bucket boundaries in seconds of the duration histogram.
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+type options struct {
+	buckets []float64
+}
+
+// recorder records the metrics of a side.
+type recorder struct {
+	// counter: <client/server>_requests_code_total{kind, operation, code}
+	requests metric.Int64Counter
+	// counter: <client/server>_requests_errors_total{kind, operation, code}
+	errors metric.Int64Counter
+	// histogram: <client/server>_requests_seconds{kind, operation, code}
+	seconds metric.Float64Histogram
+}
+
+func newRecorder(meter metric.Meter, side string, opts []Option) *recorder {
+	o := options{buckets: defaultBuckets}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var (
+		r   recorder
+		err error
+	)
+	// the instruments are no-op ones if they fail to be created
+	if r.requests, err = meter.Int64Counter(side+"_requests_code_total",
+		metric.WithDescription("The total number of the requests."),
+	); err != nil {
+		otel.Handle(err)
+	}
+	if r.errors, err = meter.Int64Counter(side+"_requests_errors_total",
+		metric.WithDescription("The total number of the failed requests."),
+	); err != nil {
+		otel.Handle(err)
+	}
+	if r.seconds, err = meter.Float64Histogram(side+"_requests_seconds",
+		metric.WithDescription("The duration of the requests."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(o.buckets...),
+	); err != nil {
+		otel.Handle(err)
+	}
+	return &r
+}
+
+func (r *recorder) record(ctx context.Context, kind, operation string, err error, d time.Duration) {
+	attrs := metric.WithAttributes(
+		attribute.String("kind", kind),
+		attribute.String("operation", operation),
+		attribute.String("code", strconv.Itoa(errors.Code(err))),
+	)
+	r.requests.Add(ctx, 1, attrs)
+	if err != nil {
+		r.errors.Add(ctx, 1, attrs)
+	}
+	r.seconds.Record(ctx, d.Seconds(), attrs)
+}
+
+// Server is middleware server-side metrics recorded by meter.
+func Server(meter metric.Meter, opts ...Option) middleware.Middleware {
+	r := newRecorder(meter, "server", opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var (
+				kind      string
+				operation string
+			)
+			startTime := time.Now()
+			if info, ok := transport.FromServerContext(ctx); ok {
+				kind = info.Kind().String()
+				operation = info.Operation()
+			}
+			reply, err := handler(ctx, req)
+			r.record(ctx, kind, operation, err, time.Since(startTime))
+			return reply, err
+		}
+	}
+}
+
+// Client is middleware client-side metrics recorded by meter.
+func Client(meter metric.Meter, opts ...Option) middleware.Middleware {
+	r := newRecorder(meter, "client", opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var (
+				kind      string
+				operation string
+			)
+			startTime := time.Now()
+			if info, ok := transport.FromClientContext(ctx); ok {
+				kind = info.Kind().String()
+				operation = info.Operation()
+			}
+			reply, err := handler(ctx, req)
+			r.record(ctx, kind, operation, err, time.Since(startTime))
+			return reply, err
+		}
+	}
+}
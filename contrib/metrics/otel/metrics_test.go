@@ -0,0 +1,120 @@
+package otel
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type testTransport struct {
+	transport.Transporter
+	kind      transport.Kind
+	operation string
+}
+
+func (tr *testTransport) Kind() transport.Kind { return tr.kind }
+func (tr *testTransport) Operation() string    { return tr.operation }
+
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	res := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			res[m.Name] = m.Data
+		}
+	}
+	return res
+}
+
+func TestServer(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	buckets := []float64{0.1, 1}
+	next := Server(meter, WithBuckets(buckets...))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if req == "fail" {
+			return nil, errors.BadRequest("BAD", "bad")
+		}
+		return "ok", nil
+	})
+	ctx := transport.NewServerContext(context.Background(), &testTransport{kind: transport.KindHTTP, operation: "/test"})
+	for _, req := range []string{"ok", "ok", "fail"} {
+		_, _ = next(ctx, req)
+	}
+	attrs := func(code string) attribute.Set {
+		return attribute.NewSet(
+			attribute.String("kind", "http"),
+			attribute.String("operation", "/test"),
+			attribute.String("code", code),
+		)
+	}
+	data := collect(t, reader)
+
+	requests, ok := data["server_requests_code_total"].(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("want the requests counter, got %v", data)
+	}
+	counts := make(map[string]int64)
+	for _, p := range requests.DataPoints {
+		for _, code := range []string{"200", "400"} {
+			if set := attrs(code); p.Attributes.Equals(&set) {
+				counts[code] = p.Value
+			}
+		}
+	}
+	if !reflect.DeepEqual(counts, map[string]int64{"200": 2, "400": 1}) {
+		t.Fatalf("want the requests by code, got %v", requests.DataPoints)
+	}
+
+	errs, ok := data["server_requests_errors_total"].(metricdata.Sum[int64])
+	if !ok || len(errs.DataPoints) != 1 || errs.DataPoints[0].Value != 1 {
+		t.Fatalf("want 1 error, got %v", data["server_requests_errors_total"])
+	}
+	if set := attrs("400"); !errs.DataPoints[0].Attributes.Equals(&set) {
+		t.Fatalf("want the error attributes %v, got %v", set, errs.DataPoints[0].Attributes)
+	}
+
+	seconds, ok := data["server_requests_seconds"].(metricdata.Histogram[float64])
+	if !ok || len(seconds.DataPoints) != 2 {
+		t.Fatalf("want the duration histogram by code, got %v", data["server_requests_seconds"])
+	}
+	for _, p := range seconds.DataPoints {
+		if !reflect.DeepEqual(p.Bounds, buckets) {
+			t.Fatalf("want the buckets %v, got %v", buckets, p.Bounds)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	next := Client(meter)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	ctx := transport.NewClientContext(context.Background(), &testTransport{kind: transport.KindGRPC, operation: "/test.Greeter/Hello"})
+	if _, err := next(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := collect(t, reader)
+	seconds, ok := data["client_requests_seconds"].(metricdata.Histogram[float64])
+	if !ok || len(seconds.DataPoints) != 1 || seconds.DataPoints[0].Count != 1 {
+		t.Fatalf("want 1 duration recorded, got %v", data["client_requests_seconds"])
+	}
+	if !reflect.DeepEqual(seconds.DataPoints[0].Bounds, defaultBuckets) {
+		t.Fatalf("want the default buckets, got %v", seconds.DataPoints[0].Bounds)
+	}
+	if v, _ := seconds.DataPoints[0].Attributes.Value("kind"); v.AsString() != "grpc" {
+		t.Fatalf("want the kind grpc, got %v", v)
+	}
+	if _, ok := data["client_requests_errors_total"]; ok {
+		t.Fatal("want no errors recorded")
+	}
+}
@@ -1,9 +1,15 @@
 package encoding
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 )
 
+// ErrUnknownContentType is returned by GetCodecByContentType when no Codec is
+// registered for the content type.
+var ErrUnknownContentType = errors.New("encoding: unknown content type")
+
 // Codec defines the interface Transport uses to encode and decode messages.  Note
 // that implementations of this interface must be thread safe; a Codec's
 // methods can be called from concurrent goroutines.
@@ -40,3 +46,32 @@ func RegisterCodec(codec Codec) {
 func GetCodec(contentSubtype string) Codec {
 	return registeredCodecs[contentSubtype]
 }
+
+// GetCodecByContentType gets a registered Codec by content-type, the
+// parameters are ignored and the content-subtype is resolved by its structured
+// syntax suffix when it has no registered Codec, e.g. application/vnd.foo+json
+// and application/grpc+json resolve the json Codec. It is used by the HTTP
+// transport and the middlewares, e.g. logging of gRPC payloads, while the gRPC
+// transport itself resolves its codecs by the registry of grpc-go.
+//
+// An error wrapping ErrUnknownContentType is returned if no Codec is registered
+// for the content-type.
+func GetCodecByContentType(contentType string) (Codec, error) {
+	subtype := strings.ToLower(contentType)
+	if i := strings.IndexByte(subtype, ';'); i >= 0 {
+		subtype = subtype[:i]
+	}
+	subtype = strings.TrimSpace(subtype)
+	if i := strings.IndexByte(subtype, '/'); i >= 0 {
+		subtype = subtype[i+1:]
+	}
+	if codec := registeredCodecs[subtype]; codec != nil {
+		return codec, nil
+	}
+	if i := strings.LastIndexByte(subtype, '+'); i >= 0 {
+		if codec := registeredCodecs[subtype[i+1:]]; codec != nil {
+			return codec, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownContentType, contentType)
+}
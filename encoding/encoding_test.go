@@ -2,6 +2,7 @@ package encoding
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"runtime/debug"
 	"testing"
@@ -63,6 +64,37 @@ func TestRegisterCodec(t *testing.T) {
 	}
 }
 
+func TestGetCodecByContentType(t *testing.T) {
+	codec := codec2{}
+	RegisterCodec(codec)
+	tests := []struct {
+		contentType string
+		ok          bool
+	}{
+		{"application/xml", true},
+		{"Application/XML; charset=utf-8", true},
+		{"text/xml", true},
+		{"application/vnd.foo+xml", true},
+		{"application/grpc+xml", true},
+		{"xml", true},
+		{"application/unknown", false},
+		{"application/vnd.foo+unknown", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		got, err := GetCodecByContentType(test.contentType)
+		if !test.ok {
+			if !errors.Is(err, ErrUnknownContentType) {
+				t.Fatalf("GetCodecByContentType(%q) want ErrUnknownContentType got %v", test.contentType, err)
+			}
+			continue
+		}
+		if err != nil || got != codec {
+			t.Fatalf("GetCodecByContentType(%q) want %v got %v, %v", test.contentType, codec, got, err)
+		}
+	}
+}
+
 // PanicTestFunc defines a func that should be passed to the assert.Panics and assert.NotPanics
 // methods, and represents a simple func that takes no arguments, and returns nothing.
 type PanicTestFunc func()
@@ -27,14 +27,48 @@ func init() {
 	encoding.RegisterCodec(codec{})
 }
 
+// Option is json codec option.
+type Option func(*protojson.MarshalOptions)
+
+// WithUseEnumNumbers with proto enums marshaled as numbers or names.
+func WithUseEnumNumbers(use bool) Option {
+	return func(o *protojson.MarshalOptions) {
+		o.UseEnumNumbers = use
+	}
+}
+
+// WithEmitUnpopulated with proto fields of zero values emitted or omitted.
+func WithEmitUnpopulated(emit bool) Option {
+	return func(o *protojson.MarshalOptions) {
+		o.EmitUnpopulated = emit
+	}
+}
+
+// NewCodec returns a json codec marshaling proto messages with options on top
+// of MarshalOptions. It is not registered, pass it to encoding.RegisterCodec
+// to replace the default json codec.
+func NewCodec(opts ...Option) encoding.Codec {
+	marshal := MarshalOptions
+	for _, o := range opts {
+		o(&marshal)
+	}
+	return codec{marshal: &marshal}
+}
+
 // codec is a Codec implementation with json.
-type codec struct{}
+type codec struct {
+	// marshal overrides MarshalOptions if not nil.
+	marshal *protojson.MarshalOptions
+}
 
-func (codec) Marshal(v interface{}) ([]byte, error) {
+func (c codec) Marshal(v interface{}) ([]byte, error) {
 	switch m := v.(type) {
 	case json.Marshaler:
 		return m.MarshalJSON()
 	case proto.Message:
+		if c.marshal != nil {
+			return c.marshal.Marshal(m)
+		}
 		return MarshalOptions.Marshal(m)
 	default:
 		return json.Marshal(m)
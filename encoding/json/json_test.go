@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/go-kratos/kratos/v2/internal/testdata/complex"
 	testData "github.com/go-kratos/kratos/v2/internal/testdata/encoding"
 )
 
@@ -145,3 +146,34 @@ func TestJSON_Unmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestJSON_EnumNumbers(t *testing.T) {
+	c := NewCodec(WithUseEnumNumbers(true), WithEmitUnpopulated(false))
+	if c.Name() != Name {
+		t.Fatalf("Name() should be %s, but got %s", Name, c.Name())
+	}
+	got, err := c.Marshal(&complex.Complex{Sex: complex.Sex_woman})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"sex":1}`; strings.ReplaceAll(string(got), " ", "") != want {
+		t.Fatalf("marshal: have %s want %s", got, want)
+	}
+	for _, input := range []string{`{"sex":1}`, `{"sex":"woman"}`} {
+		var m complex.Complex
+		if err := c.Unmarshal([]byte(input), &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Sex != complex.Sex_woman {
+			t.Fatalf("unmarshal(%s): have %v want %v", input, m.Sex, complex.Sex_woman)
+		}
+	}
+	// the registered codec keeps enum names
+	got, err = codec{}.Marshal(&complex.Complex{Sex: complex.Sex_woman})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), `"woman"`) {
+		t.Fatalf("marshal: have %s, want enum name", got)
+	}
+}
@@ -15,11 +15,34 @@ func init() {
 	encoding.RegisterCodec(codec{})
 }
 
+// Option is proto codec option.
+type Option func(*codec)
+
+// WithDeterministic with map entries marshaled in a stable order, so that
+// repeated marshals of equal messages produce identical bytes.
+func WithDeterministic() Option {
+	return func(c *codec) {
+		c.marshal.Deterministic = true
+	}
+}
+
+// NewCodec returns a proto codec with options. It is not registered, pass it
+// to encoding.RegisterCodec to replace the default proto codec.
+func NewCodec(opts ...Option) encoding.Codec {
+	c := codec{}
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
 // codec is a Codec implementation with protobuf. It is the default codec for Transport.
-type codec struct{}
+type codec struct {
+	marshal proto.MarshalOptions
+}
 
-func (codec) Marshal(v interface{}) ([]byte, error) {
-	return proto.Marshal(v.(proto.Message))
+func (c codec) Marshal(v interface{}) ([]byte, error) {
+	return c.marshal.Marshal(v.(proto.Message))
 }
 
 func (codec) Unmarshal(data []byte, v interface{}) error {
@@ -1,9 +1,11 @@
 package proto
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 
+	"github.com/go-kratos/kratos/v2/internal/testdata/complex"
 	testData "github.com/go-kratos/kratos/v2/internal/testdata/encoding"
 )
 
@@ -44,3 +46,24 @@ func TestCodec(t *testing.T) {
 		t.Errorf("Hobby should be %s, but got %s", res.Hobby, model.Hobby)
 	}
 }
+
+func TestCodec_Deterministic(t *testing.T) {
+	c := NewCodec(WithDeterministic())
+	if c.Name() != Name {
+		t.Errorf("Name() should be %s, but got %s", Name, c.Name())
+	}
+	m := &complex.Complex{Map: map[string]string{"a": "1", "b": "2", "c": "3", "d": "4", "e": "5"}}
+	first, err := c.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		data, err := c.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, data) {
+			t.Fatalf("Marshal() should be deterministic, got %x and %x", first, data)
+		}
+	}
+}
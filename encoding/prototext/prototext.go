@@ -0,0 +1,74 @@
+// Package prototext defines the protobuf text format codec. Importing this
+// package will register the codec.
+package prototext
+
+import (
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// Name is the name registered for the prototext codec.
+const Name = "prototext"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// Option is prototext codec option.
+type Option func(*codec)
+
+// WithEmitUnknown with the unknown fields of proto messages emitted, the text
+// format always omits the fields of zero values.
+func WithEmitUnknown() Option {
+	return func(c *codec) {
+		c.marshal.EmitUnknown = true
+	}
+}
+
+// WithMultiline with the fields marshaled one per line and the nested
+// messages indented, the default is a single line.
+func WithMultiline() Option {
+	return func(c *codec) {
+		c.marshal.Multiline = true
+	}
+}
+
+// NewCodec returns a prototext codec with options. It is not registered, pass
+// it to encoding.RegisterCodec to replace the default prototext codec.
+func NewCodec(opts ...Option) encoding.Codec {
+	c := codec{}
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// codec is a Codec implementation with the protobuf text format, it only
+// supports proto messages.
+type codec struct {
+	marshal prototext.MarshalOptions
+}
+
+func (c codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal, message is %T, want proto.Message", v)
+	}
+	return c.marshal.Marshal(m)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal, message is %T, want proto.Message", v)
+	}
+	return prototext.Unmarshal(data, m)
+}
+
+func (codec) Name() string {
+	return Name
+}
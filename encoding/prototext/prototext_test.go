@@ -0,0 +1,73 @@
+package prototext
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	testData "github.com/go-kratos/kratos/v2/internal/testdata/encoding"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestName(t *testing.T) {
+	if got := encoding.GetCodec(Name); got == nil || got.Name() != "prototext" {
+		t.Fatalf("want the registered codec prototext, got %v", got)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	c := codec{}
+	model := &testData.TestModel{
+		Id:    1,
+		Name:  "kratos",
+		Hobby: []string{"study", "eat", "play"},
+	}
+	data, err := c.Marshal(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `name:"kratos"`) {
+		t.Fatalf("want the text format, got %s", data)
+	}
+	res := new(testData.TestModel)
+	if err = c.Unmarshal(data, res); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(model, res) {
+		t.Fatalf("want %v, got %v", model, res)
+	}
+}
+
+func TestCodec_Options(t *testing.T) {
+	model := &testData.TestModel{Id: 1}
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, "id:1"},
+		{"multiline", []Option{WithMultiline()}, "id: 1\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := NewCodec(test.opts...).Marshal(model)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), test.want) {
+				t.Fatalf("want %q in %q", test.want, data)
+			}
+		})
+	}
+}
+
+func TestCodec_NotProto(t *testing.T) {
+	c := codec{}
+	if _, err := c.Marshal(map[string]string{"a": "b"}); err == nil {
+		t.Fatal("want an error marshaling a map")
+	}
+	var v struct{}
+	if err := c.Unmarshal([]byte(`id:1`), &v); err == nil {
+		t.Fatal("want an error unmarshaling into a struct")
+	}
+}
@@ -0,0 +1,70 @@
+package toml
+
+import (
+	"bytes"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-kratos/kratos/v2/encoding"
+)
+
+// Name is the name registered for the toml codec.
+const Name = "toml"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with toml.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	if err := toml.Unmarshal(data, v); err != nil {
+		return err
+	}
+	// arrays of tables are decoded as []map[string]interface{},
+	// normalize them into the []interface{} shape of the other codecs.
+	switch m := v.(type) {
+	case *map[string]interface{}:
+		normalize(*m)
+	case *interface{}:
+		*m = normalizeValue(*m)
+	}
+	return nil
+}
+
+func (codec) Name() string {
+	return Name
+}
+
+func normalize(m map[string]interface{}) {
+	for k, v := range m {
+		m[k] = normalizeValue(v)
+	}
+}
+
+func normalizeValue(v interface{}) interface{} {
+	switch vt := v.(type) {
+	case map[string]interface{}:
+		normalize(vt)
+	case []map[string]interface{}:
+		s := make([]interface{}, len(vt))
+		for i, m := range vt {
+			normalize(m)
+			s[i] = m
+		}
+		return s
+	case []interface{}:
+		for i, e := range vt {
+			vt[i] = normalizeValue(e)
+		}
+	}
+	return v
+}
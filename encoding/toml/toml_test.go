@@ -0,0 +1,86 @@
+package toml
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testServer struct {
+	Addr string `toml:"addr"`
+	Port int    `toml:"port"`
+}
+
+type testConfig struct {
+	Name    string       `toml:"name"`
+	Server  testServer   `toml:"server"`
+	Servers []testServer `toml:"servers"`
+}
+
+const _testTOML = `name = "kratos"
+
+[server]
+addr = "127.0.0.1"
+port = 8000
+
+[[servers]]
+addr = "a"
+port = 1
+
+[[servers]]
+addr = "b"
+port = 2
+`
+
+func TestCodec_RoundTrip(t *testing.T) {
+	var v testConfig
+	if err := (codec{}).Unmarshal([]byte(_testTOML), &v); err != nil {
+		t.Fatal(err)
+	}
+	want := testConfig{
+		Name:    "kratos",
+		Server:  testServer{Addr: "127.0.0.1", Port: 8000},
+		Servers: []testServer{{Addr: "a", Port: 1}, {Addr: "b", Port: 2}},
+	}
+	if !reflect.DeepEqual(want, v) {
+		t.Fatalf("want %+v, got %+v", want, v)
+	}
+
+	data, err := (codec{}).Marshal(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got testConfig
+	if err = (codec{}).Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestCodec_UnmarshalMap(t *testing.T) {
+	var v map[string]interface{}
+	if err := (codec{}).Unmarshal([]byte(_testTOML), &v); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"name": "kratos",
+		"server": map[string]interface{}{
+			"addr": "127.0.0.1",
+			"port": int64(8000),
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"addr": "a", "port": int64(1)},
+			map[string]interface{}{"addr": "b", "port": int64(2)},
+		},
+	}
+	if !reflect.DeepEqual(want, v) {
+		t.Fatalf("want %#v, got %#v", want, v)
+	}
+}
+
+func TestCodec_Name(t *testing.T) {
+	if (codec{}).Name() != Name {
+		t.Fatalf("want %s, got %s", Name, (codec{}).Name())
+	}
+}
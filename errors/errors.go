@@ -7,6 +7,8 @@ import (
 	httpstatus "github.com/go-kratos/kratos/v2/transport/http/status"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 //go:generate protoc -I. --go_out=paths=source_relative:. errors.proto
@@ -55,6 +57,18 @@ func (e *Error) WithMetadata(md map[string]string) *Error {
 	return err
 }
 
+// WithDetails with the details of the error, e.g. errdetails.BadRequest,
+// they are packed as Any and kept through the gRPC and HTTP transports.
+func (e *Error) WithDetails(details ...proto.Message) *Error {
+	err := Clone(e)
+	for _, detail := range details {
+		if a, perr := anypb.New(detail); perr == nil {
+			err.Details = append(err.Details, a)
+		}
+	}
+	return err
+}
+
 // GRPCStatus returns the Status represented by se.
 func (e *Error) GRPCStatus() *status.Status {
 	s, _ := status.New(httpstatus.ToGRPCCode(int(e.Code)), e.Message).
@@ -62,7 +76,12 @@ func (e *Error) GRPCStatus() *status.Status {
 			Reason:   e.Reason,
 			Metadata: e.Metadata,
 		})
-	return s
+	if len(e.Details) == 0 {
+		return s
+	}
+	sp := s.Proto()
+	sp.Details = append(sp.Details, e.Details...)
+	return status.FromProto(sp)
 }
 
 // New returns an error object for the code, message.
@@ -110,6 +129,10 @@ func Clone(err *Error) *Error {
 	for k, v := range err.Metadata {
 		metadata[k] = v
 	}
+	var details []*anypb.Any
+	for _, detail := range err.Details {
+		details = append(details, proto.Clone(detail).(*anypb.Any))
+	}
 	return &Error{
 		cause: err.cause,
 		Status: Status{
@@ -117,6 +140,7 @@ func Clone(err *Error) *Error {
 			Reason:   err.Reason,
 			Message:  err.Message,
 			Metadata: metadata,
+			Details:  details,
 		},
 	}
 }
@@ -130,21 +154,42 @@ func FromError(err error) *Error {
 	if se := new(Error); errors.As(err, &se) {
 		return se
 	}
-	gs, ok := status.FromError(err)
-	if ok {
+	// status.FromError does not unwrap the error
+	var gse interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &gse) {
+		gs := gse.GRPCStatus()
 		ret := New(
 			httpstatus.FromGRPCCode(gs.Code()),
 			UnknownReason,
 			gs.Message(),
 		)
-		for _, detail := range gs.Details() {
-			switch d := detail.(type) {
-			case *errdetails.ErrorInfo:
-				ret.Reason = d.Reason
-				return ret.WithMetadata(d.Metadata)
+		for _, detail := range gs.Proto().GetDetails() {
+			info := new(errdetails.ErrorInfo)
+			if detail.MessageIs(info) && ret.Reason == UnknownReason {
+				if detail.UnmarshalTo(info) == nil {
+					ret.Reason = info.Reason
+					ret.Metadata = info.Metadata
+					continue
+				}
 			}
+			ret.Details = append(ret.Details, detail)
 		}
 		return ret
 	}
 	return New(UnknownCode, UnknownReason, err.Error())
 }
+
+// Details returns the details of an error, the ones of unknown types are skipped.
+// It supports wrapped errors.
+func Details(err error) []proto.Message {
+	if err == nil {
+		return nil
+	}
+	var details []proto.Message
+	for _, detail := range FromError(err).Details {
+		if m, uerr := detail.UnmarshalNew(); uerr == nil {
+			details = append(details, m)
+		}
+	}
+	return details
+}
@@ -7,9 +7,12 @@ import (
 	"reflect"
 	"testing"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/grpc_testing"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 type TestError struct{ message string }
@@ -131,3 +134,78 @@ func TestOther(t *testing.T) {
 		t.Errorf(`Reason(err) = %v, want %v`, Reason(err), "test code 10001")
 	}
 }
+
+func TestDetails(t *testing.T) {
+	violation := &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "name", Description: "name is required"},
+		},
+	}
+	err := BadRequest("VALIDATOR", "invalid argument").
+		WithMetadata(map[string]string{"foo": "bar"}).
+		WithDetails(violation)
+
+	// gRPC round trip
+	gerr := FromError(status.Convert(err).Err())
+	if gerr.Reason != "VALIDATOR" || gerr.Metadata["foo"] != "bar" || gerr.Code != 400 {
+		t.Fatalf("unexpected error %v", gerr)
+	}
+	details := Details(fmt.Errorf("wrapped: %w", gerr))
+	if len(details) != 1 || !proto.Equal(details[0], violation) {
+		t.Fatalf("want %v, got %v", violation, details)
+	}
+
+	// HTTP round trip
+	data, merr := protojson.Marshal(&err.Status)
+	if merr != nil {
+		t.Fatal(merr)
+	}
+	herr := new(Error)
+	if merr = protojson.Unmarshal(data, &herr.Status); merr != nil {
+		t.Fatal(merr)
+	}
+	details = Details(herr)
+	if len(details) != 1 || !proto.Equal(details[0], violation) {
+		t.Fatalf("want %v, got %v", violation, details)
+	}
+
+	if Details(New(500, "", "")) != nil || Details(nil) != nil {
+		t.Fatal("want no details")
+	}
+}
+
+func TestWrapped(t *testing.T) {
+	base := NotFound("USER_NOT_FOUND", "user not found")
+	tests := []struct {
+		name   string
+		err    error
+		code   int
+		reason string
+	}{
+		{"kratos", fmt.Errorf("get user: %w", fmt.Errorf("query: %w", base)), 404, "USER_NOT_FOUND"},
+		{"cause", fmt.Errorf("get user: %w", Conflict("CONFLICT", "").WithCause(base)), 409, "CONFLICT"},
+		{"grpc", fmt.Errorf("call: %w", fmt.Errorf("rpc: %w", status.Convert(base).Err())), 404, "USER_NOT_FOUND"},
+		{"grpc without reason", fmt.Errorf("call: %w", status.Error(codes.Unavailable, "unavailable")), 503, UnknownReason},
+		{"other", fmt.Errorf("wrapped: %w", errors.New("test")), UnknownCode, UnknownReason},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := Code(test.err); code != test.code {
+				t.Fatalf("want code %d, got %d", test.code, code)
+			}
+			if reason := Reason(test.err); reason != test.reason {
+				t.Fatalf("want reason %q, got %q", test.reason, reason)
+			}
+		})
+	}
+	err := fmt.Errorf("get user: %w", fmt.Errorf("query: %w", base))
+	if !errors.Is(err, NotFound("USER_NOT_FOUND", "another message")) {
+		t.Fatal("want errors.Is to match the code and reason in the chain")
+	}
+	if !base.Is(fmt.Errorf("wrapped: %w", NotFound("USER_NOT_FOUND", ""))) {
+		t.Fatal("want Is to match a wrapped target")
+	}
+	if errors.Is(err, NotFound("OTHER", "")) || errors.Is(err, BadRequest("USER_NOT_FOUND", "")) {
+		t.Fatal("want errors.Is to mismatch another code or reason")
+	}
+}
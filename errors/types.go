@@ -45,6 +45,28 @@ func IsNotFound(err error) bool {
 	return Code(err) == 404
 }
 
+// MethodNotAllowed new MethodNotAllowed error that is mapped to a 405 response.
+func MethodNotAllowed(reason, message string) *Error {
+	return New(405, reason, message)
+}
+
+// IsMethodNotAllowed determines if err is an error which indicates a MethodNotAllowed error.
+// It supports wrapped errors.
+func IsMethodNotAllowed(err error) bool {
+	return Code(err) == 405
+}
+
+// RequestTimeout new RequestTimeout error that is mapped to a 408 response.
+func RequestTimeout(reason, message string) *Error {
+	return New(408, reason, message)
+}
+
+// IsRequestTimeout determines if err is an error which indicates a RequestTimeout error.
+// It supports wrapped errors.
+func IsRequestTimeout(err error) bool {
+	return Code(err) == 408
+}
+
 // Conflict new Conflict error that is mapped to a 409 response.
 func Conflict(reason, message string) *Error {
 	return New(409, reason, message)
@@ -56,6 +78,72 @@ func IsConflict(err error) bool {
 	return Code(err) == 409
 }
 
+// Gone new Gone error that is mapped to a 410 response.
+func Gone(reason, message string) *Error {
+	return New(410, reason, message)
+}
+
+// IsGone determines if err is an error which indicates a Gone error.
+// It supports wrapped errors.
+func IsGone(err error) bool {
+	return Code(err) == 410
+}
+
+// PreconditionFailed new PreconditionFailed error that is mapped to a 412 response.
+func PreconditionFailed(reason, message string) *Error {
+	return New(412, reason, message)
+}
+
+// IsPreconditionFailed determines if err is an error which indicates a PreconditionFailed error.
+// It supports wrapped errors.
+func IsPreconditionFailed(err error) bool {
+	return Code(err) == 412
+}
+
+// RequestEntityTooLarge new RequestEntityTooLarge error that is mapped to a 413 response.
+func RequestEntityTooLarge(reason, message string) *Error {
+	return New(413, reason, message)
+}
+
+// IsRequestEntityTooLarge determines if err is an error which indicates a RequestEntityTooLarge error.
+// It supports wrapped errors.
+func IsRequestEntityTooLarge(err error) bool {
+	return Code(err) == 413
+}
+
+// UnsupportedMediaType new UnsupportedMediaType error that is mapped to a 415 response.
+func UnsupportedMediaType(reason, message string) *Error {
+	return New(415, reason, message)
+}
+
+// IsUnsupportedMediaType determines if err is an error which indicates an UnsupportedMediaType error.
+// It supports wrapped errors.
+func IsUnsupportedMediaType(err error) bool {
+	return Code(err) == 415
+}
+
+// UnprocessableEntity new UnprocessableEntity error that is mapped to a 422 response.
+func UnprocessableEntity(reason, message string) *Error {
+	return New(422, reason, message)
+}
+
+// IsUnprocessableEntity determines if err is an error which indicates an UnprocessableEntity error.
+// It supports wrapped errors.
+func IsUnprocessableEntity(err error) bool {
+	return Code(err) == 422
+}
+
+// TooManyRequests new TooManyRequests error that is mapped to a 429 response.
+func TooManyRequests(reason, message string) *Error {
+	return New(429, reason, message)
+}
+
+// IsTooManyRequests determines if err is an error which indicates a TooManyRequests error.
+// It supports wrapped errors.
+func IsTooManyRequests(err error) bool {
+	return Code(err) == 429
+}
+
 // InternalServer new InternalServer error that is mapped to a 500 response.
 func InternalServer(reason, message string) *Error {
 	return New(500, reason, message)
@@ -67,6 +155,28 @@ func IsInternalServer(err error) bool {
 	return Code(err) == 500
 }
 
+// NotImplemented new NotImplemented error that is mapped to a HTTP 501 response.
+func NotImplemented(reason, message string) *Error {
+	return New(501, reason, message)
+}
+
+// IsNotImplemented determines if err is an error which indicates a NotImplemented error.
+// It supports wrapped errors.
+func IsNotImplemented(err error) bool {
+	return Code(err) == 501
+}
+
+// BadGateway new BadGateway error that is mapped to a HTTP 502 response.
+func BadGateway(reason, message string) *Error {
+	return New(502, reason, message)
+}
+
+// IsBadGateway determines if err is an error which indicates a BadGateway error.
+// It supports wrapped errors.
+func IsBadGateway(err error) bool {
+	return Code(err) == 502
+}
+
 // ServiceUnavailable new ServiceUnavailable error that is mapped to a HTTP 503 response.
 func ServiceUnavailable(reason, message string) *Error {
 	return New(503, reason, message)
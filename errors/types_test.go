@@ -2,6 +2,11 @@ package errors
 
 import (
 	"testing"
+
+	httpstatus "github.com/go-kratos/kratos/v2/transport/http/status"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestTypes(t *testing.T) {
@@ -36,3 +41,54 @@ func TestTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestTypesCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *Error
+		is       func(error) bool
+		httpCode int
+		grpcCode codes.Code
+		// fromGRPC is the http code of the error received over gRPC
+		fromGRPC int
+	}{
+		{"BadRequest", BadRequest("", ""), IsBadRequest, 400, codes.InvalidArgument, 400},
+		{"Unauthorized", Unauthorized("", ""), IsUnauthorized, 401, codes.Unauthenticated, 401},
+		{"Forbidden", Forbidden("", ""), IsForbidden, 403, codes.PermissionDenied, 403},
+		{"NotFound", NotFound("", ""), IsNotFound, 404, codes.NotFound, 404},
+		{"MethodNotAllowed", MethodNotAllowed("", ""), IsMethodNotAllowed, 405, codes.Unimplemented, 501},
+		{"RequestTimeout", RequestTimeout("", ""), IsRequestTimeout, 408, codes.DeadlineExceeded, 504},
+		{"Conflict", Conflict("", ""), IsConflict, 409, codes.Aborted, 409},
+		{"Gone", Gone("", ""), IsGone, 410, codes.NotFound, 404},
+		{"PreconditionFailed", PreconditionFailed("", ""), IsPreconditionFailed, 412, codes.FailedPrecondition, 400},
+		{"RequestEntityTooLarge", RequestEntityTooLarge("", ""), IsRequestEntityTooLarge, 413, codes.ResourceExhausted, 429},
+		{"UnsupportedMediaType", UnsupportedMediaType("", ""), IsUnsupportedMediaType, 415, codes.InvalidArgument, 400},
+		{"UnprocessableEntity", UnprocessableEntity("", ""), IsUnprocessableEntity, 422, codes.InvalidArgument, 400},
+		{"TooManyRequests", TooManyRequests("", ""), IsTooManyRequests, 429, codes.ResourceExhausted, 429},
+		{"ClientClosed", ClientClosed("", ""), IsClientClosed, 499, codes.Canceled, 499},
+		{"InternalServer", InternalServer("", ""), IsInternalServer, 500, codes.Internal, 500},
+		{"NotImplemented", NotImplemented("", ""), IsNotImplemented, 501, codes.Unimplemented, 501},
+		{"BadGateway", BadGateway("", ""), IsBadGateway, 502, codes.Unavailable, 503},
+		{"ServiceUnavailable", ServiceUnavailable("", ""), IsServiceUnavailable, 503, codes.Unavailable, 503},
+		{"GatewayTimeout", GatewayTimeout("", ""), IsGatewayTimeout, 504, codes.DeadlineExceeded, 504},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := Code(test.err); code != test.httpCode {
+				t.Fatalf("want http code %d, got %d", test.httpCode, code)
+			}
+			if code := test.err.GRPCStatus().Code(); code != test.grpcCode {
+				t.Fatalf("want grpc code %v, got %v", test.grpcCode, code)
+			}
+			if !test.is(test.err) {
+				t.Fatalf("want %s", test.name)
+			}
+			if code := httpstatus.FromGRPCCode(test.grpcCode); code != test.fromGRPC {
+				t.Fatalf("want http code %d of grpc code %v, got %d", test.fromGRPC, test.grpcCode, code)
+			}
+			if code := Code(FromError(status.New(test.grpcCode, "").Err())); code != test.fromGRPC {
+				t.Fatalf("want http code %d from the grpc status, got %d", test.fromGRPC, code)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ module github.com/go-kratos/kratos/v2
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/fsnotify/fsnotify v1.5.1
 	github.com/go-kratos/aegis v0.1.1
 	github.com/go-playground/form/v4 v4.2.0
@@ -0,0 +1,216 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// defaultTimeout is the default timeout of the checks.
+const defaultTimeout = 5 * time.Second
+
+// Status is the health status of a component or of the app.
+type Status string
+
+const (
+	// StatusUp is the status of the healthy components.
+	StatusUp Status = "UP"
+	// StatusDown is the status of the components failing their checks.
+	StatusDown Status = "DOWN"
+)
+
+// Checker is the health check of a component, e.g. a database or a background
+// worker, it returns nil if the component is healthy.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is a func implementing Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx).
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// ErrNotReady is the error of the ReadyChecker of a component not ready.
+var ErrNotReady = errors.New("not ready")
+
+// ReadyChecker returns the checker of the readiness of r, e.g. of the
+// kratos.App, which is down before it is ready and while it is drained.
+func ReadyChecker(r interface{ Ready() bool }) Checker {
+	return CheckerFunc(func(context.Context) error {
+		if !r.Ready() {
+			return ErrNotReady
+		}
+		return nil
+	})
+}
+
+// ComponentStatus is the health status of a component.
+type ComponentStatus struct {
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the health status of the app, the worst status of its components,
+// and the statuses of the components by name.
+type Report struct {
+	Status     Status                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// Option is health option.
+type Option func(*Health)
+
+// WithTimeout with the timeout of the checks, default is 5 seconds, the checks
+// not done within it are down.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Health) {
+		h.timeout = d
+	}
+}
+
+// Health aggregates the health checks of the components of the app, its
+// combined status is served on an HTTP path and by the gRPC health service:
+//
+//	h := health.New()
+//	h.Register("app", health.ReadyChecker(app))
+//	h.Register("db", health.CheckerFunc(db.PingContext))
+//	hs.Handle("/healthz", h)
+//	grpc.NewServer(grpc.HealthCheck(h.ServingStatus))
+type Health struct {
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	checkers map[string]Checker
+}
+
+// New returns a health aggregator without components, which is up.
+func New(opts ...Option) *Health {
+	h := &Health{
+		timeout:  defaultTimeout,
+		checkers: make(map[string]Checker),
+	}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
+}
+
+// Register registers the checker of the component name, replacing the one
+// registered with the same name.
+func (h *Health) Register(name string, c Checker) {
+	h.mu.Lock()
+	h.checkers[name] = c
+	h.mu.Unlock()
+}
+
+// Unregister unregisters the checker of the component name.
+func (h *Health) Unregister(name string) {
+	h.mu.Lock()
+	delete(h.checkers, name)
+	h.mu.Unlock()
+}
+
+// Check runs the checks of all the components concurrently and returns their
+// report, the app is down if any of the components is down.
+func (h *Health) Check(ctx context.Context) Report {
+	h.mu.RLock()
+	checkers := make(map[string]Checker, len(h.checkers))
+	for name, c := range h.checkers {
+		checkers[name] = c
+	}
+	h.mu.RUnlock()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		report = Report{Status: StatusUp, Components: make(map[string]ComponentStatus, len(checkers))}
+	)
+	for name, c := range checkers {
+		wg.Add(1)
+		go func(name string, c Checker) {
+			defer wg.Done()
+			s := check(ctx, c)
+			mu.Lock()
+			report.Components[name] = s
+			mu.Unlock()
+		}(name, c)
+	}
+	wg.Wait()
+	for _, s := range report.Components {
+		if s.Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// check returns the status of c, it is down if the check is not done before
+// ctx is done.
+func check(ctx context.Context, c Checker) ComponentStatus {
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return ComponentStatus{Status: StatusDown, Error: err.Error()}
+	}
+	return ComponentStatus{Status: StatusUp}
+}
+
+// ServeHTTP writes the report as JSON, with the status 503 if the app is down
+// and 200 otherwise, e.g. for the load balancer and the kubernetes probes.
+func (h *Health) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	report := h.Check(req.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == StatusDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	if req.Method != http.MethodHead {
+		_ = json.NewEncoder(w).Encode(report)
+	}
+}
+
+// ServingStatus returns the serving status of the service for the gRPC health
+// service, passed to grpc.HealthCheck. The service named as a component is the
+// status of the component, and the other services are the status of the app.
+func (h *Health) ServingStatus(ctx context.Context, service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	var status Status
+	h.mu.RLock()
+	c, ok := h.checkers[service]
+	h.mu.RUnlock()
+	if ok && service != "" {
+		if h.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+		}
+		status = check(ctx, c).Status
+	} else {
+		status = h.Check(ctx).Status
+	}
+	if status == StatusDown {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type testReady struct{ ready int32 }
+
+func (r *testReady) Ready() bool { return atomic.LoadInt32(&r.ready) == 1 }
+
+func TestHealth_Check(t *testing.T) {
+	h := New(WithTimeout(100 * time.Millisecond))
+	if report := h.Check(context.Background()); report.Status != StatusUp {
+		t.Fatalf("want up without components, got %v", report)
+	}
+	r := &testReady{}
+	h.Register("app", ReadyChecker(r))
+	h.Register("db", CheckerFunc(func(context.Context) error { return nil }))
+	h.Register("slow", CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}))
+	report := h.Check(context.Background())
+	want := Report{
+		Status: StatusDown,
+		Components: map[string]ComponentStatus{
+			"app":  {Status: StatusDown, Error: ErrNotReady.Error()},
+			"db":   {Status: StatusUp},
+			"slow": {Status: StatusDown, Error: context.DeadlineExceeded.Error()},
+		},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("want %v, got %v", want, report)
+	}
+	atomic.StoreInt32(&r.ready, 1)
+	h.Unregister("slow")
+	if report = h.Check(context.Background()); report.Status != StatusUp {
+		t.Fatalf("want up, got %v", report)
+	}
+}
+
+func TestHealth_ServeHTTP(t *testing.T) {
+	h := New()
+	h.Register("worker", CheckerFunc(func(context.Context) error { return errors.New("stopped") }))
+	h.Register("db", CheckerFunc(func(context.Context) error { return nil }))
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/healthz", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s: want 503, got %d", method, w.Code)
+		}
+		if method == http.MethodHead {
+			if w.Body.Len() != 0 {
+				t.Fatalf("want no body of HEAD, got %q", w.Body.String())
+			}
+			continue
+		}
+		var report Report
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		if report.Status != StatusDown || report.Components["worker"].Error != "stopped" || report.Components["db"].Status != StatusUp {
+			t.Fatalf("unexpected report %+v", report)
+		}
+	}
+	h.Unregister("worker")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+}
+
+func TestHealth_ServingStatus(t *testing.T) {
+	h := New()
+	h.Register("helloworld.Greeter", CheckerFunc(func(context.Context) error { return nil }))
+	h.Register("worker", CheckerFunc(func(context.Context) error { return errors.New("stopped") }))
+	tests := []struct {
+		service string
+		want    grpc_health_v1.HealthCheckResponse_ServingStatus
+	}{
+		{"", grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{"unknown", grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{"helloworld.Greeter", grpc_health_v1.HealthCheckResponse_SERVING},
+		{"worker", grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+	}
+	for _, test := range tests {
+		if s := h.ServingStatus(context.Background(), test.service); s != test.want {
+			t.Fatalf("%q: want %v, got %v", test.service, test.want, s)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Package attempt reports whether the client requests can be sent again, e.g.
+// by the retries and the hedged attempts.
+package attempt
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware/idempotency"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Operations is the set of the operations sent again even if they are not
+// idempotent.
+type Operations map[string]struct{}
+
+// Add adds the operations to the set.
+func (ops Operations) Add(operations ...string) {
+	for _, op := range operations {
+		ops[op] = struct{}{}
+	}
+}
+
+// Idempotent reports whether the client request of ctx can be sent again: the
+// HTTP requests of the idempotent methods, the requests with the idempotency
+// key and the operations of ops.
+func Idempotent(ctx context.Context, ops Operations) bool {
+	tr, ok := transport.FromClientContext(ctx)
+	if !ok {
+		return false
+	}
+	if _, ok := ops[tr.Operation()]; ok {
+		return true
+	}
+	if tr.RequestHeader().Get(idempotency.Header) != "" {
+		return true
+	}
+	if ht, ok := tr.(interface{ Request() *http.Request }); ok && ht.Request() != nil {
+		switch ht.Request().Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+			return true
+		}
+	}
+	return false
+}
+
+// Retryable is the default of the errors sent again, the Unavailable and
+// DeadlineExceeded errors.
+func Retryable(err error) bool {
+	return errors.IsServiceUnavailable(err) || errors.IsGatewayTimeout(err)
+}
@@ -0,0 +1,73 @@
+// Package hedge marks the hedged attempts of the client requests. The attempts
+// run in parallel, so the client transports decode the reply of every attempt
+// into a new one, and copy the reply returned by the middleware chain into the
+// one of the caller.
+package hedge
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type hedgeKey struct{}
+
+// NewContext returns the context of a hedged attempt.
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, hedgeKey{}, true)
+}
+
+// FromContext reports whether ctx is the context of a hedged attempt.
+func FromContext(ctx context.Context) bool {
+	ok, _ := ctx.Value(hedgeKey{}).(bool)
+	return ok
+}
+
+// NewAttemptContext returns the context of a hedged attempt with a copy of the
+// client transport of ctx if it is cloneable, so that the attempts running in
+// parallel do not share the request header.
+func NewAttemptContext(ctx context.Context) context.Context {
+	ctx = NewContext(ctx)
+	tr, ok := transport.FromClientContext(ctx)
+	if !ok {
+		return ctx
+	}
+	if c, ok := tr.(interface{ Clone() transport.Transporter }); ok {
+		return transport.NewClientContext(ctx, c.Clone())
+	}
+	return ctx
+}
+
+// Reply returns a new reply of the type of reply if ctx is the context of a
+// hedged attempt, or reply itself.
+func Reply(ctx context.Context, reply interface{}) interface{} {
+	if !FromContext(ctx) || reply == nil {
+		return reply
+	}
+	if t := reflect.TypeOf(reply); t.Kind() == reflect.Ptr {
+		return reflect.New(t.Elem()).Interface()
+	}
+	return reply
+}
+
+// Copy copies src into dst if they differ, e.g. the reply of the winning
+// attempt into the one of the caller.
+func Copy(dst, src interface{}) {
+	if dst == nil || src == nil || dst == src {
+		return
+	}
+	if d, ok := dst.(proto.Message); ok {
+		if s, ok := src.(proto.Message); ok {
+			proto.Reset(d)
+			proto.Merge(d, s)
+			return
+		}
+	}
+	dv, sv := reflect.ValueOf(dst), reflect.ValueOf(src)
+	if dv.Kind() == reflect.Ptr && sv.Type() == dv.Type() {
+		dv.Elem().Set(sv.Elem())
+	}
+}
@@ -0,0 +1,32 @@
+package hedge
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestReply(t *testing.T) {
+	reply := wrapperspb.String("caller")
+	if v := Reply(context.Background(), reply); v != reply {
+		t.Fatal("want the reply of the caller")
+	}
+	out := Reply(NewContext(context.Background()), reply)
+	if out == reply {
+		t.Fatal("want a new reply")
+	}
+	out.(*wrapperspb.StringValue).Value = "hedged"
+	Copy(reply, out)
+	if !proto.Equal(reply, wrapperspb.String("hedged")) {
+		t.Fatalf("want the reply copied, got %v", reply)
+	}
+
+	type testReply struct{ Name string }
+	dst, src := &testReply{}, &testReply{Name: "hedged"}
+	Copy(dst, src)
+	if dst.Name != "hedged" {
+		t.Fatalf("want the reply copied, got %v", dst)
+	}
+}
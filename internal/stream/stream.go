@@ -0,0 +1,152 @@
+// Package stream wraps the gRPC streams for the stream interceptors of the
+// middleware, the wrappers count the messages and report the end of the
+// client streams, as the streams are not handled by the middleware.
+package stream
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ServerStream is the grpc.ServerStream with the context replaced and the
+// messages counted.
+type ServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	sent     int64
+	received int64
+}
+
+// NewServerStream returns the stream of ss with the context ctx.
+func NewServerStream(ctx context.Context, ss grpc.ServerStream) *ServerStream {
+	return &ServerStream{ServerStream: ss, ctx: ctx}
+}
+
+// Context returns the context of the stream.
+func (s *ServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg sends a message and counts it.
+func (s *ServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.sent, 1)
+	}
+	return err
+}
+
+// RecvMsg receives a message and counts it.
+func (s *ServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.received, 1)
+	}
+	return err
+}
+
+// Sent returns the number of the messages sent.
+func (s *ServerStream) Sent() int64 {
+	return atomic.LoadInt64(&s.sent)
+}
+
+// Received returns the number of the messages received.
+func (s *ServerStream) Received() int64 {
+	return atomic.LoadInt64(&s.received)
+}
+
+// DoneFunc is called when the client stream is finished with the numbers of
+// the messages and the error of the stream, nil if it succeeded.
+type DoneFunc func(sent, received int64, err error)
+
+// ClientStream is the grpc.ClientStream with the messages counted, it calls
+// the DoneFunc once the stream is finished, i.e. when the reply is received,
+// an error is returned or the context is done.
+type ClientStream struct {
+	grpc.ClientStream
+	desc     *grpc.StreamDesc
+	done     DoneFunc
+	once     sync.Once
+	finished chan struct{}
+	sent     int64
+	received int64
+}
+
+// NewClientStream returns the stream of cs calling done once it is finished,
+// ctx is the context of the stream.
+func NewClientStream(ctx context.Context, cs grpc.ClientStream, desc *grpc.StreamDesc, done DoneFunc) *ClientStream {
+	s := &ClientStream{
+		ClientStream: cs,
+		desc:         desc,
+		done:         done,
+		finished:     make(chan struct{}),
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.finish(ctx.Err())
+		case <-s.finished:
+		}
+	}()
+	return s
+}
+
+func (s *ClientStream) finish(err error) {
+	s.once.Do(func() {
+		close(s.finished)
+		s.done(atomic.LoadInt64(&s.sent), atomic.LoadInt64(&s.received), err)
+	})
+}
+
+// SendMsg sends a message and counts it, io.EOF is not the end of the stream
+// as its status is returned by RecvMsg.
+func (s *ClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.sent, 1)
+	} else if err != io.EOF {
+		s.finish(err)
+	}
+	return err
+}
+
+// RecvMsg receives a message and counts it, the stream is finished by io.EOF
+// or the reply of the streams whose server does not send a stream.
+func (s *ClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		atomic.AddInt64(&s.received, 1)
+		if !s.desc.ServerStreams {
+			s.finish(nil)
+		}
+	case err == io.EOF:
+		s.finish(nil)
+	default:
+		s.finish(err)
+	}
+	return err
+}
+
+// Header returns the header, the stream is finished if it fails.
+func (s *ClientStream) Header() (md metadata.MD, err error) {
+	md, err = s.ClientStream.Header()
+	if err != nil {
+		s.finish(err)
+	}
+	return md, err
+}
+
+// CloseSend closes the send direction, the stream is finished if it fails.
+func (s *ClientStream) CloseSend() error {
+	err := s.ClientStream.CloseSend()
+	if err != nil {
+		s.finish(err)
+	}
+	return err
+}
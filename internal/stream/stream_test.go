@@ -0,0 +1,104 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+type mockClientStream struct {
+	grpc.ClientStream
+	recv []error
+}
+
+func (s *mockClientStream) SendMsg(m interface{}) error { return nil }
+
+func (s *mockClientStream) RecvMsg(m interface{}) error {
+	err := s.recv[0]
+	s.recv = s.recv[1:]
+	return err
+}
+
+type result struct {
+	sent, received int64
+	err            error
+}
+
+func TestClientStream(t *testing.T) {
+	errStream := errors.New("stream error")
+	tests := []struct {
+		name string
+		desc *grpc.StreamDesc
+		recv []error
+		want result
+	}{
+		{"eof", &grpc.StreamDesc{ServerStreams: true}, []error{nil, nil, io.EOF}, result{1, 2, nil}},
+		{"error", &grpc.StreamDesc{ServerStreams: true}, []error{nil, errStream}, result{1, 1, errStream}},
+		{"reply", &grpc.StreamDesc{ClientStreams: true}, []error{nil}, result{1, 1, nil}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				calls int
+				got   result
+			)
+			s := NewClientStream(context.Background(), &mockClientStream{recv: test.recv}, test.desc, func(sent, received int64, err error) {
+				calls++
+				got = result{sent, received, err}
+			})
+			_ = s.SendMsg(nil)
+			for range test.recv {
+				_ = s.RecvMsg(nil)
+			}
+			if calls != 1 {
+				t.Fatalf("want done called once, got %d", calls)
+			}
+			if got != test.want {
+				t.Fatalf("want %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestClientStreamContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	NewClientStream(ctx, &mockClientStream{}, &grpc.StreamDesc{ServerStreams: true}, func(sent, received int64, err error) {
+		done <- err
+	})
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the stream is not finished when the context is done")
+	}
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *mockServerStream) SendMsg(m interface{}) error { return nil }
+func (s *mockServerStream) RecvMsg(m interface{}) error { return io.EOF }
+
+func TestServerStream(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	s := NewServerStream(ctx, &mockServerStream{})
+	_ = s.SendMsg(nil)
+	_ = s.SendMsg(nil)
+	_ = s.RecvMsg(nil)
+	if s.Context().Value(ctxKey{}) != "value" {
+		t.Fatal("want the context of the stream replaced")
+	}
+	if s.Sent() != 2 || s.Received() != 0 {
+		t.Fatalf("want 2 sent and 0 received, got %d and %d", s.Sent(), s.Received())
+	}
+}
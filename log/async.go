@@ -0,0 +1,171 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Flusher is the interface implemented by loggers that buffer entries.
+type Flusher interface {
+	Flush() error
+}
+
+// Flush flushes the logger and the loggers wrapped by it.
+func Flush(l Logger) error {
+	switch l := l.(type) {
+	case Flusher:
+		return l.Flush()
+	case *logger:
+		for _, v := range l.logs {
+			if err := Flush(v); err != nil {
+				return err
+			}
+		}
+	case *Filter:
+		return Flush(l.logger)
+	case *Sampler:
+		return Flush(l.logger)
+	case *loggerAppliance:
+		return Flush(l.GetLogger())
+	}
+	return nil
+}
+
+// AsyncOption is async logger option.
+type AsyncOption func(*Async)
+
+// AsyncBlock with Log blocked when the buffer is full,
+// by default the oldest entry is dropped.
+func AsyncBlock() AsyncOption {
+	return func(a *Async) {
+		a.block = true
+	}
+}
+
+// Async is an asynchronous logger, entries are buffered in a bounded ring
+// and written to the wrapped logger by a background goroutine.
+// Valuers are evaluated in that goroutine, so bind them before Async
+// by wrapping it with With rather than the other way around.
+type Async struct {
+	dropped uint64
+	logger  Logger
+	block   bool
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond
+	buf      []asyncEntry
+	head     int
+	size     int
+	writing  bool
+	closed   bool
+	done     chan struct{}
+}
+
+type asyncEntry struct {
+	level   Level
+	keyvals []interface{}
+}
+
+// NewAsync new an asynchronous logger with the buffer size.
+func NewAsync(logger Logger, bufferSize int, opts ...AsyncOption) *Async {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	a := &Async{
+		logger: logger,
+		buf:    make([]asyncEntry, bufferSize),
+		done:   make(chan struct{}),
+	}
+	a.notEmpty = sync.NewCond(&a.mu)
+	a.notFull = sync.NewCond(&a.mu)
+	a.idle = sync.NewCond(&a.mu)
+	for _, o := range opts {
+		o(a)
+	}
+	go a.run()
+	return a
+}
+
+// Log Print log by level and keyvals.
+func (a *Async) Log(level Level, keyvals ...interface{}) error {
+	kvs := make([]interface{}, len(keyvals))
+	copy(kvs, keyvals)
+	a.mu.Lock()
+	for a.block && !a.closed && a.size == len(a.buf) {
+		a.notFull.Wait()
+	}
+	if a.closed {
+		a.mu.Unlock()
+		return a.logger.Log(level, kvs...)
+	}
+	if a.size == len(a.buf) {
+		a.buf[a.head] = asyncEntry{}
+		a.head = (a.head + 1) % len(a.buf)
+		a.size--
+		atomic.AddUint64(&a.dropped, 1)
+	}
+	a.buf[(a.head+a.size)%len(a.buf)] = asyncEntry{level: level, keyvals: kvs}
+	a.size++
+	a.notEmpty.Signal()
+	a.mu.Unlock()
+	return nil
+}
+
+// Dropped returns the number of entries dropped as the buffer is full.
+func (a *Async) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Flush waits for the buffered entries to be written.
+func (a *Async) Flush() error {
+	a.mu.Lock()
+	for a.size > 0 || a.writing {
+		a.idle.Wait()
+	}
+	a.mu.Unlock()
+	return Flush(a.logger)
+}
+
+// Close flushes the buffered entries and stops the background goroutine,
+// entries logged after Close are written synchronously.
+func (a *Async) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		a.notEmpty.Signal()
+		a.notFull.Broadcast()
+	}
+	a.mu.Unlock()
+	<-a.done
+	return Flush(a.logger)
+}
+
+func (a *Async) run() {
+	defer close(a.done)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for {
+		for a.size == 0 && !a.closed {
+			a.notEmpty.Wait()
+		}
+		if a.size == 0 {
+			a.idle.Broadcast()
+			return
+		}
+		e := a.buf[a.head]
+		a.buf[a.head] = asyncEntry{}
+		a.head = (a.head + 1) % len(a.buf)
+		a.size--
+		a.writing = true
+		a.notFull.Signal()
+		a.mu.Unlock()
+		_ = a.logger.Log(e.level, e.keyvals...)
+		a.mu.Lock()
+		a.writing = false
+		if a.size == 0 {
+			a.idle.Broadcast()
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type blockingLogger struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (l *blockingLogger) Log(level Level, keyvals ...interface{}) error {
+	<-l.release
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return NewStdLogger(&l.buf).Log(level, keyvals...)
+}
+
+func (l *blockingLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func TestAsync(t *testing.T) {
+	sink := &blockingLogger{release: make(chan struct{})}
+	close(sink.release)
+	a := NewAsync(sink, 16)
+	for i := 0; i < 10; i++ {
+		_ = a.Log(LevelInfo, "msg", "async")
+	}
+	if err := a.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(sink.String(), "\n"); n != 10 {
+		t.Fatalf("want 10 lines after Flush, got %d", n)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = a.Log(LevelInfo, "msg", "closed")
+	if !strings.Contains(sink.String(), "msg=closed") {
+		t.Fatalf("entries after Close should be written, got %s", sink.String())
+	}
+}
+
+func TestAsyncDropOldest(t *testing.T) {
+	sink := &blockingLogger{release: make(chan struct{})}
+	a := NewAsync(sink, 2)
+	_ = a.Log(LevelInfo, "msg", "0")
+	// wait for the first entry to be taken by the writer
+	for {
+		a.mu.Lock()
+		writing := a.writing
+		a.mu.Unlock()
+		if writing {
+			break
+		}
+	}
+	for i := 1; i <= 4; i++ {
+		_ = a.Log(LevelInfo, "msg", string(rune('0'+i)))
+	}
+	close(sink.release)
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "INFO msg=0\nINFO msg=3\nINFO msg=4\n"; sink.String() != want {
+		t.Fatalf("want %q, got %q", want, sink.String())
+	}
+	if a.Dropped() != 2 {
+		t.Fatalf("want 2 dropped, got %d", a.Dropped())
+	}
+}
+
+func TestAsyncBlock(t *testing.T) {
+	sink := &blockingLogger{release: make(chan struct{})}
+	close(sink.release)
+	a := NewAsync(sink, 1, AsyncBlock())
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				_ = a.Log(LevelInfo, "msg", "block")
+			}
+		}()
+	}
+	wg.Wait()
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(sink.String(), "\n"); n != 100 || a.Dropped() != 0 {
+		t.Fatalf("want 100 lines and none dropped, got %d lines, %d dropped", n, a.Dropped())
+	}
+}
+
+func TestFlush(t *testing.T) {
+	sink := &blockingLogger{release: make(chan struct{})}
+	close(sink.release)
+	a := NewAsync(sink, 8)
+	l := NewFilter(With(a, "service", "test"), FilterLevel(LevelInfo))
+	_ = l.Log(LevelInfo, "msg", "flush")
+	if err := Flush(l); err != nil {
+		t.Fatal(err)
+	}
+	if want := "INFO service=test msg=flush\n"; sink.String() != want {
+		t.Fatalf("want %q, got %q", want, sink.String())
+	}
+	_ = a.Close()
+}
@@ -0,0 +1,293 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Logger = (*FileLogger)(nil)
+
+const backupTimeFormat = "20060102T150405.000"
+
+// Formatter formats the level and keyvals into a log line.
+type Formatter func(level Level, keyvals ...interface{}) []byte
+
+// TextFormatter formats the log line as the std logger does, "INFO k=v".
+func TextFormatter(level Level, keyvals ...interface{}) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString(level.String())
+	for i := 0; i < len(keyvals); i += 2 {
+		_, _ = fmt.Fprintf(buf, " %s=%v", keyvals[i], keyvals[i+1])
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// JSONFormatter formats the log line as a JSON object with the level under LevelKey.
+func JSONFormatter(level Level, keyvals ...interface{}) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	writeJSONField(buf, LevelKey, level.String())
+	for i := 0; i < len(keyvals); i += 2 {
+		buf.WriteByte(',')
+		writeJSONField(buf, fmt.Sprint(keyvals[i]), keyvals[i+1])
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}) {
+	k, _ := json.Marshal(key)
+	buf.Write(k)
+	buf.WriteByte(':')
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(v)
+}
+
+// FileOption is file logger option.
+type FileOption func(*FileLogger)
+
+// FileMaxSize with the size in bytes the file is rotated at, zero disables it.
+func FileMaxSize(size int64) FileOption {
+	return func(l *FileLogger) {
+		l.maxSize = size
+	}
+}
+
+// FileRotateInterval with the interval the file is rotated at, zero disables it.
+func FileRotateInterval(d time.Duration) FileOption {
+	return func(l *FileLogger) {
+		l.interval = d
+	}
+}
+
+// FileMaxBackups with the max number of rotated files kept, zero keeps all.
+func FileMaxBackups(n int) FileOption {
+	return func(l *FileLogger) {
+		l.maxBackups = n
+	}
+}
+
+// FileCompress with rotated files compressed by gzip.
+func FileCompress() FileOption {
+	return func(l *FileLogger) {
+		l.compress = true
+	}
+}
+
+// FileFormatter with the log line formatter, TextFormatter by default.
+func FileFormatter(f Formatter) FileOption {
+	return func(l *FileLogger) {
+		l.format = f
+	}
+}
+
+// FileLogger is a logger writing to a file rotated by size and time,
+// the rotated files are renamed with the rotation time as suffix.
+type FileLogger struct {
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	compress   bool
+	format     Formatter
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	// cleanMu serializes the compression and removal of backups.
+	cleanMu sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// NewFileLogger new a file logger with path.
+func NewFileLogger(path string, opts ...FileOption) (*FileLogger, error) {
+	l := &FileLogger{
+		path:   path,
+		format: TextFormatter,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Log print the kv pairs log.
+func (l *FileLogger) Log(level Level, keyvals ...interface{}) error {
+	if len(keyvals) == 0 {
+		return nil
+	}
+	if (len(keyvals) & 1) == 1 {
+		keyvals = append(keyvals, "KEYVALS UNPAIRED")
+	}
+	line := l.format(level, keyvals...)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return os.ErrClosed
+	}
+	if l.shouldRotate(int64(len(line))) {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// Flush commits the written logs to the disk.
+func (l *FileLogger) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Sync()
+}
+
+// Close closes the file and waits for the rotated files to be compressed.
+func (l *FileLogger) Close() error {
+	l.mu.Lock()
+	var err error
+	if l.file != nil {
+		if err = l.file.Sync(); err == nil {
+			err = l.file.Close()
+		} else {
+			_ = l.file.Close()
+		}
+		l.file = nil
+	}
+	l.mu.Unlock()
+	l.wg.Wait()
+	return err
+}
+
+// Rotate rotates the file immediately.
+func (l *FileLogger) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return os.ErrClosed
+	}
+	return l.rotate()
+}
+
+func (l *FileLogger) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	l.file = f
+	l.size = info.Size()
+	l.openedAt = time.Now()
+	return nil
+}
+
+func (l *FileLogger) shouldRotate(n int64) bool {
+	if l.maxSize > 0 && l.size > 0 && l.size+n > l.maxSize {
+		return true
+	}
+	return l.interval > 0 && time.Since(l.openedAt) >= l.interval
+}
+
+func (l *FileLogger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	backup := l.path + "." + time.Now().Format(backupTimeFormat)
+	for i := 1; exists(backup); i++ {
+		backup = fmt.Sprintf("%s.%s.%d", l.path, time.Now().Format(backupTimeFormat), i)
+	}
+	if err := os.Rename(l.path, backup); err != nil {
+		return err
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		l.cleanMu.Lock()
+		defer l.cleanMu.Unlock()
+		if l.compress {
+			_ = compressFile(backup)
+		}
+		l.removeBackups()
+	}()
+	return nil
+}
+
+func (l *FileLogger) removeBackups() {
+	if l.maxBackups <= 0 {
+		return
+	}
+	backups, err := filepath.Glob(l.path + ".*")
+	if err != nil {
+		return
+	}
+	prefix := len(l.path) + 1
+	sort.Slice(backups, func(i, j int) bool {
+		return strings.TrimSuffix(backups[i][prefix:], ".gz") > strings.TrimSuffix(backups[j][prefix:], ".gz")
+	})
+	for i := l.maxBackups; i < len(backups); i++ {
+		_ = os.Remove(backups[i])
+	}
+}
+
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(name + ".gz")
+		return err
+	}
+	return os.Remove(name)
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
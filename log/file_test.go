@@ -0,0 +1,122 @@
+package log
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewFileLogger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Log(LevelInfo, "msg", "hello", "n", 1)
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INFO msg=hello n=1\n"; string(b) != want {
+		t.Fatalf("want %q, got %q", want, b)
+	}
+	if err = l.Log(LevelInfo, "msg", "closed"); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("want ErrClosed, got %v", err)
+	}
+}
+
+func TestFileLoggerRotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	l, err := NewFileLogger(path, FileMaxSize(30), FileMaxBackups(2), FileCompress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		// every line is 20 bytes, so every line after the first rotates the file
+		_ = l.Log(LevelInfo, "msg", "rotate-____")
+		time.Sleep(2 * time.Millisecond)
+	}
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("want 2 backups, got %v", backups)
+	}
+	for _, b := range backups {
+		if !strings.HasSuffix(b, ".gz") {
+			t.Fatalf("backup should be compressed: %s", b)
+		}
+		f, err := os.Open(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(zr)
+		f.Close()
+		if string(data) != "INFO msg=rotate-____\n" {
+			t.Fatalf("unexpected backup content: %q", data)
+		}
+	}
+}
+
+func TestFileLoggerInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewFileLogger(path, FileRotateInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	_ = l.Log(LevelInfo, "msg", "first")
+	time.Sleep(20 * time.Millisecond)
+	_ = l.Log(LevelInfo, "msg", "second")
+	if backups, _ := filepath.Glob(path + ".*"); len(backups) != 1 {
+		t.Fatalf("want 1 backup, got %v", backups)
+	}
+}
+
+func TestFileLoggerJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewFileLogger(path, FileFormatter(JSONFormatter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = l.Log(LevelWarn, "msg", "json", "err", errors.New("oops"), "ch", make(chan int))
+		}()
+	}
+	wg.Wait()
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("want 10 lines, got %d", len(lines))
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m[LevelKey] != "WARN" || m["msg"] != "json" || m["err"] != "oops" {
+		t.Fatalf("unexpected line: %s", lines[0])
+	}
+}
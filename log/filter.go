@@ -1,5 +1,10 @@
 package log
 
+import (
+	"context"
+	"fmt"
+)
+
 // FilterOption is filter option.
 type FilterOption func(*Filter)
 
@@ -37,12 +42,23 @@ func FilterFunc(f func(level Level, keyvals ...interface{}) bool) FilterOption {
 	}
 }
 
+// FilterKeyValue with filter key values, the log entry is discarded
+// if any of its keys has the given value.
+func FilterKeyValue(kv map[string]string) FilterOption {
+	return func(o *Filter) {
+		for k, v := range kv {
+			o.kv[k] = v
+		}
+	}
+}
+
 // Filter is a logger filter.
 type Filter struct {
 	logger Logger
 	level  Level
 	key    map[interface{}]struct{}
 	value  map[interface{}]struct{}
+	kv     map[string]string
 	filter func(level Level, keyvals ...interface{}) bool
 }
 
@@ -52,6 +68,7 @@ func NewFilter(logger Logger, opts ...FilterOption) *Filter {
 		logger: logger,
 		key:    make(map[interface{}]struct{}),
 		value:  make(map[interface{}]struct{}),
+		kv:     make(map[string]string),
 	}
 	for _, o := range opts {
 		o(&options)
@@ -65,19 +82,18 @@ func (f *Filter) Log(level Level, keyvals ...interface{}) error {
 		return nil
 	}
 	// fkv is used to provide a slice to contains both logger.prefix and keyvals for filter
-	var fkv []interface{}
-	if l, ok := f.logger.(*logger); ok {
-		if len(l.prefix) > 0 {
-			fkv = make([]interface{}, 0, len(l.prefix)+len(keyvals))
-			fkv = append(fkv, l.prefix...)
-			fkv = append(fkv, keyvals...)
-		}
-	} else {
-		fkv = keyvals
+	fkv := keyvals
+	if l, ok := f.logger.(*logger); ok && len(l.prefix) > 0 {
+		fkv = make([]interface{}, 0, len(l.prefix)+len(keyvals))
+		fkv = append(fkv, l.prefix...)
+		fkv = append(fkv, keyvals...)
 	}
 	if f.filter != nil && f.filter(level, fkv...) {
 		return nil
 	}
+	if len(f.kv) > 0 && f.matchKeyValue(fkv) {
+		return nil
+	}
 	if len(f.key) > 0 || len(f.value) > 0 {
 		for i := 0; i < len(keyvals); i += 2 {
 			v := i + 1
@@ -94,3 +110,51 @@ func (f *Filter) Log(level Level, keyvals ...interface{}) error {
 	}
 	return f.logger.Log(level, keyvals...)
 }
+
+func (f *Filter) matchKeyValue(keyvals []interface{}) bool {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		k, ok := keyvals[i].(string)
+		if !ok {
+			continue
+		}
+		want, ok := f.kv[k]
+		if !ok {
+			continue
+		}
+		v := keyvals[i+1]
+		if _, ok := v.(Valuer); ok {
+			var ctx context.Context
+			if l, ok := f.logger.(*logger); ok {
+				ctx = l.ctx
+			}
+			v = Value(ctx, v)
+		}
+		if fmt.Sprint(v) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// enabled reports whether the level would be logged through the filters
+// wrapped by the logger.
+func enabled(l Logger, level Level) bool {
+	switch l := l.(type) {
+	case *Filter:
+		return level >= l.level && enabled(l.logger, level)
+	case *logger:
+		for _, v := range l.logs {
+			if enabled(v, level) {
+				return true
+			}
+		}
+		return len(l.logs) == 0
+	case *loggerAppliance:
+		return enabled(l.GetLogger(), level)
+	case *Sampler:
+		return enabled(l.logger, level)
+	case *Async:
+		return enabled(l.logger, level)
+	}
+	return true
+}
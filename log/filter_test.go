@@ -2,6 +2,7 @@ package log
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"testing"
 )
@@ -131,3 +132,38 @@ func testFilterFuncWithLoggerPrefix(level Level, keyvals ...interface{}) bool {
 	}
 	return false
 }
+
+func TestFilterKeyValue(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := NewFilter(With(NewStdLogger(buf), "component", "http"),
+		FilterLevel(LevelInfo),
+		FilterKeyValue(map[string]string{"path": "/healthz"}),
+	)
+	_ = logger.Log(LevelInfo, "path", "/healthz")
+	_ = logger.Log(LevelInfo, "path", "/users")
+	_ = logger.Log(LevelDebug, "path", "/users")
+	if want := "INFO component=http path=/users\n"; buf.String() != want {
+		t.Fatalf("want %q, got %q", want, buf.String())
+	}
+
+	buf.Reset()
+	prefixed := NewFilter(With(NewStdLogger(buf), "path", Valuer(func(context.Context) interface{} { return "/healthz" })),
+		FilterKeyValue(map[string]string{"path": "/healthz"}),
+	)
+	_ = prefixed.Log(LevelInfo, "msg", "ping")
+	if buf.Len() != 0 {
+		t.Fatalf("prefix valuer should be matched, got %q", buf.String())
+	}
+}
+
+func TestFilterFuncWithoutPrefix(t *testing.T) {
+	var seen []interface{}
+	logger := NewFilter(MultiLogger(NewStdLogger(io.Discard)), FilterFunc(func(level Level, keyvals ...interface{}) bool {
+		seen = keyvals
+		return false
+	}))
+	_ = logger.Log(LevelInfo, "msg", "hello")
+	if len(seen) != 2 || seen[1] != "hello" {
+		t.Fatalf("filter func should see the message, got %v", seen)
+	}
+}
@@ -51,6 +51,12 @@ func (h *Helper) Log(level Level, keyvals ...interface{}) {
 	_ = h.logger.Log(level, keyvals...)
 }
 
+// Enabled reports whether the level would be logged by the filters
+// the helper writes through.
+func (h *Helper) Enabled(level Level) bool {
+	return enabled(h.logger, level)
+}
+
 // Debug logs a message at debug level.
 func (h *Helper) Debug(a ...interface{}) {
 	_ = h.logger.Log(LevelDebug, h.msgKey, fmt.Sprint(a...))
@@ -61,6 +67,13 @@ func (h *Helper) Debugf(format string, a ...interface{}) {
 	_ = h.logger.Log(LevelDebug, h.msgKey, fmt.Sprintf(format, a...))
 }
 
+// DebugFunc logs the message built by f at debug level, f is only called if the level is enabled.
+func (h *Helper) DebugFunc(f func() string) {
+	if h.Enabled(LevelDebug) {
+		_ = h.logger.Log(LevelDebug, h.msgKey, f())
+	}
+}
+
 // Debugw logs a message at debug level.
 func (h *Helper) Debugw(keyvals ...interface{}) {
 	_ = h.logger.Log(LevelDebug, keyvals...)
@@ -76,6 +89,13 @@ func (h *Helper) Infof(format string, a ...interface{}) {
 	_ = h.logger.Log(LevelInfo, h.msgKey, fmt.Sprintf(format, a...))
 }
 
+// InfoFunc logs the message built by f at info level, f is only called if the level is enabled.
+func (h *Helper) InfoFunc(f func() string) {
+	if h.Enabled(LevelInfo) {
+		_ = h.logger.Log(LevelInfo, h.msgKey, f())
+	}
+}
+
 // Infow logs a message at info level.
 func (h *Helper) Infow(keyvals ...interface{}) {
 	_ = h.logger.Log(LevelInfo, keyvals...)
@@ -91,6 +111,13 @@ func (h *Helper) Warnf(format string, a ...interface{}) {
 	_ = h.logger.Log(LevelWarn, h.msgKey, fmt.Sprintf(format, a...))
 }
 
+// WarnFunc logs the message built by f at warn level, f is only called if the level is enabled.
+func (h *Helper) WarnFunc(f func() string) {
+	if h.Enabled(LevelWarn) {
+		_ = h.logger.Log(LevelWarn, h.msgKey, f())
+	}
+}
+
 // Warnw logs a message at warnf level.
 func (h *Helper) Warnw(keyvals ...interface{}) {
 	_ = h.logger.Log(LevelWarn, keyvals...)
@@ -106,6 +133,13 @@ func (h *Helper) Errorf(format string, a ...interface{}) {
 	_ = h.logger.Log(LevelError, h.msgKey, fmt.Sprintf(format, a...))
 }
 
+// ErrorFunc logs the message built by f at error level, f is only called if the level is enabled.
+func (h *Helper) ErrorFunc(f func() string) {
+	if h.Enabled(LevelError) {
+		_ = h.logger.Log(LevelError, h.msgKey, f())
+	}
+}
+
 // Errorw logs a message at error level.
 func (h *Helper) Errorw(keyvals ...interface{}) {
 	_ = h.logger.Log(LevelError, keyvals...)
@@ -123,6 +157,14 @@ func (h *Helper) Fatalf(format string, a ...interface{}) {
 	os.Exit(1)
 }
 
+// FatalFunc logs the message built by f at fatal level and exits, f is only called if the level is enabled.
+func (h *Helper) FatalFunc(f func() string) {
+	if h.Enabled(LevelFatal) {
+		_ = h.logger.Log(LevelFatal, h.msgKey, f())
+	}
+	os.Exit(1)
+}
+
 // Fatalw logs a message at fatal level.
 func (h *Helper) Fatalw(keyvals ...interface{}) {
 	_ = h.logger.Log(LevelFatal, keyvals...)
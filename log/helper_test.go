@@ -1,9 +1,13 @@
 package log
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"io"
 	"os"
+	"os/exec"
+	"strings"
 	"testing"
 )
 
@@ -77,3 +81,46 @@ func Trace() Valuer {
 		return s
 	}
 }
+
+func TestHelperFunc(t *testing.T) {
+	buf := new(bytes.Buffer)
+	calls := 0
+	build := func() string {
+		calls++
+		return "built"
+	}
+	h := NewHelper(With(NewFilter(NewStdLogger(buf), FilterLevel(LevelInfo)), "service", "test"))
+	h.DebugFunc(build)
+	if calls != 0 || buf.Len() != 0 {
+		t.Fatalf("debug func should not be called, calls=%d log=%q", calls, buf.String())
+	}
+	h.InfoFunc(build)
+	h.WarnFunc(build)
+	h.ErrorFunc(build)
+	if calls != 3 {
+		t.Fatalf("want 3 calls, got %d", calls)
+	}
+	if want := "INFO service=test msg=built\nWARN service=test msg=built\nERROR service=test msg=built\n"; buf.String() != want {
+		t.Fatalf("want %q, got %q", want, buf.String())
+	}
+	if !NewHelper(NewStdLogger(buf)).Enabled(LevelDebug) {
+		t.Fatal("unfiltered logger should enable all levels")
+	}
+}
+
+func TestHelperFatalFunc(t *testing.T) {
+	if os.Getenv("KRATOS_TEST_FATAL_FUNC") == "1" {
+		NewHelper(NewStdLogger(os.Stdout)).FatalFunc(func() string { return "built" })
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperFatalFunc$")
+	cmd.Env = append(os.Environ(), "KRATOS_TEST_FATAL_FUNC=1")
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) || exit.ExitCode() != 1 {
+		t.Fatalf("want exit code 1, got %v", err)
+	}
+	if !strings.Contains(string(out), "FATAL msg=built") {
+		t.Fatalf("want the message logged, got %q", out)
+	}
+}
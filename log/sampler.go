@@ -0,0 +1,125 @@
+package log
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+)
+
+const samplerCounters = 4096
+
+// SamplerOption is sampler option.
+type SamplerOption func(*Sampler)
+
+// SampleFirst with the number of entries logged per message in every tick.
+func SampleFirst(n uint64) SamplerOption {
+	return func(s *Sampler) {
+		s.first = n
+	}
+}
+
+// SampleThereafter with one of every m entries logged after the first ones,
+// zero drops all of them.
+func SampleThereafter(m uint64) SamplerOption {
+	return func(s *Sampler) {
+		s.thereafter = m
+	}
+}
+
+// SampleTick with the interval the counters are reset.
+func SampleTick(d time.Duration) SamplerOption {
+	return func(s *Sampler) {
+		s.tick = d
+	}
+}
+
+// SampleMessageKey with the key of the message entries are sampled by.
+func SampleMessageKey(key string) SamplerOption {
+	return func(s *Sampler) {
+		s.msgKey = key
+	}
+}
+
+// Sampler is a logger sampler, it logs the first entries of every message
+// and level in a tick, and then one of every thereafter entries.
+type Sampler struct {
+	// counters must be the first field to be 64-bit aligned.
+	counters   [samplerCounters]counter
+	sampled    uint64
+	dropped    uint64
+	logger     Logger
+	first      uint64
+	thereafter uint64
+	tick       time.Duration
+	msgKey     string
+}
+
+type counter struct {
+	resetAt int64
+	count   uint64
+}
+
+// NewSampler new a logger sampler.
+func NewSampler(logger Logger, opts ...SamplerOption) *Sampler {
+	s := &Sampler{
+		logger:     logger,
+		first:      100,
+		thereafter: 100,
+		tick:       time.Second,
+		msgKey:     DefaultMessageKey,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Log Print log by level and keyvals.
+func (s *Sampler) Log(level Level, keyvals ...interface{}) error {
+	c := &s.counters[s.index(level, keyvals)]
+	if !c.allow(time.Now().UnixNano(), int64(s.tick), s.first, s.thereafter) {
+		atomic.AddUint64(&s.dropped, 1)
+		return nil
+	}
+	atomic.AddUint64(&s.sampled, 1)
+	return s.logger.Log(level, keyvals...)
+}
+
+// Sampled returns the number of entries logged.
+func (s *Sampler) Sampled() uint64 {
+	return atomic.LoadUint64(&s.sampled)
+}
+
+// Dropped returns the number of entries dropped.
+func (s *Sampler) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *Sampler) index(level Level, keyvals []interface{}) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte{byte(level)})
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if k, ok := keyvals[i].(string); ok && k == s.msgKey {
+			if msg, ok := keyvals[i+1].(string); ok {
+				_, _ = h.Write([]byte(msg))
+			} else {
+				_, _ = fmt.Fprint(h, keyvals[i+1])
+			}
+			break
+		}
+	}
+	return h.Sum32() % samplerCounters
+}
+
+func (c *counter) allow(now, tick int64, first, thereafter uint64) bool {
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	if now > resetAt && atomic.CompareAndSwapInt64(&c.resetAt, resetAt, now+tick) {
+		atomic.StoreUint64(&c.count, 0)
+	}
+	n := atomic.AddUint64(&c.count, 1)
+	if n <= first {
+		return true
+	}
+	return thereafter > 0 && (n-first)%thereafter == 0
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	s := NewSampler(NewStdLogger(buf), SampleFirst(2), SampleThereafter(3), SampleTick(time.Hour))
+	for i := 0; i < 8; i++ {
+		_ = s.Log(LevelWarn, "msg", "flood")
+	}
+	_ = s.Log(LevelWarn, "msg", "other")
+	_ = s.Log(LevelError, "msg", "flood")
+	// first 2, then the 5th and 8th of "flood", plus "other" and the error
+	if n := strings.Count(buf.String(), "\n"); n != 6 {
+		t.Fatalf("want 6 lines, got %d: %s", n, buf.String())
+	}
+	if s.Sampled() != 6 || s.Dropped() != 4 {
+		t.Fatalf("unexpected counters sampled=%d dropped=%d", s.Sampled(), s.Dropped())
+	}
+}
+
+func TestSamplerTick(t *testing.T) {
+	buf := new(bytes.Buffer)
+	s := NewSampler(NewStdLogger(buf), SampleFirst(1), SampleThereafter(0), SampleTick(10*time.Millisecond))
+	_ = s.Log(LevelInfo, "msg", "tick")
+	_ = s.Log(LevelInfo, "msg", "tick")
+	time.Sleep(20 * time.Millisecond)
+	_ = s.Log(LevelInfo, "msg", "tick")
+	if s.Sampled() != 2 || s.Dropped() != 1 {
+		t.Fatalf("unexpected counters sampled=%d dropped=%d", s.Sampled(), s.Dropped())
+	}
+}
+
+func TestSamplerConcurrent(t *testing.T) {
+	s := NewSampler(NewStdLogger(new(bytes.Buffer)), SampleFirst(10), SampleTick(time.Hour))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = s.Log(LevelInfo, "msg", "concurrent")
+			}
+		}()
+	}
+	wg.Wait()
+	if s.Sampled()+s.Dropped() != 800 {
+		t.Fatalf("unexpected counters sampled=%d dropped=%d", s.Sampled(), s.Dropped())
+	}
+}
@@ -2,6 +2,7 @@ package log
 
 import (
 	"context"
+	"fmt"
 	"runtime"
 	"strconv"
 	"strings"
@@ -53,6 +54,31 @@ func Timestamp(layout string) Valuer {
 	}
 }
 
+// ContextValue returns a Valuer that returns the value of key in the context,
+// or nil if the context or the value is missing.
+func ContextValue(key interface{}) Valuer {
+	return func(ctx context.Context) interface{} {
+		if ctx == nil {
+			return nil
+		}
+		return ctx.Value(key)
+	}
+}
+
+// WithContextValues with logger fields of the context values of keys,
+// the keys are logged by their string form.
+func WithContextValues(l Logger, keys ...interface{}) Logger {
+	kvs := make([]interface{}, 0, len(keys)*2)
+	for _, k := range keys {
+		name, ok := k.(string)
+		if !ok {
+			name = fmt.Sprint(k)
+		}
+		kvs = append(kvs, name, ContextValue(k))
+	}
+	return With(l, kvs...)
+}
+
 func bindValues(ctx context.Context, keyvals []interface{}) {
 	for i := 1; i < len(keyvals); i += 2 {
 		if v, ok := keyvals[i].(Valuer); ok {
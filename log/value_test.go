@@ -1,6 +1,7 @@
 package log
 
 import (
+	"bytes"
 	"context"
 	"testing"
 )
@@ -28,3 +29,25 @@ func TestValue(t *testing.T) {
 		t.Errorf("Value() = %v, want %v", res, 3)
 	}
 }
+
+type userKey struct{}
+
+func (userKey) String() string { return "user_id" }
+
+func TestWithContextValues(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := WithContextValues(With(NewStdLogger(buf), "service", "test"), "trace_id", userKey{})
+	logger = With(logger, "caller", Valuer(func(context.Context) interface{} { return "main.go" }))
+
+	h := NewHelper(logger)
+	h.Info("no context")
+	ctx := context.WithValue(context.Background(), "trace_id", "t1") //nolint:staticcheck
+	ctx = context.WithValue(ctx, userKey{}, 42)
+	h.WithContext(ctx).Info("with context")
+
+	want := "INFO caller=main.go trace_id=<nil> user_id=<nil> service=test msg=no context\n" +
+		"INFO caller=main.go trace_id=t1 user_id=42 service=test msg=with context\n"
+	if buf.String() != want {
+		t.Fatalf("want %q, got %q", want, buf.String())
+	}
+}
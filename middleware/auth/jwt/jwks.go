@@ -0,0 +1,219 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// minJWKSRefresh is the min interval of the refreshes triggered by unknown kids.
+const minJWKSRefresh = time.Second
+
+var errUnknownKID = errors.New("jwt: unknown kid")
+
+// WithJWKS with the keys of the server side fetched from the JWKS, the key is
+// selected by the "kid" header of the token. It is used if the keyFunc of
+// Server is nil.
+func WithJWKS(j *JWKS) Option {
+	return func(o *options) {
+		o.jwks = j
+	}
+}
+
+// JWKS is the keys fetched from a JWKS url. A token of an unknown kid triggers
+// a refresh, and the last fetched keys are kept serving if a refresh fails.
+type JWKS struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+	done    chan struct{}
+	close   sync.Once
+	wg      sync.WaitGroup
+
+	fetchMu   sync.Mutex
+	mu        sync.RWMutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// NewJWKS returns the keys fetched from url, they are fetched and refreshed
+// every refresh in background until Close is called, or fetched by the
+// first token only if refresh is zero.
+func NewJWKS(url string, refresh time.Duration) *JWKS {
+	j := &JWKS{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		done:    make(chan struct{}),
+	}
+	if refresh > 0 {
+		j.wg.Add(1)
+		go j.run()
+	}
+	return j
+}
+
+func (j *JWKS) run() {
+	defer j.wg.Done()
+	// the first token may have fetched the keys already
+	_ = j.fetch(true)
+	ticker := time.NewTicker(j.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-j.done:
+			return
+		case <-ticker.C:
+			_ = j.fetch(false)
+		}
+	}
+}
+
+// Close stops the refresh of the keys and waits for it to exit.
+func (j *JWKS) Close() error {
+	j.close.Do(func() {
+		close(j.done)
+	})
+	j.wg.Wait()
+	return nil
+}
+
+// Keyfunc returns the key of the "kid" header of the token, it is a
+// jwt.Keyfunc.
+func (j *JWKS) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := j.key(kid); ok {
+		return key, nil
+	}
+	if err := j.fetch(true); err != nil {
+		return nil, err
+	}
+	if key, ok := j.key(kid); ok {
+		return key, nil
+	}
+	return nil, errUnknownKID
+}
+
+func (j *JWKS) key(kid string) (interface{}, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// fetch fetches the keys, the fetch is skipped if missed is true and the
+// keys have just been fetched.
+func (j *JWKS) fetch(missed bool) error {
+	j.fetchMu.Lock()
+	defer j.fetchMu.Unlock()
+	j.mu.RLock()
+	fetchedAt := j.fetchedAt
+	j.mu.RUnlock()
+	if missed && time.Since(fetchedAt) < minJWKSRefresh {
+		return nil
+	}
+	keys, err := j.load()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.fetchedAt = time.Now()
+	if err != nil {
+		return err
+	}
+	j.keys = keys
+	return nil
+}
+
+func (j *JWKS) load() (map[string]interface{}, error) {
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwt: fetch jwks: %s", resp.Status)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		// keys of unsupported types are skipped
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// jsonWebKey is a JSON web key of RFC 7517.
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("jwt: unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	default:
+		return nil, fmt.Errorf("jwt: unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
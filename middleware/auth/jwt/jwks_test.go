@@ -0,0 +1,133 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type testJWKS struct {
+	mu       sync.Mutex
+	keys     map[string]*rsa.PublicKey
+	fail     bool
+	requests int32
+}
+
+func (s *testJWKS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.requests, 1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	keys := make([]map[string]string, 0, len(s.keys))
+	for kid, k := range s.keys {
+		keys = append(keys, map[string]string{
+			"kid": kid,
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		})
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+}
+
+func (s *testJWKS) set(kid string, key *rsa.PublicKey, fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key != nil {
+		s.keys[kid] = key
+	}
+	s.fail = fail
+}
+
+func signRS256(t *testing.T, kid string, key *rsa.PrivateKey) context.Context {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"name": "kratos"})
+	token.Header["kid"] = kid
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transport.NewServerContext(context.Background(), &Transport{reqHeader: newTokenHeader(authorizationKey, fmt.Sprintf(bearerFormat, s))})
+}
+
+func TestJWKS(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+	set := &testJWKS{keys: map[string]*rsa.PublicKey{"k1": &key1.PublicKey}}
+	srv := httptest.NewServer(set)
+	defer srv.Close()
+
+	jwks := NewJWKS(srv.URL, time.Hour)
+	defer jwks.Close()
+	next := Server(nil, WithSigningMethod(jwt.SigningMethodRS256), WithJWKS(jwks))(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "reply", nil
+		})
+	if _, err := next(signRS256(t, "k1", key1), nil); err != nil {
+		t.Fatal(err)
+	}
+	// unknown kid refreshes the keys once
+	time.Sleep(minJWKSRefresh)
+	set.set("k2", &key2.PublicKey, false)
+	if _, err := next(signRS256(t, "k2", key2), nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&set.requests); n != 2 {
+		t.Fatalf("want 2 requests, got %d", n)
+	}
+	// the last keys are kept if the refresh fails
+	time.Sleep(minJWKSRefresh)
+	set.set("", nil, true)
+	if _, err := next(signRS256(t, "k3", key2), nil); err == nil {
+		t.Fatal("unknown kid should be rejected")
+	}
+	if _, err := next(signRS256(t, "k1", key1), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJWKS_Close(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	set := &testJWKS{keys: map[string]*rsa.PublicKey{"k1": &key.PublicKey}}
+	srv := httptest.NewServer(set)
+	defer srv.Close()
+
+	jwks := NewJWKS(srv.URL, 10*time.Millisecond)
+	for atomic.LoadInt32(&set.requests) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := jwks.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// the refresh is stopped after Close
+	n := atomic.LoadInt32(&set.requests)
+	time.Sleep(50 * time.Millisecond)
+	if m := atomic.LoadInt32(&set.requests); m != n {
+		t.Fatalf("want %d requests after close, got %d", n, m)
+	}
+	// the keys fetched are kept serving
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"name": "kratos"})
+	token.Header["kid"] = "k1"
+	if _, err := jwks.Keyfunc(token); err != nil {
+		t.Fatal(err)
+	}
+	if err := jwks.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
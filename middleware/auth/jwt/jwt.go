@@ -40,6 +40,8 @@ var (
 	ErrNeedTokenProvider      = errors.Unauthorized(reason, "Token provider is missing")
 	ErrSignToken              = errors.Unauthorized(reason, "Can not sign token.Is the key correct?")
 	ErrGetKey                 = errors.Unauthorized(reason, "Can not get key while signing token")
+	ErrTokenAudience          = errors.Unauthorized(reason, "JWT token has a wrong audience")
+	ErrTokenIssuer            = errors.Unauthorized(reason, "JWT token has a wrong issuer")
 )
 
 // Option is jwt option.
@@ -50,6 +52,9 @@ type options struct {
 	signingMethod jwt.SigningMethod
 	claims        func() jwt.Claims
 	tokenHeader   map[string]interface{}
+	audience      string
+	issuer        string
+	jwks          *JWKS
 }
 
 // WithSigningMethod with signing method option.
@@ -75,7 +80,25 @@ func WithTokenHeader(header map[string]interface{}) Option {
 	}
 }
 
+// WithAudience with the audience the token must be issued for on server side,
+// the claims must implement VerifyAudience as jwt.RegisteredClaims does.
+func WithAudience(aud string) Option {
+	return func(o *options) {
+		o.audience = aud
+	}
+}
+
+// WithIssuer with the issuer the token must be issued by on server side,
+// the claims must implement VerifyIssuer as jwt.RegisteredClaims does.
+func WithIssuer(iss string) Option {
+	return func(o *options) {
+		o.issuer = iss
+	}
+}
+
 // Server is a server auth middleware. Check the token and extract the info from token.
+// The keyFunc is called with the parsed token on every request, so it can select
+// the key by the "kid" header to support rotating keys.
 func Server(keyFunc jwt.Keyfunc, opts ...Option) middleware.Middleware {
 	o := &options{
 		signingMethod: jwt.SigningMethodHS256,
@@ -83,6 +106,9 @@ func Server(keyFunc jwt.Keyfunc, opts ...Option) middleware.Middleware {
 	for _, opt := range opts {
 		opt(o)
 	}
+	if keyFunc == nil && o.jwks != nil {
+		keyFunc = o.jwks.Keyfunc
+	}
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			if header, ok := transport.FromServerContext(ctx); ok {
@@ -119,6 +145,9 @@ func Server(keyFunc jwt.Keyfunc, opts ...Option) middleware.Middleware {
 				} else if tokenInfo.Method != o.signingMethod {
 					return nil, ErrUnSupportSigningMethod
 				}
+				if err = o.verify(tokenInfo.Claims); err != nil {
+					return nil, err
+				}
 				ctx = NewContext(ctx, tokenInfo.Claims)
 				return handler(ctx, req)
 			}
@@ -127,6 +156,23 @@ func Server(keyFunc jwt.Keyfunc, opts ...Option) middleware.Middleware {
 	}
 }
 
+// verify verifies the audience and issuer of claims.
+func (o *options) verify(claims jwt.Claims) error {
+	if o.audience != "" {
+		c, ok := claims.(interface{ VerifyAudience(string, bool) bool })
+		if !ok || !c.VerifyAudience(o.audience, true) {
+			return ErrTokenAudience
+		}
+	}
+	if o.issuer != "" {
+		c, ok := claims.(interface{ VerifyIssuer(string, bool) bool })
+		if !ok || !c.VerifyIssuer(o.issuer, true) {
+			return ErrTokenIssuer
+		}
+	}
+	return nil
+}
+
 // Client is a client jwt middleware.
 func Client(keyProvider jwt.Keyfunc, opts ...Option) middleware.Middleware {
 	claims := jwt.RegisteredClaims{}
@@ -493,3 +493,51 @@ func TestClientMissKey(t *testing.T) {
 		}
 	})
 }
+
+func TestServerAudienceIssuer(t *testing.T) {
+	testKey := []byte("testKey")
+	claims := jwt.NewWithClaims(jwt.SigningMethodHS256, &CustomerClaims{
+		Name: "kratos",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience: jwt.ClaimStrings{"api"},
+			Issuer:   "idp",
+		},
+	})
+	token, err := claims.SignedString(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := transport.NewServerContext(context.Background(), &Transport{reqHeader: newTokenHeader(authorizationKey, fmt.Sprintf(bearerFormat, token))})
+	tests := []struct {
+		name string
+		opts []Option
+		err  error
+	}{
+		{"match", []Option{WithAudience("api"), WithIssuer("idp")}, nil},
+		{"audience", []Option{WithAudience("admin")}, ErrTokenAudience},
+		{"issuer", []Option{WithIssuer("other")}, ErrTokenIssuer},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := append([]Option{WithClaims(func() jwt.Claims { return &CustomerClaims{} })}, test.opts...)
+			var name string
+			next := Server(func(token *jwt.Token) (interface{}, error) {
+				return testKey, nil
+			}, opts...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+				c, _ := FromContext(ctx)
+				name = c.(*CustomerClaims).Name
+				return "reply", nil
+			})
+			_, err := next(ctx, nil)
+			if test.err == nil {
+				if err != nil || name != "kratos" {
+					t.Fatalf("want claims of kratos, got %s, err %v", name, err)
+				}
+				return
+			}
+			if err != test.err {
+				t.Fatalf("want %v, got %v", test.err, err)
+			}
+		})
+	}
+}
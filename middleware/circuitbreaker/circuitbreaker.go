@@ -2,6 +2,8 @@ package circuitbreaker
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 
 	"github.com/go-kratos/aegis/circuitbreaker"
 	"github.com/go-kratos/aegis/circuitbreaker/sre"
@@ -14,6 +16,23 @@ import (
 // ErrNotAllowed is request failed due to circuit breaker triggered.
 var ErrNotAllowed = errors.New(503, "CIRCUITBREAKER", "request failed due to circuit breaker triggered")
 
+// State is the state of a circuit breaker.
+type State int32
+
+const (
+	// StateClosed is the state requests are allowed.
+	StateClosed State = iota
+	// StateOpen is the state requests are rejected.
+	StateOpen
+)
+
+func (s State) String() string {
+	if s == StateOpen {
+		return "open"
+	}
+	return "closed"
+}
+
 // Option is circuit breaker option.
 type Option func(*options)
 
@@ -25,8 +44,42 @@ func WithGroup(g *group.Group) Option {
 	}
 }
 
+// WithBreaker with the circuit breaker created for every operation.
+func WithBreaker(f func(operation string) circuitbreaker.CircuitBreaker) Option {
+	return func(o *options) {
+		o.breaker = f
+	}
+}
+
+// WithFallback with the fallback called when the request is rejected by an
+// open breaker, the reply is returned instead of ErrNotAllowed if handled.
+func WithFallback(f func(ctx context.Context, operation string, err error) (reply interface{}, handled bool)) Option {
+	return func(o *options) {
+		o.fallback = f
+	}
+}
+
+// WithStateChange with the func called when the breaker of an operation
+// changes state, with StateOpen on the first rejected request and StateClosed
+// on the first allowed request succeeding afterwards, so the failed requests
+// an open breaker lets through as probes do not close it.
+func WithStateChange(f func(operation string, state State)) Option {
+	return func(o *options) {
+		o.stateChange = f
+	}
+}
+
 type options struct {
-	group *group.Group
+	group       *group.Group
+	breaker     func(operation string) circuitbreaker.CircuitBreaker
+	fallback    func(ctx context.Context, operation string, err error) (interface{}, bool)
+	stateChange func(operation string, state State)
+}
+
+// breaker is the circuit breaker of an operation with its last state.
+type breaker struct {
+	circuitbreaker.CircuitBreaker
+	state int32
 }
 
 // Client circuitbreaker middleware will return errBreakerTriggered when the circuit
@@ -40,15 +93,57 @@ func Client(opts ...Option) middleware.Middleware {
 	for _, o := range opts {
 		o(opt)
 	}
+	var (
+		mu       sync.RWMutex
+		breakers = make(map[string]*breaker)
+	)
+	get := func(operation string) *breaker {
+		mu.RLock()
+		b, ok := breakers[operation]
+		mu.RUnlock()
+		if ok {
+			return b
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if b, ok = breakers[operation]; ok {
+			return b
+		}
+		if opt.breaker != nil {
+			b = &breaker{CircuitBreaker: opt.breaker(operation)}
+		} else {
+			b = &breaker{CircuitBreaker: opt.group.Get(operation).(circuitbreaker.CircuitBreaker)}
+		}
+		breakers[operation] = b
+		return b
+	}
+	setState := func(operation string, b *breaker, state State) {
+		old := atomic.LoadInt32(&b.state)
+		if old == int32(state) || !atomic.CompareAndSwapInt32(&b.state, old, int32(state)) {
+			return
+		}
+		if opt.stateChange != nil {
+			opt.stateChange(operation, state)
+		}
+	}
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
-			info, _ := transport.FromClientContext(ctx)
-			breaker := opt.group.Get(info.Operation()).(circuitbreaker.CircuitBreaker)
+			var operation string
+			if info, ok := transport.FromClientContext(ctx); ok {
+				operation = info.Operation()
+			}
+			breaker := get(operation)
 			if err := breaker.Allow(); err != nil {
+				setState(operation, breaker, StateOpen)
 				// rejected
 				// NOTE: when client reject requets locally,
 				// continue add counter let the drop ratio higher.
 				breaker.MarkFailed()
+				if opt.fallback != nil {
+					if reply, handled := opt.fallback(ctx, operation, ErrNotAllowed); handled {
+						return reply, nil
+					}
+				}
 				return nil, ErrNotAllowed
 			}
 			// allowed
@@ -57,6 +152,7 @@ func Client(opts ...Option) middleware.Middleware {
 				breaker.MarkFailed()
 			} else {
 				breaker.MarkSuccess()
+				setState(operation, breaker, StateClosed)
 			}
 			return reply, err
 		}
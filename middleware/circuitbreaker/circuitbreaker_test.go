@@ -0,0 +1,118 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *testTransport) Operation() string {
+	return tr.operation
+}
+
+type switchBreaker struct {
+	open bool
+}
+
+func (b *switchBreaker) Allow() error {
+	if b.open {
+		return circuitbreaker.ErrNotAllowed
+	}
+	return nil
+}
+func (b *switchBreaker) MarkSuccess() {}
+func (b *switchBreaker) MarkFailed()  {}
+
+func TestFallbackAndState(t *testing.T) {
+	var (
+		b      = &switchBreaker{}
+		states []State
+	)
+	next := Client(
+		WithBreaker(func(operation string) circuitbreaker.CircuitBreaker {
+			if operation != "/v1/users" {
+				t.Fatalf("unexpected operation: %s", operation)
+			}
+			return b
+		}),
+		WithFallback(func(ctx context.Context, operation string, err error) (interface{}, bool) {
+			return "cached", errors.Is(err, ErrNotAllowed)
+		}),
+		WithStateChange(func(operation string, state State) {
+			states = append(states, state)
+		}),
+	)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	ctx := transport.NewClientContext(context.Background(), &testTransport{operation: "/v1/users"})
+
+	for _, tt := range []struct {
+		open  bool
+		reply string
+	}{{false, "reply"}, {true, "cached"}, {true, "cached"}, {false, "reply"}} {
+		b.open = tt.open
+		reply, err := next(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply != tt.reply {
+			t.Fatalf("want %s, got %v", tt.reply, reply)
+		}
+	}
+	if len(states) != 2 || states[0] != StateOpen || states[1] != StateClosed {
+		t.Fatalf("unexpected state changes: %v", states)
+	}
+}
+
+func TestStateChangeOnce(t *testing.T) {
+	var (
+		b      = &switchBreaker{}
+		states []State
+		fail   error
+	)
+	next := Client(
+		WithBreaker(func(string) circuitbreaker.CircuitBreaker { return b }),
+		WithStateChange(func(operation string, state State) {
+			states = append(states, state)
+		}),
+	)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", fail
+	})
+	for _, tt := range []struct {
+		open bool
+		err  error
+	}{
+		{false, nil}, {false, nil},
+		{true, nil}, {true, nil},
+		// the failed probe does not close the breaker
+		{false, kerrors.ServiceUnavailable("", "")},
+		{true, nil},
+		{false, nil}, {false, nil},
+	} {
+		b.open, fail = tt.open, tt.err
+		_, _ = next(context.Background(), nil)
+	}
+	if len(states) != 2 || states[0] != StateOpen || states[1] != StateClosed {
+		t.Fatalf("unexpected state changes: %v", states)
+	}
+}
+
+func TestNoFallback(t *testing.T) {
+	next := Client(WithBreaker(func(string) circuitbreaker.CircuitBreaker {
+		return &switchBreaker{open: true}
+	}))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	if _, err := next(context.Background(), nil); !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("want ErrNotAllowed, got %v", err)
+	}
+}
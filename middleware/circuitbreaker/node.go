@@ -0,0 +1,124 @@
+package circuitbreaker
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/circuitbreaker/sre"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+// probeTimeout is the max duration of the probe of the nodes all open, the
+// next probe is allowed after it if the result of the probe is not marked.
+const probeTimeout = time.Second
+
+// NodeBreakers is the registry of the circuit breakers by node address. It
+// couples the NodeClient middleware and the selector: the middleware marks
+// the results of the requests on the breaker of the node picked by the
+// selector, and the Filter of the selector skips the nodes of the breakers
+// rejecting requests. So the filter must be set on the selector of the client
+// whose middleware is NodeClient of the same NodeBreakers.
+type NodeBreakers struct {
+	mu         sync.RWMutex
+	breakers   map[string]circuitbreaker.CircuitBreaker
+	newBreaker func(addr string) circuitbreaker.CircuitBreaker
+	// probe is the node probed when all the breakers are open.
+	probe   string
+	probeAt time.Time
+}
+
+// NewNodeBreakers new a registry of the breakers created by newBreaker, nil
+// creates the sre breakers.
+func NewNodeBreakers(newBreaker func(addr string) circuitbreaker.CircuitBreaker) *NodeBreakers {
+	if newBreaker == nil {
+		newBreaker = func(string) circuitbreaker.CircuitBreaker { return sre.NewBreaker() }
+	}
+	return &NodeBreakers{
+		breakers:   make(map[string]circuitbreaker.CircuitBreaker),
+		newBreaker: newBreaker,
+	}
+}
+
+// Get returns the breaker of the node address.
+func (b *NodeBreakers) Get(addr string) circuitbreaker.CircuitBreaker {
+	b.mu.RLock()
+	cb, ok := b.breakers[addr]
+	b.mu.RUnlock()
+	if ok {
+		return cb
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cb, ok = b.breakers[addr]; ok {
+		return cb
+	}
+	cb = b.newBreaker(addr)
+	b.breakers[addr] = cb
+	return cb
+}
+
+// Filter returns the selector filter removing the nodes of the breakers
+// rejecting requests. If all of them are, a random node is kept as the probe,
+// only one probe is allowed at a time.
+func (b *NodeBreakers) Filter() selector.Filter {
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		newNodes := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			if b.Get(n.Address()).Allow() == nil {
+				newNodes = append(newNodes, n)
+			}
+		}
+		if len(newNodes) > 0 || len(nodes) == 0 {
+			return newNodes
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.probe != "" && time.Since(b.probeAt) < probeTimeout {
+			return newNodes
+		}
+		n := nodes[rand.Intn(len(nodes))]
+		b.probe, b.probeAt = n.Address(), time.Now()
+		return []selector.Node{n}
+	}
+}
+
+func (b *NodeBreakers) mark(addr string, err error) {
+	cb := b.Get(addr)
+	if err != nil && (errors.IsInternalServer(err) || errors.IsServiceUnavailable(err) || errors.IsGatewayTimeout(err)) {
+		cb.MarkFailed()
+	} else {
+		cb.MarkSuccess()
+	}
+	b.mu.Lock()
+	if b.probe == addr {
+		b.probe = ""
+	}
+	b.mu.Unlock()
+}
+
+// NodeClient is the circuit breaker middleware of the nodes, it marks the
+// results of the requests on the breakers of the nodes picked by the selector
+// filtered by b.Filter(). Unlike Client, it does not reject the requests, the
+// nodes of the open breakers are not picked instead.
+func NodeClient(b *NodeBreakers) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			// the peer of the hedged attempt is reused
+			p, ok := selector.FromPeerContext(ctx)
+			if !ok {
+				p = new(selector.Peer)
+				ctx = selector.NewPeerContext(ctx, p)
+			}
+			reply, err := handler(ctx, req)
+			if n := p.Node(); n != nil {
+				b.mark(n.Address(), err)
+			}
+			return reply, err
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package circuitbreaker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/selector/random"
+)
+
+type countBreaker struct {
+	switchBreaker
+	success int
+	failed  int
+}
+
+func (b *countBreaker) MarkSuccess() { b.success++ }
+func (b *countBreaker) MarkFailed()  { b.failed++ }
+
+func newNodeBreakers() (*NodeBreakers, map[string]*countBreaker) {
+	breakers := map[string]*countBreaker{
+		"127.0.0.1:1": {},
+		"127.0.0.1:2": {},
+	}
+	return NewNodeBreakers(func(addr string) circuitbreaker.CircuitBreaker {
+		return breakers[addr]
+	}), breakers
+}
+
+func newSelector(b *NodeBreakers) selector.Selector {
+	s := random.New(random.WithFilter(b.Filter()))
+	s.Apply([]selector.Node{
+		selector.NewNode("http", "127.0.0.1:1", nil),
+		selector.NewNode("http", "127.0.0.1:2", nil),
+	})
+	return s
+}
+
+func TestNodeFilter(t *testing.T) {
+	b, breakers := newNodeBreakers()
+	s := newSelector(b)
+	breakers["127.0.0.1:1"].open = true
+	for i := 0; i < 10; i++ {
+		n, _, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.Address() != "127.0.0.1:2" {
+			t.Fatalf("want the node of the closed breaker, got %s", n.Address())
+		}
+	}
+
+	// one probe is allowed when all the breakers are open
+	breakers["127.0.0.1:2"].open = true
+	n, _, err := s.Select(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = s.Select(context.Background()); !errors.Is(err, selector.ErrNoAvailable) {
+		t.Fatalf("want ErrNoAvailable during the probe, got %v", err)
+	}
+	b.mark(n.Address(), nil)
+	if _, _, err = s.Select(context.Background()); err != nil {
+		t.Fatalf("want the next probe once the probe is marked, got %v", err)
+	}
+}
+
+func TestNodeClient(t *testing.T) {
+	b, breakers := newNodeBreakers()
+	s := newSelector(b)
+	breakers["127.0.0.1:1"].open = true
+	next := NodeClient(b)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if _, _, err := s.Select(ctx); err != nil {
+			return nil, err
+		}
+		return nil, req.(error)
+	})
+	_, _ = next(context.Background(), errors.ServiceUnavailable("UNAVAILABLE", "unavailable"))
+	_, _ = next(context.Background(), errors.BadRequest("BAD", "bad"))
+	if cb := breakers["127.0.0.1:2"]; cb.failed != 1 || cb.success != 1 {
+		t.Fatalf("want 1 failure and 1 success marked, got %d and %d", cb.failed, cb.success)
+	}
+	if cb := breakers["127.0.0.1:1"]; cb.failed != 0 || cb.success != 0 {
+		t.Fatalf("want nothing marked on the open node, got %d and %d", cb.failed, cb.success)
+	}
+}
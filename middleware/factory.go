@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Config is the config of a middleware of the chain built by Build, e.g. an
+// element of a list scanned by the config package.
+type Config struct {
+	// Name is the name the factory of the middleware is registered with.
+	Name string `json:"name"`
+	// Disabled skips the middleware, so it can be toggled per environment.
+	Disabled bool `json:"disabled"`
+	// Options is the options of the middleware decoded by the factory.
+	Options map[string]interface{} `json:"options"`
+}
+
+// Decode decodes the options into v by the json tags of v.
+func (c *Config) Decode(v interface{}) error {
+	if len(c.Options) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(c.Options)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Factory creates a middleware from its config.
+type Factory func(c *Config) (Middleware, error)
+
+var registeredFactories = make(map[string]Factory)
+
+// Register registers the factory of the middleware of name, the core
+// middlewares register their server-side ones in init, so the package of a
+// middleware must be imported to build it.
+func Register(name string, factory Factory) {
+	if factory == nil {
+		panic("cannot register a nil Factory")
+	}
+	if name == "" {
+		panic("cannot register Factory with empty name")
+	}
+	registeredFactories[name] = factory
+}
+
+// Build builds the middleware chain in the order of the configs by the
+// registered factories, it fails if a name is not registered.
+func Build(configs ...*Config) ([]Middleware, error) {
+	ms := make([]Middleware, 0, len(configs))
+	for _, c := range configs {
+		factory, ok := registeredFactories[c.Name]
+		if !ok {
+			return nil, fmt.Errorf("middleware: unknown middleware %q", c.Name)
+		}
+		if c.Disabled {
+			continue
+		}
+		m, err := factory(c)
+		if err != nil {
+			return nil, fmt.Errorf("middleware: build %s: %w", c.Name, err)
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
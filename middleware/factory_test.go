@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	var calls []string
+	Register("test", func(c *Config) (Middleware, error) {
+		var o struct {
+			Tag string `json:"tag"`
+		}
+		if err := c.Decode(&o); err != nil {
+			return nil, err
+		}
+		if o.Tag == "" {
+			return nil, errors.New("no tag")
+		}
+		return func(handler Handler) Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls = append(calls, o.Tag)
+				return handler(ctx, req)
+			}
+		}, nil
+	})
+	tests := []struct {
+		name    string
+		configs []*Config
+		calls   []string
+		err     bool
+	}{
+		{
+			name: "ordering",
+			configs: []*Config{
+				{Name: "test", Options: map[string]interface{}{"tag": "a"}},
+				{Name: "test", Options: map[string]interface{}{"tag": "b"}},
+			},
+			calls: []string{"a", "b"},
+		},
+		{
+			name: "disabled",
+			configs: []*Config{
+				{Name: "test", Options: map[string]interface{}{"tag": "a"}, Disabled: true},
+				{Name: "test", Options: map[string]interface{}{"tag": "b"}},
+			},
+			calls: []string{"b"},
+		},
+		{
+			name:    "unknown",
+			configs: []*Config{{Name: "unknown"}},
+			err:     true,
+		},
+		{
+			name:    "invalid options",
+			configs: []*Config{{Name: "test"}},
+			err:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls = nil
+			ms, err := Build(test.configs...)
+			if (err != nil) != test.err {
+				t.Fatalf("want error %v, got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			_, _ = Chain(ms...)(func(context.Context, interface{}) (interface{}, error) {
+				return nil, nil
+			})(context.Background(), nil)
+			if !reflect.DeepEqual(calls, test.calls) {
+				t.Fatalf("want %v, got %v", test.calls, calls)
+			}
+		})
+	}
+}
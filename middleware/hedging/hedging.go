@@ -0,0 +1,175 @@
+// Package hedging provides a client middleware hedging the slow requests.
+//
+// If an attempt has not returned within the delay, e.g. the p95 latency of
+// the operation, another attempt is sent to a node not picked by the previous
+// ones, and the first reply returned is used while the other attempts are
+// canceled. An attempt failed with a retryable error starts the next one at
+// once, and the other errors are returned. The nodes are picked distinct by
+// the selector of the client, so the hedged attempts are limited by the number
+// of the nodes. Every attempt has its own copy of the client transport, so the
+// request headers set by the inner middlewares are not shared.
+//
+// Only the idempotent operations are hedged: the HTTP requests of the
+// idempotent methods, the requests with the idempotency key and the
+// operations allowed by WithOperations.
+package hedging
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/internal/attempt"
+	"github.com/go-kratos/kratos/v2/internal/hedge"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+// Option is hedging option.
+type Option func(*options)
+
+type options struct {
+	delay       time.Duration
+	maxAttempts int
+	retryable   func(err error) bool
+	operations  attempt.Operations
+}
+
+// WithHedging with the delay before the next attempt and the max number of
+// the attempts including the first one, default is no hedging.
+func WithHedging(delay time.Duration, maxAttempts int) Option {
+	return func(o *options) {
+		o.delay = delay
+		o.maxAttempts = maxAttempts
+	}
+}
+
+// WithRetryable with the func reporting whether err starts the next attempt,
+// default is the Unavailable and DeadlineExceeded errors.
+func WithRetryable(f func(err error) bool) Option {
+	return func(o *options) {
+		o.retryable = f
+	}
+}
+
+// WithOperations with the operations hedged even if they are not idempotent.
+func WithOperations(operations ...string) Option {
+	return func(o *options) {
+		o.operations.Add(operations...)
+	}
+}
+
+type result struct {
+	reply interface{}
+	err   error
+	// exhausted reports whether the nodes are all picked by the previous
+	// attempts, the error of the attempt is then the one of the selector.
+	exhausted bool
+}
+
+// Client is a client middleware hedging the idempotent requests.
+func Client(opts ...Option) middleware.Middleware {
+	o := &options{
+		retryable:  attempt.Retryable,
+		operations: make(attempt.Operations),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if o.maxAttempts <= 1 || !attempt.Idempotent(ctx, o.operations) {
+				return handler(ctx, req)
+			}
+			// the attempts not returned are canceled once it returns
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			var (
+				mu        sync.Mutex
+				peers     []*selector.Peer
+				exhausted = make(map[*selector.Peer]bool)
+			)
+			ctx = selector.NewFilterContext(hedge.NewContext(ctx), func(ctx context.Context, nodes []selector.Node) []selector.Node {
+				mu.Lock()
+				picked := make(map[string]struct{}, len(peers))
+				for _, p := range peers {
+					if n := p.Node(); n != nil {
+						picked[n.Address()] = struct{}{}
+					}
+				}
+				mu.Unlock()
+				newNodes := make([]selector.Node, 0, len(nodes))
+				for _, n := range nodes {
+					if _, ok := picked[n.Address()]; !ok {
+						newNodes = append(newNodes, n)
+					}
+				}
+				if len(nodes) > 0 && len(newNodes) == 0 {
+					if p, ok := selector.FromPeerContext(ctx); ok {
+						mu.Lock()
+						exhausted[p] = true
+						mu.Unlock()
+					}
+				}
+				return newNodes
+			})
+			results := make(chan result, o.maxAttempts)
+			var (
+				attempts, inflight int
+				// done reports whether no more attempts are started
+				done     bool
+				firstErr error
+			)
+			next := func() {
+				p := new(selector.Peer)
+				mu.Lock()
+				peers = append(peers, p)
+				mu.Unlock()
+				attempts++
+				inflight++
+				go func() {
+					reply, err := handler(hedge.NewAttemptContext(selector.NewPeerContext(ctx, p)), req)
+					mu.Lock()
+					r := result{reply: reply, err: err, exhausted: exhausted[p]}
+					mu.Unlock()
+					results <- r
+				}()
+			}
+			next()
+			timer := time.NewTimer(o.delay)
+			defer timer.Stop()
+			for {
+				select {
+				case r := <-results:
+					inflight--
+					if r.err == nil {
+						return r.reply, nil
+					}
+					if r.exhausted {
+						done = true
+					} else {
+						if !o.retryable(r.err) {
+							return nil, r.err
+						}
+						if firstErr == nil {
+							firstErr = r.err
+						}
+					}
+					if !done && attempts < o.maxAttempts && ctx.Err() == nil {
+						next()
+					} else if inflight == 0 {
+						if firstErr != nil {
+							return nil, firstErr
+						}
+						return nil, r.err
+					}
+				case <-timer.C:
+					if !done && attempts < o.maxAttempts {
+						next()
+						timer.Reset(o.delay)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package hedging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/metadata"
+	mmd "github.com/go-kratos/kratos/v2/middleware/metadata"
+	"github.com/go-kratos/kratos/v2/middleware/requestid"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/selector/random"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string        { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string               { return nil }
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+	header    headerCarrier
+}
+
+func (tr *testTransport) Operation() string               { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+
+func newContext(ctx context.Context) context.Context {
+	return transport.NewClientContext(ctx, &testTransport{operation: "/test/get", header: headerCarrier{}})
+}
+
+func newSelector(addrs ...string) selector.Selector {
+	s := random.New()
+	nodes := make([]selector.Node, 0, len(addrs))
+	for _, addr := range addrs {
+		nodes = append(nodes, selector.NewNode("http", addr, nil))
+	}
+	s.Apply(nodes)
+	return s
+}
+
+func TestClient(t *testing.T) {
+	s := newSelector("127.0.0.1:1", "127.0.0.1:2")
+	var (
+		mu       sync.Mutex
+		picked   []string
+		canceled = make(chan struct{})
+	)
+	next := Client(WithHedging(10*time.Millisecond, 2), WithOperations("/test/get"))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		node, _, err := s.Select(ctx)
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		picked = append(picked, node.Address())
+		first := len(picked) == 1
+		mu.Unlock()
+		if first {
+			// the slow attempt is canceled once the hedged one returns
+			<-ctx.Done()
+			close(canceled)
+			return nil, ctx.Err()
+		}
+		return node.Address(), nil
+	})
+	reply, err := next(newContext(context.Background()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("the slow attempt is not canceled")
+	}
+	if len(picked) != 2 || picked[0] == picked[1] || reply != picked[1] {
+		t.Fatalf("want the reply of the hedged attempt of another node, got %v: %v", reply, picked)
+	}
+}
+
+func TestClientDistinctNodes(t *testing.T) {
+	s := newSelector("127.0.0.1:1", "127.0.0.1:2")
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := Client(WithHedging(10*time.Millisecond, 3), WithOperations("/test/get"))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		if _, _, err := s.Select(ctx); err != nil {
+			return nil, err
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})(newContext(ctx), nil)
+	if err == nil {
+		t.Fatal("want an error")
+	}
+	if calls != 3 {
+		t.Fatalf("want 3 attempts, got %d", calls)
+	}
+	if _, _, err := s.Select(context.Background()); err != nil {
+		t.Fatal("the filter of the attempts leaks to the selector", err)
+	}
+}
+
+func TestClientNotIdempotent(t *testing.T) {
+	calls := 0
+	_, err := Client(WithHedging(time.Millisecond, 3))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		time.Sleep(10 * time.Millisecond)
+		return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+	})(newContext(context.Background()), nil)
+	if !errors.IsServiceUnavailable(err) || calls != 1 {
+		t.Fatalf("want 1 call, got %d: %v", calls, err)
+	}
+}
+
+func TestClientNotRetryable(t *testing.T) {
+	s := newSelector("127.0.0.1:1", "127.0.0.1:2")
+	var calls int32
+	_, err := Client(WithHedging(time.Second, 2), WithOperations("/test/get"))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if _, _, err := s.Select(ctx); err != nil {
+			return nil, err
+		}
+		return nil, errors.BadRequest("BAD", "bad")
+	})(newContext(context.Background()), nil)
+	if !errors.IsBadRequest(err) || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("want the error of the only attempt, got %d calls: %v", calls, err)
+	}
+}
+
+func TestClientFirstError(t *testing.T) {
+	s := newSelector("127.0.0.1:1", "127.0.0.1:2")
+	var calls int32
+	_, err := Client(WithHedging(time.Second, 3), WithOperations("/test/get"))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		node, _, err := s.Select(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.ServiceUnavailable("UNAVAILABLE", node.Address())
+	})(newContext(context.Background()), nil)
+	if errors.Reason(err) != "UNAVAILABLE" || atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("want the first error of the nodes, got %d calls: %v", calls, err)
+	}
+}
+
+func TestClientHTTP(t *testing.T) {
+	var (
+		calls int32
+		mu    sync.Mutex
+		ids   = make(map[string]struct{})
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-md-global-k") != "v" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		ids[r.Header.Get(requestid.Header)] = struct{}{}
+		mu.Unlock()
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// the slow first attempt is hedged
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"kratos"}`))
+	}))
+	defer srv.Close()
+	client, err := khttp.NewClient(context.Background(),
+		khttp.WithEndpoint(srv.Listener.Addr().String()),
+		khttp.WithMiddleware(
+			Client(WithHedging(10*time.Millisecond, 3)),
+			mmd.Client(),
+			requestid.Client(),
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := metadata.AppendToClientContext(context.Background(), "x-md-global-k", "v")
+	var out struct{ Name string }
+	if err = client.Invoke(ctx, http.MethodGet, "/test", nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "kratos" || atomic.LoadInt32(&calls) < 2 {
+		t.Fatalf("want the reply of a hedged attempt, got %d calls: %v", calls, out)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := ids[""]; ok {
+		t.Fatalf("want the request id of every attempt, got %v", ids)
+	}
+}
@@ -0,0 +1,316 @@
+// Package idempotency provides a middleware replaying the results of the
+// requests retried with the same idempotency key.
+//
+// The key is read from the Idempotency-Key header of HTTP or the
+// idempotency-key metadata of gRPC, and the result of the first request of
+// an operation and a key is stored for the TTL and returned to the duplicate
+// ones without executing the handler again. The requests with the same key
+// are serialized, so only one of them is executed at a time. The keys are
+// scoped by the caller returned by WithKeyFunc, and a duplicate of another
+// request payload is rejected with 422.
+//
+// Only the proto replies and the deterministic errors of codes below 500 are
+// stored by default, the server errors and the transient ones of 408, 429 and
+// 499 are not, so that the retries after them are executed.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Header is the header of the idempotency key.
+const Header = "Idempotency-Key"
+
+// ErrKeyMismatch is returned if the idempotency key is reused by a request of
+// another payload.
+var ErrKeyMismatch = errors.UnprocessableEntity("IDEMPOTENCY_KEY_MISMATCH", "idempotency key is reused with another request")
+
+// defaultTTL is the default duration the results are stored for.
+const defaultTTL = 24 * time.Hour
+
+func init() {
+	// options: ttl is the duration string the results are stored for, e.g. "1h".
+	middleware.Register("idempotency", func(c *middleware.Config) (middleware.Middleware, error) {
+		var o struct {
+			TTL string `json:"ttl"`
+		}
+		if err := c.Decode(&o); err != nil {
+			return nil, err
+		}
+		var opts []Option
+		if o.TTL != "" {
+			ttl, err := time.ParseDuration(o.TTL)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, WithTTL(ttl))
+		}
+		return Server(opts...), nil
+	})
+}
+
+// Store stores the results of the requests by key, e.g. in Redis.
+type Store interface {
+	// Get returns the value of key, or false if it is not found or expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set sets the value of key expiring after ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// Locker is implemented by the stores serializing the requests with the same
+// key across the processes, e.g. by a Redis lock. The requests are serialized
+// in the process if the store does not implement it.
+type Locker interface {
+	// Lock blocks until the lock of key is acquired or ctx is done, the lock
+	// expires after ttl unless it is released by unlock.
+	Lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), err error)
+}
+
+// Option is idempotency option.
+type Option func(*options)
+
+type options struct {
+	store     Store
+	ttl       time.Duration
+	cacheable func(err error) bool
+	keyFunc   func(ctx context.Context, req interface{}) string
+}
+
+// WithStore with the store of the results, default is an in-memory store.
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithTTL with the duration the results are stored for, default is 24h.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithCacheable with the func reporting whether the result of err is stored,
+// default is the errors of codes below 500 except 408, 429 and 499.
+func WithCacheable(f func(err error) bool) Option {
+	return func(o *options) {
+		o.cacheable = f
+	}
+}
+
+// WithKeyFunc with the func returning the caller the keys are scoped by, e.g.
+// the subject of the token, so that the same keys of the different callers do
+// not share the results. Default is no scope.
+func WithKeyFunc(f func(ctx context.Context, req interface{}) string) Option {
+	return func(o *options) {
+		o.keyFunc = f
+	}
+}
+
+// defaultCacheable reports whether err is a deterministic client error, the
+// timeouts, the throttles and the canceled requests are transient.
+func defaultCacheable(err error) bool {
+	switch code := errors.Code(err); code {
+	case 408, 429, 499:
+		return false
+	default:
+		return code < 500
+	}
+}
+
+// result is the stored result of a request.
+type result struct {
+	// Type is the full name of the reply message.
+	Type string `json:"type,omitempty"`
+	// Reply is the marshaled reply.
+	Reply []byte `json:"reply,omitempty"`
+	// Status is the marshaled errors.Status of the error.
+	Status []byte `json:"status,omitempty"`
+	// Fingerprint is the hash of the request.
+	Fingerprint []byte `json:"fingerprint,omitempty"`
+}
+
+// Server is a server middleware replaying the results of the duplicate requests.
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{
+		ttl:       defaultTTL,
+		cacheable: defaultCacheable,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.store == nil {
+		o.store = NewMemoryStore()
+	}
+	locker, ok := o.store.(Locker)
+	if !ok {
+		locker = newLocalLocker()
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			key := tr.RequestHeader().Get(Header)
+			if key == "" {
+				return handler(ctx, req)
+			}
+			if o.keyFunc != nil {
+				key = o.keyFunc(ctx, req) + ":" + key
+			}
+			key = tr.Operation() + ":" + key
+			unlock, err := locker.Lock(ctx, key, o.ttl)
+			if err != nil {
+				return nil, err
+			}
+			defer unlock()
+			data, ok, err := o.store.Get(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			fingerprint := fingerprint(req)
+			if ok {
+				return decode(data, fingerprint)
+			}
+			reply, err := handler(ctx, req)
+			if data, ok := o.encode(reply, err, fingerprint); ok {
+				// the reply is returned even if it fails to be stored
+				_ = o.store.Set(ctx, key, data, o.ttl)
+			}
+			return reply, err
+		}
+	}
+}
+
+// fingerprint returns the hash of req, or nil if it fails to be marshaled.
+func fingerprint(req interface{}) []byte {
+	var (
+		data []byte
+		err  error
+	)
+	if m, ok := req.(proto.Message); ok {
+		data, err = proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	} else {
+		data, err = json.Marshal(req)
+	}
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+func (o *options) encode(reply interface{}, err error, fingerprint []byte) ([]byte, bool) {
+	r := result{Fingerprint: fingerprint}
+	if err != nil {
+		if !o.cacheable(err) {
+			return nil, false
+		}
+		se := errors.FromError(err)
+		status, err := proto.Marshal(&se.Status)
+		if err != nil {
+			return nil, false
+		}
+		r.Status = status
+	} else {
+		m, ok := reply.(proto.Message)
+		if !ok {
+			return nil, false
+		}
+		data, err := proto.Marshal(m)
+		if err != nil {
+			return nil, false
+		}
+		r.Type, r.Reply = string(m.ProtoReflect().Descriptor().FullName()), data
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func decode(data []byte, fingerprint []byte) (interface{}, error) {
+	var r result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	// the results stored without fingerprints are replayed as is
+	if r.Fingerprint != nil && fingerprint != nil && !bytes.Equal(r.Fingerprint, fingerprint) {
+		return nil, ErrKeyMismatch
+	}
+	if r.Status != nil {
+		se := new(errors.Error)
+		if err := proto.Unmarshal(r.Status, &se.Status); err != nil {
+			return nil, err
+		}
+		return nil, se
+	}
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(r.Type))
+	if err != nil {
+		return nil, err
+	}
+	reply := mt.New().Interface()
+	if err := proto.Unmarshal(r.Reply, reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// localLocker serializes the requests with the same key in the process.
+type localLocker struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	// ch is the semaphore of the lock.
+	ch   chan struct{}
+	refs int
+}
+
+func newLocalLocker() *localLocker {
+	return &localLocker{locks: make(map[string]*keyLock)}
+}
+
+func (l *localLocker) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	l.mu.Lock()
+	lk, ok := l.locks[key]
+	if !ok {
+		lk = &keyLock{ch: make(chan struct{}, 1)}
+		l.locks[key] = lk
+	}
+	lk.refs++
+	l.mu.Unlock()
+	select {
+	case lk.ch <- struct{}{}:
+		return func() {
+			<-lk.ch
+			l.release(key, lk)
+		}, nil
+	case <-ctx.Done():
+		l.release(key, lk)
+		return nil, ctx.Err()
+	}
+}
+
+func (l *localLocker) release(key string, lk *keyLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lk.refs--; lk.refs == 0 {
+		delete(l.locks, key)
+	}
+}
@@ -0,0 +1,190 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string        { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string               { return nil }
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+	header    headerCarrier
+}
+
+func (tr *testTransport) Operation() string               { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+
+func newContext(operation, key string) context.Context {
+	tr := &testTransport{operation: operation, header: headerCarrier{}}
+	if key != "" {
+		tr.header.Set(Header, key)
+	}
+	return transport.NewServerContext(context.Background(), tr)
+}
+
+func TestServer(t *testing.T) {
+	var calls int32
+	next := Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		n := atomic.AddInt32(&calls, 1)
+		switch req.(string) {
+		case "bad":
+			return nil, errors.BadRequest("BAD", "bad request")
+		case "unavailable":
+			return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+		case "throttled":
+			return nil, errors.New(429, "RATELIMIT", "throttled")
+		case "canceled":
+			return nil, errors.ClientClosed("CANCELED", "canceled")
+		}
+		return wrapperspb.Int32(n), nil
+	})
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		req   string
+		reply int32
+		code  int
+		calls int32
+	}{
+		{"first", newContext("/test/create", "1"), "ok", 1, 200, 1},
+		{"duplicate", newContext("/test/create", "1"), "ok", 1, 200, 1},
+		{"another key", newContext("/test/create", "2"), "ok", 2, 200, 2},
+		{"another operation", newContext("/test/update", "1"), "ok", 3, 200, 3},
+		{"no key", newContext("/test/create", ""), "ok", 4, 200, 4},
+		{"no key again", newContext("/test/create", ""), "ok", 5, 200, 5},
+		{"client error", newContext("/test/create", "3"), "bad", 0, 400, 6},
+		{"client error duplicate", newContext("/test/create", "3"), "bad", 0, 400, 6},
+		{"server error", newContext("/test/create", "4"), "unavailable", 0, 503, 7},
+		{"server error retried", newContext("/test/create", "4"), "unavailable", 0, 503, 8},
+		{"throttled", newContext("/test/create", "5"), "throttled", 0, 429, 9},
+		{"throttled retried", newContext("/test/create", "5"), "throttled", 0, 429, 10},
+		{"canceled", newContext("/test/create", "6"), "canceled", 0, 499, 11},
+		{"canceled retried", newContext("/test/create", "6"), "canceled", 0, 499, 12},
+	}
+	for _, test := range tests {
+		reply, err := next(test.ctx, test.req)
+		if code := errors.Code(err); code != test.code {
+			t.Fatalf("%s: want code %d, got %v", test.name, test.code, err)
+		}
+		if err == nil && !proto.Equal(reply.(proto.Message), wrapperspb.Int32(test.reply)) {
+			t.Fatalf("%s: want reply %d, got %v", test.name, test.reply, reply)
+		}
+		if n := atomic.LoadInt32(&calls); n != test.calls {
+			t.Fatalf("%s: want %d calls, got %d", test.name, test.calls, n)
+		}
+	}
+}
+
+func TestServerConcurrent(t *testing.T) {
+	var calls int32
+	next := Server(WithTTL(time.Minute))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(10 * time.Millisecond)
+		return wrapperspb.Int32(atomic.AddInt32(&calls, 1)), nil
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reply, err := next(newContext("/test/create", "1"), "ok")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if v := reply.(*wrapperspb.Int32Value).Value; v != 1 {
+				t.Errorf("want the reply of the first request, got %d", v)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("want 1 call, got %d", calls)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	if err := s.Set(ctx, "key", []byte("value"), 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := s.Get(ctx, "key"); !ok || string(v) != "value" {
+		t.Fatalf("want value, got %q %v", v, ok)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := s.Get(ctx, "key"); ok {
+		t.Fatal("want the key expired")
+	}
+}
+
+func TestServerCacheable(t *testing.T) {
+	var calls int32
+	next := Server(WithCacheable(func(err error) bool { return errors.IsServiceUnavailable(err) }))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if req == "bad" {
+			return nil, errors.BadRequest("BAD", "bad request")
+		}
+		return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+	})
+	for _, req := range []string{"bad", "bad", "unavailable", "unavailable"} {
+		_, _ = next(newContext("/test/create", req), req)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Fatalf("want the cacheable errors stored only, got %d calls", n)
+	}
+}
+
+type callerKey struct{}
+
+func TestServerKeyFunc(t *testing.T) {
+	var calls int32
+	next := Server(WithKeyFunc(func(ctx context.Context, req interface{}) string {
+		return ctx.Value(callerKey{}).(string)
+	}))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return wrapperspb.Int32(atomic.AddInt32(&calls, 1)), nil
+	})
+	for _, caller := range []string{"alice", "bob", "alice"} {
+		ctx := context.WithValue(newContext("/test/create", "1"), callerKey{}, caller)
+		if _, err := next(ctx, "ok"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("want the keys scoped by the callers, got %d calls", n)
+	}
+}
+
+func TestServerMismatch(t *testing.T) {
+	var calls int32
+	next := Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return wrapperspb.Int32(atomic.AddInt32(&calls, 1)), nil
+	})
+	if _, err := next(newContext("/test/create", "1"), wrapperspb.String("a")); err != nil {
+		t.Fatal(err)
+	}
+	_, err := next(newContext("/test/create", "1"), wrapperspb.String("b"))
+	if !errors.IsUnprocessableEntity(err) {
+		t.Fatalf("want 422, got %v", err)
+	}
+	if _, err := next(newContext("/test/create", "1"), wrapperspb.String("a")); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("want 1 call, got %d", n)
+	}
+}
@@ -0,0 +1,64 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the min interval of removing the expired entries.
+const sweepInterval = time.Minute
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore is an in-memory store.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	swept   time.Time
+}
+
+type entry struct {
+	value    []byte
+	expireAt time.Time
+}
+
+// NewMemoryStore new an in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]entry),
+		swept:   time.Now(),
+	}
+}
+
+// Get returns the value of key, or false if it is not found or expired.
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !time.Now().Before(e.expireAt) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Set sets the value of key expiring after ttl.
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.swept) >= sweepInterval {
+		for k, e := range s.entries {
+			if !now.Before(e.expireAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.swept = now
+	}
+	s.entries[key] = entry{value: value, expireAt: now.Add(ttl)}
+	return nil
+}
@@ -3,21 +3,85 @@ package logging
 import (
 	"context"
 	"fmt"
+	"io"
+	"runtime"
 	"time"
+	"unicode/utf8"
 
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/encoding/json"
 	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/stream"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/requestid"
 	"github.com/go-kratos/kratos/v2/transport"
+	"google.golang.org/grpc"
 )
 
+func init() {
+	// options: payload is the max bytes of the logged payloads, zero disables them.
+	middleware.Register("logging", func(c *middleware.Config) (middleware.Middleware, error) {
+		var o struct {
+			Payload int `json:"payload"`
+		}
+		if err := c.Decode(&o); err != nil {
+			return nil, err
+		}
+		var opts []Option
+		if o.Payload > 0 {
+			opts = append(opts, WithPayload(o.Payload))
+		}
+		return Server(log.GetLogger(), opts...), nil
+	})
+}
+
+// Option is logging option.
+type Option func(*options)
+
+// FieldsFunc returns extra keyvals logged for the request.
+type FieldsFunc func(ctx context.Context, req, reply interface{}, err error) []interface{}
+
+// Redactor returns the request logged in place of req, e.g. with sensitive fields masked.
+type Redactor func(ctx context.Context, req interface{}) interface{}
+
+// WithFields with extra keyvals logged for every request.
+func WithFields(f FieldsFunc) Option {
+	return func(o *options) {
+		o.fields = append(o.fields, f)
+	}
+}
+
+// WithRedactor with the request redacted before logging.
+func WithRedactor(r Redactor) Option {
+	return func(o *options) {
+		o.redactor = r
+	}
+}
+
+// WithPayload with the request and reply marshaled by the codec of the
+// operation and logged under the args and reply keys if the debug level is
+// enabled, payloads longer than maxBytes are truncated.
+// The json codec is used if the operation has no codec, e.g. plain gRPC.
+func WithPayload(maxBytes int) Option {
+	return func(o *options) {
+		o.payload = maxBytes
+	}
+}
+
+type options struct {
+	fields   []FieldsFunc
+	redactor Redactor
+	payload  int
+}
+
 // Server is an server logging middleware.
-func Server(logger log.Logger) middleware.Middleware {
+func Server(logger log.Logger, opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	helper := log.NewHelper(logger)
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
 			var (
-				code      int32
-				reason    string
 				kind      string
 				operation string
 			)
@@ -26,34 +90,26 @@ func Server(logger log.Logger) middleware.Middleware {
 				kind = info.Kind().String()
 				operation = info.Operation()
 			}
+			defer func() {
+				if rerr := recover(); rerr != nil {
+					o.log(ctx, logger, helper, "server", kind, operation, req, nil, panicError(rerr), startTime)
+					panic(rerr)
+				}
+			}()
 			reply, err = handler(ctx, req)
-			if se := errors.FromError(err); se != nil {
-				code = se.Code
-				reason = se.Reason
-			}
-			level, stack := extractError(err)
-			_ = log.WithContext(ctx, logger).Log(level,
-				"kind", "server",
-				"component", kind,
-				"operation", operation,
-				"args", extractArgs(req),
-				"code", code,
-				"reason", reason,
-				"stack", stack,
-				"latency", time.Since(startTime).Seconds(),
-			)
+			o.log(ctx, logger, helper, "server", kind, operation, req, reply, err, startTime)
 			return
 		}
 	}
 }
 
 // Client is an client logging middleware.
-func Client(logger log.Logger) middleware.Middleware {
+func Client(logger log.Logger, opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	helper := log.NewHelper(logger)
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
 			var (
-				code      int32
-				reason    string
 				kind      string
 				operation string
 			)
@@ -62,27 +118,221 @@ func Client(logger log.Logger) middleware.Middleware {
 				kind = info.Kind().String()
 				operation = info.Operation()
 			}
+			defer func() {
+				if rerr := recover(); rerr != nil {
+					o.log(ctx, logger, helper, "client", kind, operation, req, nil, panicError(rerr), startTime)
+					panic(rerr)
+				}
+			}()
 			reply, err = handler(ctx, req)
-			if se := errors.FromError(err); se != nil {
-				code = se.Code
-				reason = se.Reason
-			}
-			level, stack := extractError(err)
-			_ = log.WithContext(ctx, logger).Log(level,
-				"kind", "client",
-				"component", kind,
-				"operation", operation,
-				"args", extractArgs(req),
-				"code", code,
-				"reason", reason,
-				"stack", stack,
-				"latency", time.Since(startTime).Seconds(),
-			)
+			o.log(ctx, logger, helper, "client", kind, operation, req, reply, err, startTime)
 			return
 		}
 	}
 }
 
+// StreamServerInterceptor returns a new gRPC stream server interceptor
+// logging the open and the close of the streams with the numbers of the
+// messages, as the middleware is not applied to the streaming handlers. It
+// must be chained after the kratos one, e.g. by grpc.StreamInterceptor.
+func StreamServerInterceptor(logger log.Logger, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx := ss.Context()
+		startTime := time.Now()
+		o.logOpen(ctx, logger, "server", info.FullMethod)
+		s := stream.NewServerStream(ctx, ss)
+		defer func() {
+			if rerr := recover(); rerr != nil {
+				o.logClose(ctx, logger, "server", info.FullMethod, ss, s.Sent(), s.Received(), panicError(rerr), startTime)
+				panic(rerr)
+			}
+		}()
+		err = handler(srv, s)
+		o.logClose(ctx, logger, "server", info.FullMethod, ss, s.Sent(), s.Received(), err, startTime)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a new gRPC stream client interceptor
+// logging the open and the close of the streams with the numbers of the
+// messages, e.g. set by grpc.WithStreamInterceptor. The stream is closed when
+// the reply or an error is received, or when the context of the stream is done.
+func StreamClientInterceptor(logger log.Logger, opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		startTime := time.Now()
+		o.logOpen(ctx, logger, "client", method)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			o.logClose(ctx, logger, "client", method, nil, 0, 0, err, startTime)
+			return nil, err
+		}
+		return stream.NewClientStream(ctx, cs, desc, func(sent, received int64, err error) {
+			o.logClose(ctx, logger, "client", method, cs, sent, received, err, startTime)
+		}), nil
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *options) log(ctx context.Context, logger log.Logger, helper *log.Helper, side, kind, operation string, req, reply interface{}, err error, startTime time.Time) {
+	var (
+		code   int32
+		reason string
+	)
+	if se := errors.FromError(err); se != nil {
+		code = se.Code
+		reason = se.Reason
+	}
+	args := req
+	if o.redactor != nil {
+		args = o.redactor(ctx, req)
+	}
+	level, stack := extractError(err)
+	var (
+		codec   encoding.Codec
+		payload = o.payload > 0 && helper.Enabled(log.LevelDebug) && !isStream(req) && !isStream(reply)
+		logArgs interface{}
+	)
+	if payload {
+		codec = payloadCodec(ctx, side)
+		logArgs = extractPayload(codec, args, o.payload)
+	} else {
+		logArgs = extractArgs(args)
+	}
+	keyvals := []interface{}{
+		"kind", side,
+		"component", kind,
+		"operation", operation,
+		"args", logArgs,
+		"code", code,
+		"reason", reason,
+		"stack", stack,
+		"latency", time.Since(startTime).Seconds(),
+	}
+	if payload {
+		keyvals = append(keyvals, "reply", extractPayload(codec, reply, o.payload))
+	}
+	if id, ok := requestid.FromContext(ctx); ok {
+		keyvals = append(keyvals, "request_id", id)
+	}
+	for _, f := range o.fields {
+		keyvals = append(keyvals, f(ctx, req, reply, err)...)
+	}
+	_ = log.WithContext(ctx, logger).Log(level, keyvals...)
+}
+
+func (o *options) logOpen(ctx context.Context, logger log.Logger, side, operation string) {
+	_ = log.WithContext(ctx, logger).Log(log.LevelInfo,
+		"kind", side,
+		"component", transport.KindGRPC.String(),
+		"operation", operation,
+		"stream", "open",
+	)
+}
+
+// logClose logs the close of the stream ss, the fields are called with ss as the request
+func (o *options) logClose(ctx context.Context, logger log.Logger, side, operation string, ss interface{}, sent, received int64, err error, startTime time.Time) {
+	var (
+		code   int32
+		reason string
+	)
+	if se := errors.FromError(err); se != nil {
+		code = se.Code
+		reason = se.Reason
+	}
+	level, stack := extractError(err)
+	keyvals := []interface{}{
+		"kind", side,
+		"component", transport.KindGRPC.String(),
+		"operation", operation,
+		"stream", "close",
+		"sent", sent,
+		"received", received,
+		"code", code,
+		"reason", reason,
+		"stack", stack,
+		"latency", time.Since(startTime).Seconds(),
+	}
+	for _, f := range o.fields {
+		keyvals = append(keyvals, f(ctx, ss, nil, err)...)
+	}
+	_ = log.WithContext(ctx, logger).Log(level, keyvals...)
+}
+
+// payloadCodec returns the codec of the request content type
+func payloadCodec(ctx context.Context, side string) encoding.Codec {
+	var (
+		info transport.Transporter
+		ok   bool
+	)
+	if side == "server" {
+		info, ok = transport.FromServerContext(ctx)
+	} else {
+		info, ok = transport.FromClientContext(ctx)
+	}
+	if ok && info.RequestHeader() != nil {
+		// e.g. application/grpc+json
+		if codec, err := encoding.GetCodecByContentType(info.RequestHeader().Get("Content-Type")); err == nil {
+			return codec
+		}
+	}
+	return encoding.GetCodec(json.Name)
+}
+
+// extractPayload returns the payload marshaled by codec and truncated to maxBytes
+func extractPayload(codec encoding.Codec, v interface{}, maxBytes int) (payload string) {
+	if v == nil {
+		return ""
+	}
+	defer func() {
+		// non-serializable payload
+		if rerr := recover(); rerr != nil {
+			payload = truncate(extractArgs(v), maxBytes)
+		}
+	}()
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return truncate(extractArgs(v), maxBytes)
+	}
+	return truncate(string(data), maxBytes)
+}
+
+// truncate truncates s to maxBytes at most, without splitting a rune
+func truncate(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	n := maxBytes
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return fmt.Sprintf("%s...(%d bytes elided)", s[:n], len(s)-n)
+}
+
+// isStream reports whether v is a stream rather than a message
+func isStream(v interface{}) bool {
+	switch v.(type) {
+	case interface{ RecvMsg(interface{}) error }, interface{ SendMsg(interface{}) error }, io.Reader:
+		return true
+	}
+	return false
+}
+
+// panicError returns the error of a panic with the stack
+func panicError(rerr interface{}) error {
+	buf := make([]byte, 64<<10) //nolint:gomnd
+	n := runtime.Stack(buf, false)
+	return fmt.Errorf("panic: %v\n%s", rerr, buf[:n])
+}
+
 // extractArgs returns the string of the req
 func extractArgs(req interface{}) string {
 	if stringer, ok := req.(fmt.Stringer); ok {
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/requestid"
 	"github.com/go-kratos/kratos/v2/transport"
+	"google.golang.org/grpc"
 )
 
 var _ transport.Transporter = &Transport{}
@@ -46,7 +49,7 @@ func TestHTTP(t *testing.T) {
 
 	tests := []struct {
 		name string
-		kind func(logger log.Logger) middleware.Middleware
+		kind func(logger log.Logger, opts ...Option) middleware.Middleware
 		err  error
 		ctx  context.Context
 	}{
@@ -97,3 +100,147 @@ func TestHTTP(t *testing.T) {
 		})
 	}
 }
+
+func TestFieldsAndRedactor(t *testing.T) {
+	bf := bytes.NewBuffer(nil)
+	logger := log.NewStdLogger(bf)
+	ctx := transport.NewServerContext(context.Background(), &Transport{kind: transport.KindGRPC, operation: "/package.service/method"})
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	}
+	next = Server(logger,
+		WithFields(func(ctx context.Context, req, reply interface{}, err error) []interface{} {
+			return []interface{}{"size", len(reply.(string))}
+		}),
+		WithRedactor(func(ctx context.Context, req interface{}) interface{} {
+			return strings.ReplaceAll(req.(string), "secret", "***")
+		}),
+	)(next)
+	if _, err := next(ctx, "password=secret"); err != nil {
+		t.Fatal(err)
+	}
+	got := bf.String()
+	if !strings.Contains(got, "args=password=***") || !strings.Contains(got, "size=5") {
+		t.Fatalf("unexpected log: %s", got)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	bf := bytes.NewBuffer(nil)
+	ctx := transport.NewServerContext(context.Background(), &Transport{kind: transport.KindGRPC, operation: "/package.service/method"})
+	ctx = requestid.NewContext(ctx, "abc-123")
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	}
+	if _, err := Server(log.NewStdLogger(bf))(next)(ctx, "req"); err != nil {
+		t.Fatal(err)
+	}
+	if got := bf.String(); !strings.Contains(got, "request_id=abc-123") {
+		t.Fatalf("want the request id logged, got %s", got)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	bf := bytes.NewBuffer(nil)
+	logger := log.NewStdLogger(bf)
+	ctx := transport.NewServerContext(context.Background(), &Transport{kind: transport.KindHTTP, operation: "/package.service/method"})
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	}
+	next = Server(logger)(next)
+	defer func() {
+		if rerr := recover(); rerr != "boom" {
+			t.Fatalf("panic should be rethrown, got %v", rerr)
+		}
+		if got := bf.String(); !strings.HasPrefix(got, "ERROR") || !strings.Contains(got, "panic: boom") {
+			t.Fatalf("unexpected log: %s", got)
+		}
+	}()
+	_, _ = next(ctx, "req")
+}
+
+func TestPayload(t *testing.T) {
+	bf := bytes.NewBuffer(nil)
+	ctx := transport.NewServerContext(context.Background(), &Transport{kind: transport.KindHTTP, operation: "/package.service/method"})
+	type message struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name   string
+		logger log.Logger
+		req    interface{}
+		want   []string
+	}{
+		{"json", log.NewStdLogger(bf), &message{Name: "kratos"}, []string{`args={"name":"kratos"}`, `reply={"name":"reply"}`}},
+		{"truncated", log.NewStdLogger(bf), &message{Name: "kratos-kratos-kratos"}, []string{`args={"name":"kratos-krat...(11 bytes elided)`}},
+		{"unserializable", log.NewStdLogger(bf), make(chan int), []string{"args=0x"}},
+		{"disabled", log.NewFilter(log.NewStdLogger(bf), log.FilterLevel(log.LevelInfo)), &message{Name: "kratos"}, []string{"args=&{Name:kratos}"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bf.Reset()
+			next := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return &message{Name: "reply"}, nil
+			}
+			next = Server(test.logger, WithPayload(20))(next)
+			if _, err := next(ctx, test.req); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(bf.String(), want) {
+					t.Fatalf("log should contain %s, got %s", want, bf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s        string
+		maxBytes int
+		want     string
+	}{
+		{"kratos", 6, "kratos"},
+		{"kratos", 3, "kra...(3 bytes elided)"},
+		{"你好", 4, "你...(3 bytes elided)"},
+		{"你好", 2, "...(6 bytes elided)"},
+	}
+	for _, test := range tests {
+		if got := truncate(test.s, test.maxBytes); got != test.want {
+			t.Errorf("truncate(%q, %d): want %q, got %q", test.s, test.maxBytes, test.want, got)
+		}
+	}
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *mockServerStream) Context() context.Context    { return context.Background() }
+func (s *mockServerStream) SendMsg(m interface{}) error { return nil }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	bf := bytes.NewBuffer(nil)
+	err := StreamServerInterceptor(log.NewStdLogger(bf))(nil, &mockServerStream{}, &grpc.StreamServerInfo{FullMethod: "/package.service/method"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			_ = ss.SendMsg(nil)
+			_ = ss.SendMsg(nil)
+			return errors.New("stream.error")
+		})
+	if err == nil {
+		t.Fatal("want the error of the handler")
+	}
+	lines := strings.Split(strings.TrimSpace(bf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want the open and the close logged, got %q", bf.String())
+	}
+	if !strings.Contains(lines[0], "stream=open") {
+		t.Fatalf("want the open logged, got %q", lines[0])
+	}
+	for _, want := range []string{"ERROR", "stream=close", "sent=2", "received=0", "stream.error"} {
+		if !strings.Contains(lines[1], want) {
+			t.Fatalf("want %q in the close log, got %q", want, lines[1])
+		}
+	}
+}
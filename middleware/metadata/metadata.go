@@ -9,12 +9,44 @@ import (
 	"github.com/go-kratos/kratos/v2/transport"
 )
 
+func init() {
+	// options: prefix is the propagated key prefixes, constants is the constant metadata.
+	middleware.Register("metadata", func(c *middleware.Config) (middleware.Middleware, error) {
+		var o struct {
+			Prefix    []string          `json:"prefix"`
+			Constants map[string]string `json:"constants"`
+		}
+		if err := c.Decode(&o); err != nil {
+			return nil, err
+		}
+		var opts []Option
+		if len(o.Prefix) > 0 {
+			opts = append(opts, WithPropagatedPrefix(o.Prefix...))
+		}
+		if len(o.Constants) > 0 {
+			opts = append(opts, WithConstants(metadata.New(o.Constants)))
+		}
+		return Server(opts...), nil
+	})
+}
+
 // Option is metadata option.
 type Option func(*options)
 
 type options struct {
-	prefix []string
-	md     metadata.Metadata
+	prefix    []string
+	md        metadata.Metadata
+	transform func(key string) (string, bool)
+}
+
+// propagate returns the key the metadata is propagated as,
+// keys are lowercased as header casing differs between transports.
+func (o *options) propagate(key string) (string, bool) {
+	k := strings.ToLower(key)
+	if o.transform != nil {
+		return o.transform(k)
+	}
+	return k, o.hasPrefix(k)
 }
 
 func (o *options) hasPrefix(key string) bool {
@@ -41,6 +73,16 @@ func WithPropagatedPrefix(prefix ...string) Option {
 	}
 }
 
+// WithPropagatedTransform with the transform of the propagated keys, it is
+// called with the lowercase key both when the server extracts the request
+// header and when the client injects the server metadata, and returns the key
+// to propagate as or false to drop the key. It overrides the propagated prefix.
+func WithPropagatedTransform(f func(key string) (newKey string, keep bool)) Option {
+	return func(o *options) {
+		o.transform = f
+	}
+}
+
 // Server is middleware server-side metadata.
 func Server(opts ...Option) middleware.Middleware {
 	options := &options{
@@ -55,8 +97,8 @@ func Server(opts ...Option) middleware.Middleware {
 				md := options.md.Clone()
 				header := tr.RequestHeader()
 				for _, k := range header.Keys() {
-					if options.hasPrefix(k) {
-						md.Set(k, header.Get(k))
+					if nk, ok := options.propagate(k); ok {
+						md.Set(nk, header.Get(k))
 					}
 				}
 				ctx = metadata.NewServerContext(ctx, md)
@@ -90,8 +132,8 @@ func Client(opts ...Option) middleware.Middleware {
 				// x-md-global-
 				if md, ok := metadata.FromServerContext(ctx); ok {
 					for k, v := range md {
-						if options.hasPrefix(k) {
-							header.Set(k, v)
+						if nk, ok := options.propagate(k); ok {
+							header.Set(nk, v)
 						}
 					}
 				}
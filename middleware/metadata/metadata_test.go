@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/go-kratos/kratos/v2/metadata"
@@ -126,3 +127,43 @@ func TestClient(t *testing.T) {
 		t.Fatalf("want foo got %v", reply)
 	}
 }
+
+func TestPropagatedTransform(t *testing.T) {
+	transform := func(key string) (string, bool) {
+		switch {
+		case key == "x-tenant":
+			return "x-md-global-tenant", true
+		case strings.HasPrefix(key, "x-md-global-"):
+			return key, true
+		}
+		return "", false
+	}
+	header := headerCarrier{}
+	header.Set("X-Tenant", "kratos")
+	header.Set("X-Internal", "secret")
+	ctx := transport.NewServerContext(context.Background(), &testTransport{header})
+
+	var md metadata.Metadata
+	_, err := Server(WithPropagatedTransform(transform))(func(ctx context.Context, in interface{}) (interface{}, error) {
+		md, _ = metadata.FromServerContext(ctx)
+		return in, nil
+	})(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(md) != 1 || md.Get("x-md-global-tenant") != "kratos" {
+		t.Fatalf("unexpected server metadata: %v", md)
+	}
+
+	out := headerCarrier{}
+	ctx = transport.NewClientContext(metadata.NewServerContext(context.Background(), md), &testTransport{out})
+	_, err = Client(WithPropagatedTransform(transform))(func(ctx context.Context, in interface{}) (interface{}, error) {
+		return in, nil
+	})(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || out.Get("x-md-global-tenant") != "kratos" {
+		t.Fatalf("unexpected client header: %v", out)
+	}
+}
@@ -2,13 +2,37 @@ package ratelimit
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"github.com/go-kratos/aegis/ratelimit"
 	"github.com/go-kratos/aegis/ratelimit/bbr"
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
 )
 
+func init() {
+	// options: rate and burst of a token bucket limiter, the bbr limiter is
+	// used if rate is not set. The burst defaults to the rate rounded up.
+	middleware.Register("ratelimit", func(c *middleware.Config) (middleware.Middleware, error) {
+		var o struct {
+			Rate  *float64 `json:"rate"`
+			Burst int      `json:"burst"`
+		}
+		if err := c.Decode(&o); err != nil {
+			return nil, err
+		}
+		if o.Rate == nil {
+			return Server(), nil
+		}
+		if *o.Rate <= 0 {
+			return nil, fmt.Errorf("ratelimit: rate must be positive, got %v", *o.Rate)
+		}
+		return Server(WithLimiter(NewTokenBucket(*o.Rate, o.Burst))), nil
+	})
+}
+
 // ErrLimitExceed is service unavailable due to rate limit exceeded.
 var ErrLimitExceed = errors.New(429, "RATELIMIT", "service unavailable due to rate limit exceeded")
 
@@ -23,8 +47,28 @@ func WithLimiter(limiter ratelimit.Limiter) Option {
 	}
 }
 
+// WithLimiterFactory set the Limiter of every operation, the limiter is
+// created by factory on the first request of the operation and cached,
+// operations with a nil limiter are not limited.
+func WithLimiterFactory(factory func(operation string) ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.factory = factory
+	}
+}
+
+// WithLimiterKey set the key the limiters of WithLimiterFactory are created
+// and cached by, e.g. a user id for per-user buckets, default is the operation.
+// Every distinct key holds a limiter, so keys should be of a bounded set.
+func WithLimiterKey(key func(ctx context.Context) string) Option {
+	return func(o *options) {
+		o.key = key
+	}
+}
+
 type options struct {
 	limiter ratelimit.Limiter
+	factory func(key string) ratelimit.Limiter
+	key     func(ctx context.Context) string
 }
 
 // Server ratelimiter middleware
@@ -35,9 +79,21 @@ func Server(opts ...Option) middleware.Middleware {
 	for _, o := range opts {
 		o(options)
 	}
+	limiter := func(context.Context) ratelimit.Limiter { return options.limiter }
+	if options.factory != nil {
+		key := options.key
+		if key == nil {
+			key = operation
+		}
+		limiter = newLimiters(key, options.factory).get
+	}
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
-			done, e := options.limiter.Allow()
+			l := limiter(ctx)
+			if l == nil {
+				return handler(ctx, req)
+			}
+			done, e := l.Allow()
 			if e != nil {
 				// rejected
 				return nil, ErrLimitExceed
@@ -49,3 +105,39 @@ func Server(opts ...Option) middleware.Middleware {
 		}
 	}
 }
+
+func operation(ctx context.Context) string {
+	if info, ok := transport.FromServerContext(ctx); ok {
+		return info.Operation()
+	}
+	return ""
+}
+
+// limiters is the cache of limiters by key.
+type limiters struct {
+	key     func(ctx context.Context) string
+	factory func(key string) ratelimit.Limiter
+	mu      sync.Mutex
+	cache   sync.Map
+}
+
+func newLimiters(key func(ctx context.Context) string, factory func(key string) ratelimit.Limiter) *limiters {
+	return &limiters{key: key, factory: factory}
+}
+
+func (l *limiters) get(ctx context.Context) ratelimit.Limiter {
+	key := l.key(ctx)
+	if v, ok := l.cache.Load(key); ok {
+		limiter, _ := v.(ratelimit.Limiter)
+		return limiter
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if v, ok := l.cache.Load(key); ok {
+		limiter, _ := v.(ratelimit.Limiter)
+		return limiter
+	}
+	limiter := l.factory(key)
+	l.cache.Store(key, limiter)
+	return limiter
+}
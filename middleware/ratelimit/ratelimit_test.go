@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *testTransport) Operation() string {
+	return tr.operation
+}
+
+type rejectLimiter struct{}
+
+func (rejectLimiter) Allow() (ratelimit.DoneFunc, error) {
+	return nil, ratelimit.ErrLimitExceed
+}
+
+func TestLimiterFactory(t *testing.T) {
+	created := map[string]int{}
+	next := Server(WithLimiterFactory(func(operation string) ratelimit.Limiter {
+		created[operation]++
+		if operation == "/v1/heavy" {
+			return rejectLimiter{}
+		}
+		return nil
+	}))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	for i := 0; i < 3; i++ {
+		heavy := transport.NewServerContext(context.Background(), &testTransport{operation: "/v1/heavy"})
+		if _, err := next(heavy, nil); !errors.Is(err, ErrLimitExceed) {
+			t.Fatalf("want ErrLimitExceed, got %v", err)
+		}
+		light := transport.NewServerContext(context.Background(), &testTransport{operation: "/v1/light"})
+		if _, err := next(light, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if created["/v1/heavy"] != 1 || created["/v1/light"] != 1 {
+		t.Fatalf("limiters should be created once per operation, got %v", created)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(100, 2)
+	for i := 0; i < 2; i++ {
+		done, err := b.Allow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(ratelimit.DoneInfo{})
+	}
+	if _, err := b.Allow(); !errors.Is(err, ratelimit.ErrLimitExceed) {
+		t.Fatalf("want ErrLimitExceed, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := b.Allow(); err != nil {
+		t.Fatalf("bucket should be refilled, got %v", err)
+	}
+}
+
+func TestTokenBucketDefaultBurst(t *testing.T) {
+	tests := []struct {
+		rate  float64
+		burst int
+		want  int
+	}{
+		{0.5, 0, 1},
+		{2.5, 0, 3},
+		{10, -1, 10},
+	}
+	for _, test := range tests {
+		b := NewTokenBucket(test.rate, test.burst)
+		for i := 0; i < test.want; i++ {
+			if _, err := b.Allow(); err != nil {
+				t.Fatalf("rate %v: want %d requests allowed, got %v", test.rate, test.want, err)
+			}
+		}
+		if _, err := b.Allow(); !errors.Is(err, ratelimit.ErrLimitExceed) {
+			t.Fatalf("rate %v: want the burst %d, got %v", test.rate, test.want, err)
+		}
+	}
+}
+
+type userKey struct{}
+
+func TestLimiterKey(t *testing.T) {
+	next := Server(
+		WithLimiterFactory(func(key string) ratelimit.Limiter { return NewTokenBucket(0, 1) }),
+		WithLimiterKey(func(ctx context.Context) string {
+			user, _ := ctx.Value(userKey{}).(string)
+			return user
+		}),
+	)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	alice := context.WithValue(context.Background(), userKey{}, "alice")
+	bob := context.WithValue(context.Background(), userKey{}, "bob")
+	if _, err := next(alice, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := next(bob, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := next(alice, nil); !errors.Is(err, ErrLimitExceed) {
+		t.Fatalf("want ErrLimitExceed, got %v", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	ms, err := middleware.Build(&middleware.Config{
+		Name:    "ratelimit",
+		Options: map[string]interface{}{"rate": 0.001, "burst": 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := middleware.Chain(ms...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	if _, err := next(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := next(context.Background(), nil); !errors.Is(err, ErrLimitExceed) {
+		t.Fatalf("want ErrLimitExceed, got %v", err)
+	}
+}
+
+func TestBuildDefaultBurst(t *testing.T) {
+	ms, err := middleware.Build(&middleware.Config{
+		Name:    "ratelimit",
+		Options: map[string]interface{}{"rate": 0.001},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := middleware.Chain(ms...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	if _, err := next(context.Background(), nil); err != nil {
+		t.Fatalf("want the request allowed by the default burst, got %v", err)
+	}
+	for _, rate := range []float64{0, -1} {
+		if _, err := middleware.Build(&middleware.Config{
+			Name:    "ratelimit",
+			Options: map[string]interface{}{"rate": rate},
+		}); err == nil {
+			t.Fatalf("want an error of the rate %v", rate)
+		}
+	}
+}
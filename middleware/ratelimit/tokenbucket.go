@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+var _ ratelimit.Limiter = (*TokenBucket)(nil)
+
+// TokenBucket is a token bucket limiter allowing rate requests per second
+// on average with bursts of up to burst requests.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket new a token bucket limiter which starts full, a burst not
+// positive is the rate rounded up and at least 1, as a bucket of no tokens
+// rejects every request.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow takes a token from the bucket, it returns ratelimit.ErrLimitExceed
+// if the bucket is empty.
+func (b *TokenBucket) Allow() (ratelimit.DoneFunc, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	b.tokens--
+	return func(ratelimit.DoneInfo) {}, nil
+}
@@ -1,18 +1,28 @@
 package recovery
 
 import (
+	"bytes"
 	"context"
 	"runtime"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"google.golang.org/grpc"
 )
 
+func init() {
+	middleware.Register("recovery", func(*middleware.Config) (middleware.Middleware, error) {
+		return Recovery(), nil
+	})
+}
+
 // ErrUnknownRequest is unknown request error.
 var ErrUnknownRequest = errors.InternalServer("UNKNOWN", "unknown request error")
 
-// HandlerFunc is recovery handler func.
+// HandlerFunc is recovery handler func, err is the recovered value and the
+// returned error is the error of the request, the stack of the panic is
+// returned by Stack(ctx), e.g. to report it to Sentry.
 type HandlerFunc func(ctx context.Context, req, err interface{}) error
 
 // Option is recovery option.
@@ -23,7 +33,7 @@ type options struct {
 	logger  log.Logger
 }
 
-// WithHandler with recovery handler.
+// WithHandler with recovery handler, the default one returns ErrUnknownRequest.
 func WithHandler(h HandlerFunc) Option {
 	return func(o *options) {
 		o.handler = h
@@ -39,29 +49,72 @@ func WithLogger(logger log.Logger) Option {
 
 // Recovery is a server middleware that recovers from any panics.
 func Recovery(opts ...Option) middleware.Middleware {
-	op := options{
-		logger: log.GetLogger(),
-		handler: func(ctx context.Context, req, err interface{}) error {
-			return ErrUnknownRequest
-		},
-	}
-	for _, o := range opts {
-		o(&op)
-	}
-	logger := log.NewHelper(op.logger)
+	op := newOptions(opts)
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
 			defer func() {
 				if rerr := recover(); rerr != nil {
-					buf := make([]byte, 64<<10) //nolint:gomnd
-					n := runtime.Stack(buf, false)
-					buf = buf[:n]
-					logger.WithContext(ctx).Errorf("%v: %+v\n%s\n", rerr, req, buf)
-
-					err = op.handler(ctx, req, rerr)
+					err = op.recover(ctx, req, rerr)
 				}
 			}()
 			return handler(ctx, req)
 		}
 	}
 }
+
+// StreamServerInterceptor returns a new gRPC stream server interceptor that
+// recovers from any panics of the streaming handlers, as the middleware is not
+// applied to them. req of the handler is the grpc.ServerStream.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	op := newOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if rerr := recover(); rerr != nil {
+				err = op.recover(ss.Context(), ss, rerr)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func newOptions(opts []Option) *options {
+	op := &options{
+		logger: log.GetLogger(),
+		handler: func(ctx context.Context, req, err interface{}) error {
+			return ErrUnknownRequest
+		},
+	}
+	for _, o := range opts {
+		o(op)
+	}
+	return op
+}
+
+// recover logs the panic rerr with the stack and returns the error of the handler
+func (o *options) recover(ctx context.Context, req, rerr interface{}) error {
+	buf := make([]byte, 64<<10) //nolint:gomnd
+	n := runtime.Stack(buf, false)
+	buf = trimStack(buf[:n])
+	log.NewHelper(o.logger).WithContext(ctx).Errorf("%v: %+v\n%s\n", rerr, req, buf)
+
+	return o.handler(context.WithValue(ctx, stackKey{}, buf), req, rerr)
+}
+
+type stackKey struct{}
+
+// Stack returns the stack of the panicking goroutine in the recovery handler.
+func Stack(ctx context.Context) []byte {
+	buf, _ := ctx.Value(stackKey{}).([]byte)
+	return buf
+}
+
+// trimStack removes the frames of the recovery from the stack, so that it
+// begins with the panic frame followed by the frames of the panicking code.
+func trimStack(buf []byte) []byte {
+	i := bytes.IndexByte(buf, '\n')
+	j := bytes.Index(buf, []byte("\npanic("))
+	if i < 0 || j < 0 {
+		return buf
+	}
+	return append(buf[:i:i], buf[j:]...)
+}
@@ -3,10 +3,12 @@ package recovery
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/grpc"
 )
 
 func TestOnce(t *testing.T) {
@@ -35,3 +37,46 @@ func TestNotPanic(t *testing.T) {
 		t.Errorf("e isn't nil")
 	}
 }
+
+func panicking() {
+	panic("panic reason")
+}
+
+func TestStack(t *testing.T) {
+	var stack string
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		panicking()
+		return nil, nil
+	}
+	_, err := Recovery(WithHandler(func(ctx context.Context, req, err interface{}) error {
+		stack = string(Stack(ctx))
+		return errors.InternalServer("RECOVERY", fmt.Sprintf("panic triggered: %v", err))
+	}))(next)(context.Background(), "panic")
+	if errors.Reason(err) != "RECOVERY" {
+		t.Fatalf("want the error of the handler, got %v", err)
+	}
+	if !strings.HasPrefix(stack, "goroutine ") {
+		t.Fatalf("want the goroutine header, got %s", stack)
+	}
+	lines := strings.Split(stack, "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[1], "panic(") || !strings.Contains(lines[3], "recovery.panicking(") {
+		t.Fatalf("want the stack beginning with the panic frame, got %s", stack)
+	}
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *mockServerStream) Context() context.Context { return context.Background() }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	err := StreamServerInterceptor()(nil, &mockServerStream{}, &grpc.StreamServerInfo{},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			panicking()
+			return nil
+		})
+	if !errors.Is(err, ErrUnknownRequest) {
+		t.Fatalf("want ErrUnknownRequest, got %v", err)
+	}
+}
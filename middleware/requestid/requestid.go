@@ -0,0 +1,167 @@
+// Package requestid provides the middleware carrying a request id for the
+// correlation of the requests across the services.
+//
+// The server reads the id from the X-Request-ID header of HTTP or the
+// x-request-id metadata of gRPC, or generates a UUID if it is missing or
+// invalid, places it in the context and echoes it in the reply header. The
+// client sends the id of the context, e.g. the one of the server request, or
+// generates a new one.
+//
+// The logging and tracing middleware log the id and set it as a span
+// attribute, so the requestid middleware must come before them in the chain.
+package requestid
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/google/uuid"
+)
+
+// Header is the default header of the request id.
+const Header = "X-Request-ID"
+
+// maxLength is the max length of the valid ids.
+const maxLength = 128
+
+func init() {
+	// options: header is the header of the request id.
+	middleware.Register("requestid", func(c *middleware.Config) (middleware.Middleware, error) {
+		var o struct {
+			Header string `json:"header"`
+		}
+		if err := c.Decode(&o); err != nil {
+			return nil, err
+		}
+		var opts []Option
+		if o.Header != "" {
+			opts = append(opts, WithHeader(o.Header))
+		}
+		return Server(opts...), nil
+	})
+}
+
+// Option is request id option.
+type Option func(*options)
+
+type options struct {
+	header    string
+	generator func() string
+	validator func(id string) bool
+}
+
+// WithHeader with the header of the request id, default is X-Request-ID.
+func WithHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithGenerator with the generator of the request ids, default generates
+// random UUIDs.
+func WithGenerator(f func() string) Option {
+	return func(o *options) {
+		o.generator = f
+	}
+}
+
+// WithValidator with the validator of the incoming request ids, the invalid
+// ones are replaced by generated ids. The default accepts the printable ASCII
+// ids of up to 128 bytes.
+func WithValidator(f func(id string) bool) Option {
+	return func(o *options) {
+		o.validator = f
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		header:    Header,
+		generator: uuid.NewString,
+		validator: valid,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// valid reports whether id is a non-empty printable ASCII string of up to
+// maxLength bytes, so that it is safe to log and to send in headers.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+type requestIDKey struct{}
+
+// NewContext returns a new context with the request id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request id of the context.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// RequestID returns a log valuer of the request id.
+func RequestID() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		id, _ := FromContext(ctx)
+		return id
+	}
+}
+
+// Server is a server middleware that reads the request id of the request
+// header or generates one, and echoes it in the reply header.
+func Server(opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				id := tr.RequestHeader().Get(o.header)
+				if !o.validator(id) {
+					id = o.generator()
+				}
+				if header := tr.ReplyHeader(); header != nil {
+					header.Set(o.header, id)
+				}
+				ctx = NewContext(ctx, id)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// Client is a client middleware that sends the request id of the context or
+// a generated one, unless the request header already has one.
+func Client(opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				header := tr.RequestHeader()
+				id := header.Get(o.header)
+				if id == "" {
+					if id, ok = FromContext(ctx); !ok {
+						id = o.generator()
+					}
+					header.Set(o.header, id)
+				}
+				ctx = NewContext(ctx, id)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
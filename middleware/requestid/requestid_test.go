@@ -0,0 +1,135 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type testTransport struct {
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+func newTransport() *testTransport {
+	return &testTransport{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) Operation() string               { return "" }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *testTransport) ReplyHeader() transport.Header   { return tr.replyHeader }
+
+func handleID(ctx context.Context, req interface{}) (interface{}, error) {
+	id, _ := FromContext(ctx)
+	return id, nil
+}
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		opts     []Option
+		want     string
+	}{
+		{"incoming", "abc-123", nil, "abc-123"},
+		{"missing", "", []Option{WithGenerator(func() string { return "gen" })}, "gen"},
+		{"invalid", "a b", []Option{WithGenerator(func() string { return "gen" })}, "gen"},
+		{"too long", strings.Repeat("a", maxLength+1), []Option{WithGenerator(func() string { return "gen" })}, "gen"},
+		{"validator", "a b", []Option{WithValidator(func(id string) bool { return id != "" })}, "a b"},
+		{"header", "abc-123", []Option{WithHeader("X-Trace-Request")}, "abc-123"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := newOptions(test.opts).header
+			tr := newTransport()
+			if test.incoming != "" {
+				tr.reqHeader.Set(header, test.incoming)
+			}
+			ctx := transport.NewServerContext(context.Background(), tr)
+			reply, err := Server(test.opts...)(handleID)(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply != test.want {
+				t.Fatalf("want the request id %q, got %q", test.want, reply)
+			}
+			if got := tr.replyHeader.Get(header); got != test.want {
+				t.Fatalf("want the reply header %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestServer_Generate(t *testing.T) {
+	ctx := transport.NewServerContext(context.Background(), newTransport())
+	a, _ := Server()(handleID)(ctx, nil)
+	b, _ := Server()(handleID)(ctx, nil)
+	if a == "" || a == b {
+		t.Fatalf("want unique generated ids, got %q and %q", a, b)
+	}
+}
+
+func TestClient(t *testing.T) {
+	gen := WithGenerator(func() string { return "gen" })
+	tests := []struct {
+		name   string
+		ctxID  string
+		header string
+		want   string
+	}{
+		{"context", "from-server", "", "from-server"},
+		{"generated", "", "", "gen"},
+		{"header", "from-server", "explicit", "explicit"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.ctxID != "" {
+				ctx = NewContext(ctx, test.ctxID)
+			}
+			tr := newTransport()
+			if test.header != "" {
+				tr.reqHeader.Set(Header, test.header)
+			}
+			ctx = transport.NewClientContext(ctx, tr)
+			reply, err := Client(gen)(handleID)(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply != test.want {
+				t.Fatalf("want the request id %q, got %q", test.want, reply)
+			}
+			if got := tr.reqHeader.Get(Header); got != test.want {
+				t.Fatalf("want the request header %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	if got := RequestID()(NewContext(context.Background(), "abc")); got != "abc" {
+		t.Fatalf("want abc, got %v", got)
+	}
+	if got := RequestID()(context.Background()); got != "" {
+		t.Fatalf("want empty, got %v", got)
+	}
+}
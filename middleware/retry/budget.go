@@ -0,0 +1,45 @@
+package retry
+
+import "sync"
+
+// Budget is the retry budget avoiding the retry storms, it is the token
+// bucket of the retry throttling of gRPC: every retryable failure takes a
+// token and every other reply puts ratio tokens back, the retries are allowed
+// only if there are more than half of the max tokens.
+type Budget struct {
+	mu     sync.Mutex
+	max    float64
+	ratio  float64
+	tokens float64
+}
+
+// NewBudget new a retry budget of max tokens which starts full.
+func NewBudget(max, ratio float64) *Budget {
+	return &Budget{
+		max:    max,
+		ratio:  ratio,
+		tokens: max,
+	}
+}
+
+func (b *Budget) succeed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens += b.ratio; b.tokens > b.max {
+		b.tokens = b.max
+	}
+}
+
+func (b *Budget) fail() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens--; b.tokens < 0 {
+		b.tokens = 0
+	}
+}
+
+func (b *Budget) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.max/2
+}
@@ -0,0 +1,139 @@
+// Package retry provides a client middleware retrying the failed requests.
+//
+// Every retry invokes the next handler again, so the node is selected again
+// by the selector of the client and the retry may hit another backend. Only
+// the idempotent operations are retried: the HTTP requests of the idempotent
+// methods, the requests with the idempotency key and the operations allowed
+// by WithOperations. The gRPC operations are not retried unless allowed.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/internal/attempt"
+	"github.com/go-kratos/kratos/v2/middleware"
+)
+
+// Backoff returns the duration to wait before the retry of attempt, the first
+// retry is attempt 1.
+type Backoff func(attempt int) time.Duration
+
+// Constant returns the backoff waiting d before every retry.
+func Constant(d time.Duration) Backoff {
+	return func(int) time.Duration { return d }
+}
+
+// Exponential returns the backoff of the full jitter, it waits a random
+// duration up to base*2^(attempt-1) capped by max.
+func Exponential(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := max
+		if attempt < 32 {
+			if v := base << (attempt - 1); v > 0 && v < max {
+				d = v
+			}
+		}
+		return time.Duration(rand.Int63n(int64(d) + 1))
+	}
+}
+
+// Option is retry option.
+type Option func(*options)
+
+type options struct {
+	max        int
+	backoff    Backoff
+	retryable  func(err error) bool
+	budget     *Budget
+	operations attempt.Operations
+}
+
+// WithMax with the max number of the retries, default is 2.
+func WithMax(n int) Option {
+	return func(o *options) {
+		o.max = n
+	}
+}
+
+// WithBackoff with the backoff of the retries, default is
+// Exponential(100*time.Millisecond, time.Second).
+func WithBackoff(b Backoff) Option {
+	return func(o *options) {
+		o.backoff = b
+	}
+}
+
+// WithRetryable with the func reporting whether err is retryable, default
+// retries the Unavailable and DeadlineExceeded errors.
+func WithRetryable(f func(err error) bool) Option {
+	return func(o *options) {
+		o.retryable = f
+	}
+}
+
+// WithBudget with the retry budget, it is shared by the middlewares of
+// the same budget. Default is NewBudget(10, 0.1) of the middleware.
+func WithBudget(b *Budget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// WithOperations with the operations retried even if they are not idempotent.
+func WithOperations(operations ...string) Option {
+	return func(o *options) {
+		o.operations.Add(operations...)
+	}
+}
+
+// Client is a client middleware retrying the failed idempotent requests.
+func Client(opts ...Option) middleware.Middleware {
+	o := &options{
+		max:        2,
+		backoff:    Exponential(100*time.Millisecond, time.Second),
+		retryable:  attempt.Retryable,
+		operations: make(attempt.Operations),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.budget == nil {
+		o.budget = NewBudget(10, 0.1)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			for n := 1; ; n++ {
+				reply, err := handler(ctx, req)
+				if err == nil || !o.retryable(err) {
+					o.budget.succeed()
+					return reply, err
+				}
+				o.budget.fail()
+				if n > o.max || !attempt.Idempotent(ctx, o.operations) || !o.budget.allow() {
+					return reply, err
+				}
+				if !sleep(ctx, o.backoff(n)) {
+					return reply, err
+				}
+			}
+		}
+	}
+}
+
+// sleep waits d, it returns false if ctx is done or the deadline of ctx is
+// before the end of d.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= d {
+		return false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
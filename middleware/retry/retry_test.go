@@ -0,0 +1,124 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware/idempotency"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string        { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Keys() []string               { return nil }
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+	header    headerCarrier
+}
+
+func (tr *testTransport) Operation() string               { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+
+func TestClient(t *testing.T) {
+	unavailable := errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+	tests := []struct {
+		name  string
+		opts  []Option
+		key   string
+		err   error
+		calls int
+	}{
+		{"not idempotent", nil, "", unavailable, 1},
+		{"allowed operation", []Option{WithOperations("/test/create")}, "", unavailable, 3},
+		{"idempotency key", nil, "1", unavailable, 3},
+		{"max", []Option{WithOperations("/test/create"), WithMax(4)}, "", unavailable, 5},
+		{"not retryable", []Option{WithOperations("/test/create")}, "", errors.BadRequest("BAD", "bad"), 1},
+		{"retryable", []Option{WithOperations("/test/create"), WithRetryable(func(error) bool { return true })}, "", errors.BadRequest("BAD", "bad"), 3},
+		{"budget", []Option{WithOperations("/test/create"), WithBudget(NewBudget(2, 0.1))}, "", unavailable, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr := &testTransport{operation: "/test/create", header: headerCarrier{}}
+			if test.key != "" {
+				tr.header.Set(idempotency.Header, test.key)
+			}
+			calls := 0
+			opts := append([]Option{WithBackoff(Constant(time.Millisecond))}, test.opts...)
+			_, err := Client(opts...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls++
+				return nil, test.err
+			})(transport.NewClientContext(context.Background(), tr), nil)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("want %v, got %v", test.err, err)
+			}
+			if calls != test.calls {
+				t.Fatalf("want %d calls, got %d", test.calls, calls)
+			}
+		})
+	}
+}
+
+func TestClientDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ctx = transport.NewClientContext(ctx, &testTransport{operation: "/test/get", header: headerCarrier{}})
+	calls := 0
+	_, err := Client(WithOperations("/test/get"), WithBackoff(Constant(time.Second)))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+	})(ctx, nil)
+	if !errors.IsServiceUnavailable(err) || calls != 1 {
+		t.Fatalf("want 1 call before the deadline, got %d: %v", calls, err)
+	}
+}
+
+func TestExponential(t *testing.T) {
+	b := Exponential(10*time.Millisecond, 50*time.Millisecond)
+	for attempt, max := range []time.Duration{10, 20, 40, 50, 50} {
+		for i := 0; i < 10; i++ {
+			if d := b(attempt + 1); d < 0 || d > max*time.Millisecond {
+				t.Fatalf("attempt %d: want up to %v, got %v", attempt+1, max*time.Millisecond, d)
+			}
+		}
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	client, err := khttp.NewClient(context.Background(),
+		khttp.WithEndpoint(srv.Listener.Addr().String()),
+		khttp.WithMiddleware(Client(WithBackoff(Constant(time.Millisecond)))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := map[string]string{"name": "kratos"}
+	out := map[string]string{}
+	if err = client.Invoke(context.Background(), http.MethodPut, "/test", in, &out); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || out["name"] != "kratos" {
+		t.Fatalf("want the body sent again by the retry, got %d calls: %v", calls, out)
+	}
+}
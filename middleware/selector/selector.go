@@ -73,17 +73,24 @@ func (b *Builder) Match(fn MatchFunc) *Builder {
 
 // Build is Builder's Build, for example: Server().Path(m1,m2).Build()
 func (b *Builder) Build() middleware.Middleware {
-	var transporter func(ctx context.Context) (transport.Transporter, bool)
+	tr := serverTransporter
 	if b.client {
-		transporter = clientTransporter
-	} else {
-		transporter = serverTransporter
+		tr = clientTransporter
 	}
-	return selector(transporter, b.matches, b.ms...)
+	regexs := make([]*regexp.Regexp, 0, len(b.regex))
+	for _, regex := range b.regex {
+		// invalid regexes match nothing
+		if r, err := regexp.Compile(regex); err == nil {
+			regexs = append(regexs, r)
+		}
+	}
+	return selector(tr, func(ctx context.Context, transporter transporter) bool {
+		return b.matches(ctx, transporter, regexs)
+	}, b.ms...)
 }
 
 // matches is match operation compliance Builder
-func (b *Builder) matches(ctx context.Context, transporter transporter) bool {
+func (b *Builder) matches(ctx context.Context, transporter transporter, regexs []*regexp.Regexp) bool {
 	info, ok := transporter(ctx)
 	if !ok {
 		return false
@@ -95,7 +102,7 @@ func (b *Builder) matches(ctx context.Context, transporter transporter) bool {
 			return true
 		}
 	}
-	for _, regex := range b.regex {
+	for _, regex := range regexs {
 		if regexMatch(regex, operation) {
 			return true
 		}
@@ -118,11 +125,12 @@ func (b *Builder) matches(ctx context.Context, transporter transporter) bool {
 // selector middleware
 func selector(transporter transporter, match func(context.Context, transporter) bool, ms ...middleware.Middleware) middleware.Middleware {
 	return func(handler middleware.Handler) middleware.Handler {
+		next := middleware.Chain(ms...)(handler)
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
 			if !match(ctx, transporter) {
 				return handler(ctx, req)
 			}
-			return middleware.Chain(ms...)(handler)(ctx, req)
+			return next(ctx, req)
 		}
 	}
 }
@@ -135,10 +143,6 @@ func prefixMatch(prefix string, operation string) bool {
 	return strings.HasPrefix(operation, prefix)
 }
 
-func regexMatch(regex string, operation string) bool {
-	r, err := regexp.Compile(regex)
-	if err != nil {
-		return false
-	}
+func regexMatch(r *regexp.Regexp, operation string) bool {
 	return r.FindString(operation) == operation
 }
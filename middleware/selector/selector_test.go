@@ -249,3 +249,63 @@ func testMiddleware(handler middleware.Handler) middleware.Handler {
 		return
 	}
 }
+
+func TestPassThrough(t *testing.T) {
+	var calls int
+	counter := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls++
+			return handler(ctx, req)
+		}
+	}
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	}
+	tests := []struct {
+		name  string
+		ms    middleware.Middleware
+		ctx   context.Context
+		calls int
+	}{
+		{"server admin", Server(counter).Prefix("/v1/admin/").Build(), transport.NewServerContext(context.Background(), &Transport{operation: "/v1/admin/users"}), 1},
+		{"server public", Server(counter).Prefix("/v1/admin/").Build(), transport.NewServerContext(context.Background(), &Transport{operation: "/v1/public/users"}), 0},
+		{"client regex", Client(counter).Regex("/v1/admin/.*").Build(), transport.NewClientContext(context.Background(), &Transport{operation: "/v1/admin/users"}), 1},
+		{"client public", Client(counter).Regex("/v1/admin/.*").Build(), transport.NewClientContext(context.Background(), &Transport{operation: "/v1/public/users"}), 0},
+		{"invalid regex", Server(counter).Regex("(").Build(), transport.NewServerContext(context.Background(), &Transport{operation: "/v1/admin/users"}), 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls = 0
+			reply, err := test.ms(next)(test.ctx, nil)
+			if err != nil || reply != "reply" {
+				t.Fatalf("unexpected reply %v, err %v", reply, err)
+			}
+			if calls != test.calls {
+				t.Fatalf("want %d calls, got %d", test.calls, calls)
+			}
+		})
+	}
+}
+
+func TestBuildRegexTwice(t *testing.T) {
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "reply", nil
+	}
+	var calls int
+	counter := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls++
+			return handler(ctx, req)
+		}
+	}
+	b := Server(counter).Regex("/v1/admin/.*")
+	admin := b.Build()
+	b.Regex("/v1/public/.*").Build()
+	ctx := transport.NewServerContext(context.Background(), &Transport{operation: "/v1/admin/users"})
+	if _, err := admin(next)(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("the built middleware should keep its regexes, got %d calls", calls)
+	}
+}
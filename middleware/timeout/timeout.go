@@ -0,0 +1,105 @@
+// Package timeout provides a middleware bounding the duration of handlers.
+//
+// The deadline is set by context.WithTimeout, so it never extends a deadline
+// already set on the context: the effective deadline is the earlier one of
+// the transport timeout, e.g. the Timeout server option of transport/http and
+// transport/grpc, the deadline propagated by the client and the one of this
+// middleware. Handlers and the RPCs issued by them must observe the context
+// to be interrupted by it.
+package timeout
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+func init() {
+	// options: timeout is the default timeout, operations is the timeouts by
+	// operation, both are duration strings, e.g. "1s".
+	middleware.Register("timeout", func(c *middleware.Config) (middleware.Middleware, error) {
+		var o struct {
+			Timeout    string            `json:"timeout"`
+			Operations map[string]string `json:"operations"`
+		}
+		if err := c.Decode(&o); err != nil {
+			return nil, err
+		}
+		var (
+			timeout time.Duration
+			opts    []Option
+			err     error
+		)
+		if o.Timeout != "" {
+			if timeout, err = time.ParseDuration(o.Timeout); err != nil {
+				return nil, err
+			}
+		}
+		for op, v := range o.Operations {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, WithOperation(op, d))
+		}
+		return Server(timeout, opts...), nil
+	})
+}
+
+// ErrDeadlineExceeded is returned if the deadline is exceeded,
+// it is converted to codes.DeadlineExceeded or 504 by the transports.
+var ErrDeadlineExceeded = errors.GatewayTimeout("DEADLINE_EXCEEDED", "request deadline exceeded")
+
+// Option is timeout option.
+type Option func(*options)
+
+type options struct {
+	operations map[string]time.Duration
+}
+
+// WithOperation with the timeout of the operation overriding the default one,
+// zero disables the timeout of the operation.
+func WithOperation(operation string, timeout time.Duration) Option {
+	return func(o *options) {
+		o.operations[operation] = timeout
+	}
+}
+
+// Server is a server middleware setting the timeout of the context passed to
+// the handler, zero disables the timeout of the operations not overridden.
+func Server(timeout time.Duration, opts ...Option) middleware.Middleware {
+	o := &options{
+		operations: make(map[string]time.Duration),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			d := timeout
+			if len(o.operations) > 0 {
+				if tr, ok := transport.FromServerContext(ctx); ok {
+					if v, ok := o.operations[tr.Operation()]; ok {
+						d = v
+					}
+				}
+			}
+			if d <= 0 {
+				return handler(ctx, req)
+			}
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			reply, err := handler(ctx, req)
+			if ctx.Err() == context.DeadlineExceeded {
+				if err == nil {
+					err = ctx.Err()
+				}
+				return nil, ErrDeadlineExceeded.WithCause(err)
+			}
+			return reply, err
+		}
+	}
+}
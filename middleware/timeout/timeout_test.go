@@ -0,0 +1,95 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *testTransport) Operation() string {
+	return tr.operation
+}
+
+func TestServer(t *testing.T) {
+	wait := func(ctx context.Context, req interface{}) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+			return "reply", nil
+		}
+	}
+	next := Server(50*time.Millisecond, WithOperation("/slow", time.Second), WithOperation("/none", 0))(wait)
+
+	tests := []struct {
+		name      string
+		operation string
+		err       error
+	}{
+		{"default", "/fast", ErrDeadlineExceeded},
+		{"override", "/slow", nil},
+		{"disabled", "/none", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := transport.NewServerContext(context.Background(), &testTransport{operation: test.operation})
+			reply, err := next(ctx, nil)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("want %v, got %v", test.err, err)
+			}
+			if test.err == nil && reply != "reply" {
+				t.Fatalf("want reply, got %v", reply)
+			}
+			if test.err != nil && !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("want the cause context.DeadlineExceeded, got %v", err)
+			}
+		})
+	}
+}
+
+func TestServerShorterDeadline(t *testing.T) {
+	next := Server(time.Second)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > 100*time.Millisecond {
+			t.Fatalf("the shorter deadline should be kept, got %v", deadline)
+		}
+		return "reply", nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := next(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	if _, err := middleware.Build(&middleware.Config{
+		Name:    "timeout",
+		Options: map[string]interface{}{"timeout": "1"},
+	}); err == nil {
+		t.Fatal("want an invalid duration error")
+	}
+	ms, err := middleware.Build(&middleware.Config{
+		Name:    "timeout",
+		Options: map[string]interface{}{"timeout": "10ms"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = middleware.Chain(ms...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})(context.Background(), nil)
+	if !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("want ErrDeadlineExceeded, got %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/middleware/requestid"
 	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/go-kratos/kratos/v2/transport/http"
 
@@ -48,6 +49,9 @@ func setClientSpan(ctx context.Context, span trace.Span, m interface{}) {
 	if p, ok := m.(proto.Message); ok {
 		attrs = append(attrs, attribute.Key("send_msg.size").Int(proto.Size(p)))
 	}
+	if id, ok := requestid.FromContext(ctx); ok {
+		attrs = append(attrs, attribute.Key("request.id").String(id))
+	}
 
 	span.SetAttributes(attrs...)
 }
@@ -83,6 +87,9 @@ func setServerSpan(ctx context.Context, span trace.Span, m interface{}) {
 	if p, ok := m.(proto.Message); ok {
 		attrs = append(attrs, attribute.Key("recv_msg.size").Int(proto.Size(p)))
 	}
+	if id, ok := requestid.FromContext(ctx); ok {
+		attrs = append(attrs, attribute.Key("request.id").String(id))
+	}
 	if md, ok := metadata.FromServerContext(ctx); ok {
 		attrs = append(attrs, semconv.PeerServiceKey.String(md.Get(serviceHeader)))
 	}
@@ -112,6 +119,14 @@ func parseFullMethod(fullMethod string) (string, []attribute.KeyValue) {
 }
 
 // peerAttr returns attributes about the peer address.
+// setStreamSpan sets the numbers of the messages of the stream
+func setStreamSpan(span trace.Span, sent, received int64) {
+	span.SetAttributes(
+		attribute.Key("send_msg.count").Int64(sent),
+		attribute.Key("recv_msg.count").Int64(received),
+	)
+}
+
 func peerAttr(addr string) []attribute.KeyValue {
 	host, port, err := net.SplitHostPort(addr)
 	if err != nil {
@@ -13,6 +13,8 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+var defaultPropagator = propagation.NewCompositeTextMapPropagator(Metadata{}, propagation.Baggage{}, propagation.TraceContext{})
+
 // Tracer is otel span tracer
 type Tracer struct {
 	tracer trace.Tracer
@@ -22,49 +24,73 @@ type Tracer struct {
 
 // NewTracer create tracer instance
 func NewTracer(kind trace.SpanKind, opts ...Option) *Tracer {
-	op := options{
-		propagator: propagation.NewCompositeTextMapPropagator(Metadata{}, propagation.Baggage{}, propagation.TraceContext{}),
-	}
+	op := options{}
 	for _, o := range opts {
 		o(&op)
 	}
+	var tracer trace.Tracer
 	if op.tracerProvider != nil {
-		otel.SetTracerProvider(op.tracerProvider)
+		tracer = op.tracerProvider.Tracer("kratos")
+	} else {
+		tracer = otel.Tracer("kratos")
 	}
 
 	switch kind {
 	case trace.SpanKindClient:
-		return &Tracer{tracer: otel.Tracer("kratos"), kind: kind, opt: &op}
+		return &Tracer{tracer: tracer, kind: kind, opt: &op}
 	case trace.SpanKindServer:
-		return &Tracer{tracer: otel.Tracer("kratos"), kind: kind, opt: &op}
+		return &Tracer{tracer: tracer, kind: kind, opt: &op}
 	default:
 		panic(fmt.Sprintf("unsupported span kind: %v", kind))
 	}
 }
 
+// propagator returns the propagator of the options, or the global one if it is
+// unset. The default one of kratos is used if the global one is not set either.
+func (t *Tracer) propagator() propagation.TextMapPropagator {
+	if t.opt.propagator != nil {
+		return t.opt.propagator
+	}
+	if p := otel.GetTextMapPropagator(); len(p.Fields()) > 0 {
+		return p
+	}
+	return defaultPropagator
+}
+
 // Start start tracing span
 func (t *Tracer) Start(ctx context.Context, operation string, carrier propagation.TextMapCarrier) (context.Context, trace.Span) {
 	if t.kind == trace.SpanKindServer {
-		ctx = t.opt.propagator.Extract(ctx, carrier)
+		ctx = t.propagator().Extract(ctx, carrier)
 	}
 	ctx, span := t.tracer.Start(ctx,
 		operation,
 		trace.WithSpanKind(t.kind),
 	)
 	if t.kind == trace.SpanKindClient {
-		t.opt.propagator.Inject(ctx, carrier)
+		t.propagator().Inject(ctx, carrier)
 	}
 	return ctx, span
 }
 
+// setAttributes sets the attributes of the WithAttributes func
+func (t *Tracer) setAttributes(ctx context.Context, span trace.Span, req interface{}) {
+	if t.opt.attributes != nil {
+		span.SetAttributes(t.opt.attributes(ctx, req)...)
+	}
+}
+
 // End finish tracing span
 func (t *Tracer) End(ctx context.Context, span trace.Span, m interface{}, err error) {
 	if err != nil {
 		span.RecordError(err)
+		description := err.Error()
 		if e := errors.FromError(err); e != nil {
 			span.SetAttributes(attribute.Key("rpc.status_code").Int64(int64(e.Code)))
+			if e.Reason != "" {
+				description = e.Reason
+			}
 		}
-		span.SetStatus(codes.Error, err.Error())
+		span.SetStatus(codes.Error, description)
 	} else {
 		span.SetStatus(codes.Ok, "OK")
 	}
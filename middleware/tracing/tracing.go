@@ -3,37 +3,57 @@ package tracing
 import (
 	"context"
 
+	"github.com/go-kratos/kratos/v2/internal/stream"
 	"github.com/go-kratos/kratos/v2/log"
 
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 )
 
+func init() {
+	middleware.Register("tracing", func(*middleware.Config) (middleware.Middleware, error) {
+		return Server(), nil
+	})
+}
+
 // Option is tracing option.
 type Option func(*options)
 
 type options struct {
 	tracerProvider trace.TracerProvider
 	propagator     propagation.TextMapPropagator
+	attributes     func(ctx context.Context, req interface{}) []attribute.KeyValue
 }
 
-// WithPropagator with tracer propagator.
+// WithPropagator with tracer propagator extracting the server requests and
+// injecting the client requests, the global one is used if it is unset.
 func WithPropagator(propagator propagation.TextMapPropagator) Option {
 	return func(opts *options) {
 		opts.propagator = propagator
 	}
 }
 
-// WithTracerProvider with tracer provider.
-// Deprecated: use otel.SetTracerProvider(provider) instead.
+// WithTracerProvider with tracer provider of the middleware, the global one is
+// used if it is unset.
 func WithTracerProvider(provider trace.TracerProvider) Option {
 	return func(opts *options) {
 		opts.tracerProvider = provider
 	}
 }
 
+// WithAttributes with the func returning the extra attributes of the server
+// span, e.g. the tenant id or the user id. req is the grpc.ServerStream of the
+// streaming handlers traced by StreamServerInterceptor.
+func WithAttributes(f func(ctx context.Context, req interface{}) []attribute.KeyValue) Option {
+	return func(opts *options) {
+		opts.attributes = f
+	}
+}
+
 // Server returns a new server middleware for OpenTelemetry.
 func Server(opts ...Option) middleware.Middleware {
 	tracer := NewTracer(trace.SpanKindServer, opts...)
@@ -43,6 +63,7 @@ func Server(opts ...Option) middleware.Middleware {
 				var span trace.Span
 				ctx, span = tracer.Start(ctx, tr.Operation(), tr.RequestHeader())
 				setServerSpan(ctx, span, req)
+				tracer.setAttributes(ctx, span, req)
 				defer func() { tracer.End(ctx, span, reply, err) }()
 			}
 			return handler(ctx, req)
@@ -50,6 +71,54 @@ func Server(opts ...Option) middleware.Middleware {
 	}
 }
 
+// StreamServerInterceptor returns a new gRPC stream server interceptor for
+// OpenTelemetry, as the middleware is not applied to the streaming handlers.
+// It must be chained after the kratos one, e.g. by grpc.StreamInterceptor.
+// The span ends when the handler returns.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	tracer := NewTracer(trace.SpanKindServer, opts...)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		tr, ok := transport.FromServerContext(ss.Context())
+		if !ok {
+			return handler(srv, ss)
+		}
+		ctx, span := tracer.Start(ss.Context(), tr.Operation(), tr.RequestHeader())
+		setServerSpan(ctx, span, ss)
+		tracer.setAttributes(ctx, span, ss)
+		s := stream.NewServerStream(ctx, ss)
+		defer func() {
+			setStreamSpan(span, s.Sent(), s.Received())
+			tracer.End(ctx, span, nil, err)
+		}()
+		return handler(srv, s)
+	}
+}
+
+// StreamClientInterceptor returns a new gRPC stream client interceptor for
+// OpenTelemetry, e.g. set by grpc.WithStreamInterceptor. The span ends when
+// the stream is finished, i.e. the reply or an error is received, or when the
+// context of the stream is done.
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	tracer := NewTracer(trace.SpanKindClient, opts...)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		tr, ok := transport.FromClientContext(ctx)
+		if !ok {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		ctx, span := tracer.Start(ctx, tr.Operation(), tr.RequestHeader())
+		setClientSpan(ctx, span, nil)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			tracer.End(ctx, span, nil, err)
+			return nil, err
+		}
+		return stream.NewClientStream(ctx, cs, desc, func(sent, received int64, err error) {
+			setStreamSpan(span, sent, received)
+			tracer.End(ctx, span, nil, err)
+		}), nil
+	}
+}
+
 // Client returns a new client middleware for OpenTelemetry.
 func Client(opts ...Option) middleware.Middleware {
 	tracer := NewTracer(trace.SpanKindClient, opts...)
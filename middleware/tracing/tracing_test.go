@@ -2,16 +2,23 @@ package tracing
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"os"
 	"reflect"
 	"testing"
 
+	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/transport"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 )
 
 var _ transport.Transporter = &mockTransport{}
@@ -212,3 +219,159 @@ func TestClient(t *testing.T) {
 		t.Errorf("expected %v, got %v", childTraceID, span.SpanContext().TraceID().String())
 	}
 }
+
+func TestPropagatorHop(t *testing.T) {
+	tp := tracesdk.NewTracerProvider()
+	tests := []struct {
+		name       string
+		propagator propagation.TextMapPropagator
+		baggage    string
+	}{
+		{
+			name:       "baggage",
+			propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+			baggage:    "user=alice",
+		},
+		{
+			name:       "tracecontext only",
+			propagator: propagation.TraceContext{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the gRPC request of the upstream caller
+			in := &mockTransport{kind: transport.KindGRPC, operation: "/test.server/hello", header: headerCarrier{}}
+			in.header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			in.header.Set("baggage", "user=alice")
+			// the HTTP request to the downstream
+			out := &mockTransport{kind: transport.KindHTTP, operation: "/test/hello", header: headerCarrier{}}
+
+			client := Client(WithTracerProvider(tp), WithPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})))
+			next := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return client(func(ctx context.Context, req interface{}) (interface{}, error) {
+					return req, nil
+				})(transport.NewClientContext(ctx, out), req)
+			}
+			_, err := Server(WithTracerProvider(tp), WithPropagator(test.propagator))(next)(transport.NewServerContext(context.Background(), in), "hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v := out.header.Get("traceparent"); len(v) < 36 || v[3:35] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Fatalf("want the trace id propagated, got %q", v)
+			}
+			if v := out.header.Get("baggage"); v != test.baggage {
+				t.Fatalf("want baggage %q, got %q", test.baggage, v)
+			}
+		})
+	}
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *mockServerStream) Context() context.Context { return s.ctx }
+
+func TestAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+	tenant := func(ctx context.Context, req interface{}) []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.String("tenant", "kratos")}
+	}
+	ctx := transport.NewServerContext(context.Background(), &mockTransport{
+		kind:      transport.KindGRPC,
+		operation: "/test.server/hello",
+		header:    headerCarrier{},
+	})
+
+	_, _ = Server(WithTracerProvider(tp), WithAttributes(tenant))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.BadRequest("INVALID_TENANT", "invalid tenant")
+	})(ctx, "hello")
+	err := StreamServerInterceptor(WithTracerProvider(tp), WithAttributes(tenant))(nil, &mockServerStream{ctx: ctx}, &grpc.StreamServerInfo{},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			if !trace.SpanContextFromContext(ss.Context()).IsValid() {
+				t.Fatal("no span in the stream context")
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("want 2 spans, got %d", len(spans))
+	}
+	for _, span := range spans {
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		if v := attrs["tenant"].AsString(); v != "kratos" {
+			t.Fatalf("want tenant kratos, got %q", v)
+		}
+		if v := attrs[semconv.RPCMethodKey].AsString(); v != "hello" {
+			t.Fatalf("want method hello, got %q", v)
+		}
+	}
+	if s := spans[0].Status(); s.Code != codes.Error || s.Description != "INVALID_TENANT" {
+		t.Fatalf("want the error status of the reason, got %v", s)
+	}
+	if s := spans[1].Status(); s.Code != codes.Ok {
+		t.Fatalf("want the ok status, got %v", s)
+	}
+}
+
+type mockClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *mockClientStream) RecvMsg(m interface{}) error { return io.EOF }
+
+func TestStreamClientInterceptor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+	carrier := headerCarrier{}
+	ctx := transport.NewClientContext(context.Background(), &mockTransport{
+		kind:      transport.KindGRPC,
+		operation: "/test.server/hello",
+		header:    carrier,
+	})
+	desc := &grpc.StreamDesc{ServerStreams: true}
+	cs, err := StreamClientInterceptor(WithTracerProvider(tp))(ctx, desc, nil, "/test.server/hello",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return &mockClientStream{}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if carrier.Get("traceparent") == "" {
+		t.Fatal("want the span context injected into the request header")
+	}
+	if len(recorder.Ended()) != 0 {
+		t.Fatal("the span should not end before the stream is finished")
+	}
+	if err = cs.RecvMsg(nil); err != io.EOF {
+		t.Fatalf("want io.EOF, got %v", err)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("want 1 span, got %d", len(spans))
+	}
+	if s := spans[0].Status(); s.Code != codes.Ok {
+		t.Fatalf("want the ok status, got %v", s)
+	}
+
+	_, err = StreamClientInterceptor(WithTracerProvider(tp))(ctx, desc, nil, "/test.server/hello",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+		})
+	if err == nil {
+		t.Fatal("want the error of the streamer")
+	}
+	spans = recorder.Ended()
+	if len(spans) != 2 || spans[1].Status().Code != codes.Error {
+		t.Fatalf("want the span of the failed stream ended with the error status, got %v", spans)
+	}
+}
@@ -2,25 +2,128 @@ package validate
 
 import (
 	"context"
+	"strings"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 )
 
+func init() {
+	middleware.Register("validate", func(*middleware.Config) (middleware.Middleware, error) {
+		return Validator(), nil
+	})
+}
+
 type validator interface {
 	Validate() error
 }
 
-// Validator is a validator middleware.
-func Validator() middleware.Middleware {
+// validatorAll is implemented by the messages generated by protoc-gen-validate
+// returning all the violations.
+type validatorAll interface {
+	ValidateAll() error
+}
+
+// multiError is the error of ValidateAll.
+type multiError interface {
+	AllErrors() []error
+}
+
+// fieldError is the violation of a field, e.g. the errors generated by
+// protoc-gen-validate.
+type fieldError interface {
+	Field() string
+	Reason() string
+}
+
+// Option is validate option.
+type Option func(*options)
+
+type options struct {
+	validators []func(ctx context.Context, req interface{}) error
+	all        bool
+}
+
+// WithValidator with the validator run after the generated validation passes,
+// e.g. the cross-field or the DB-backed validation. The errors implementing
+// Field() and Reason() are reported as the violations of the fields.
+func WithValidator(f func(ctx context.Context, req interface{}) error) Option {
+	return func(o *options) {
+		o.validators = append(o.validators, f)
+	}
+}
+
+// WithAllViolations with all the violations collected rather than the first
+// one, by ValidateAll of the generated messages and all the validators.
+func WithAllViolations() Option {
+	return func(o *options) {
+		o.all = true
+	}
+}
+
+// Validator is a validator middleware. The violations are packed into the
+// errdetails.BadRequest details of the error.
+func Validator(opts ...Option) middleware.Middleware {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
-			if v, ok := req.(validator); ok {
-				if err := v.Validate(); err != nil {
-					return nil, errors.BadRequest("VALIDATOR", err.Error()).WithCause(err)
-				}
+			if errs := o.validate(ctx, req); len(errs) > 0 {
+				return nil, violationError(errs)
 			}
 			return handler(ctx, req)
 		}
 	}
 }
+
+func (o *options) validate(ctx context.Context, req interface{}) []error {
+	var err error
+	if v, ok := req.(validatorAll); ok && o.all {
+		err = v.ValidateAll()
+	} else if v, ok := req.(validator); ok {
+		err = v.Validate()
+	}
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, f := range o.validators {
+		if err := f(ctx, req); err != nil {
+			errs = append(errs, err)
+			if !o.all {
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// violationError returns the BadRequest error of errs with the field violations.
+func violationError(errs []error) *errors.Error {
+	var (
+		msgs []string
+		br   = &errdetails.BadRequest{}
+	)
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+		violations := []error{err}
+		if me, ok := err.(multiError); ok {
+			violations = me.AllErrors()
+		}
+		for _, v := range violations {
+			fv := &errdetails.BadRequest_FieldViolation{Description: v.Error()}
+			if fe, ok := v.(fieldError); ok {
+				fv.Field, fv.Description = fe.Field(), fe.Reason()
+			}
+			br.FieldViolations = append(br.FieldViolations, fv)
+		}
+	}
+	e := errors.BadRequest("VALIDATOR", strings.Join(msgs, "; ")).WithDetails(br)
+	if len(errs) == 1 {
+		return e.WithCause(errs[0])
+	}
+	return e
+}
@@ -3,10 +3,12 @@ package validate
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 )
 
 // protoVali implement validate.validator
@@ -41,3 +43,88 @@ func TestTable(t *testing.T) {
 		})
 	}
 }
+
+type fieldErr struct {
+	field  string
+	reason string
+}
+
+func (e fieldErr) Field() string  { return e.field }
+func (e fieldErr) Reason() string { return e.reason }
+func (e fieldErr) Error() string  { return e.field + ": " + e.reason }
+
+type fieldErrs []error
+
+func (e fieldErrs) AllErrors() []error { return e }
+func (e fieldErrs) Error() string      { return fmt.Sprint([]error(e)) }
+
+// allVali implement validate.validatorAll
+type allVali struct {
+	errs fieldErrs
+}
+
+func (v allVali) Validate() error {
+	if len(v.errs) > 0 {
+		return v.errs[0]
+	}
+	return nil
+}
+
+func (v allVali) ValidateAll() error {
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+func TestWithValidator(t *testing.T) {
+	var mock middleware.Handler = func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	unique := WithValidator(func(ctx context.Context, req interface{}) error {
+		return fieldErr{"name", "already exists"}
+	})
+	adult := WithValidator(func(ctx context.Context, req interface{}) error {
+		return fieldErr{"age", "must be adult"}
+	})
+	invalid := fieldErrs{fieldErr{"name", "empty"}, fieldErr{"age", "negative"}}
+	tests := []struct {
+		name   string
+		opts   []Option
+		req    interface{}
+		fields []string
+	}{
+		{"valid", nil, allVali{}, nil},
+		{"generated first", []Option{unique}, allVali{invalid}, []string{"name"}},
+		{"generated all", []Option{unique, WithAllViolations()}, allVali{invalid}, []string{"name", "age"}},
+		{"validator first", []Option{unique, adult}, allVali{}, []string{"name"}},
+		{"validator all", []Option{unique, adult, WithAllViolations()}, allVali{}, []string{"name", "age"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Validator(test.opts...)(mock)(context.Background(), test.req)
+			if test.fields == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.IsBadRequest(err) {
+				t.Fatalf("want BadRequest, got %v", err)
+			}
+			details := errors.Details(err)
+			if len(details) != 1 {
+				t.Fatalf("want 1 detail, got %v", details)
+			}
+			br, ok := details[0].(*errdetails.BadRequest)
+			if !ok {
+				t.Fatalf("want BadRequest detail, got %T", details[0])
+			}
+			var fields []string
+			for _, v := range br.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+			if !reflect.DeepEqual(fields, test.fields) {
+				t.Fatalf("want %v, got %v", test.fields, fields)
+			}
+		})
+	}
+}
@@ -22,14 +22,27 @@ type options struct {
 	metadata  map[string]string
 	endpoints []*url.URL
 
-	ctx  context.Context
-	sigs []os.Signal
+	ctx       context.Context
+	sigs      []os.Signal
+	drainSigs []os.Signal
 
 	logger           *log.Helper
+	rawLogger        log.Logger
 	registrar        registry.Registrar
 	registrarTimeout time.Duration
+	heartbeat        time.Duration
+	heartbeatTTL     time.Duration
 	stopTimeout      time.Duration
 	servers          []transport.Server
+
+	beforeStart []func(context.Context) error
+	// teardown is the stop hook paired with the BeforeStart hook of the same
+	// index by StartStop, nil if unpaired.
+	teardown   []func(context.Context) error
+	afterStart []func(context.Context) error
+	beforeStop  []func(context.Context) error
+	afterStop   []func(context.Context) error
+	readiness   []func(context.Context) bool
 }
 
 // ID with service id.
@@ -66,6 +79,7 @@ func Context(ctx context.Context) Option {
 func Logger(logger log.Logger) Option {
 	return func(o *options) {
 		o.logger = log.NewHelper(logger)
+		o.rawLogger = logger
 	}
 }
 
@@ -79,6 +93,12 @@ func Signal(sigs ...os.Signal) Option {
 	return func(o *options) { o.sigs = sigs }
 }
 
+// DrainSignal with the signals toggling the drain of the app, see App.Drain,
+// default is SIGUSR1 except on windows.
+func DrainSignal(sigs ...os.Signal) Option {
+	return func(o *options) { o.drainSigs = sigs }
+}
+
 // Registrar with service registry.
 func Registrar(r registry.Registrar) Option {
 	return func(o *options) { o.registrar = r }
@@ -89,7 +109,61 @@ func RegistrarTimeout(t time.Duration) Option {
 	return func(o *options) { o.registrarTimeout = t }
 }
 
+// RegistrarHeartbeat with the interval the instance is registered again at,
+// so that the consumers can ignore it once the heartbeat lapses, e.g. when the
+// process is killed without deregistering. The ttl is 3 intervals if it is zero.
+func RegistrarHeartbeat(interval, ttl time.Duration) Option {
+	return func(o *options) {
+		o.heartbeat = interval
+		o.heartbeatTTL = ttl
+	}
+}
+
 // StopTimeout with app stop timeout.
 func StopTimeout(t time.Duration) Option {
 	return func(o *options) { o.stopTimeout = t }
 }
+
+// BeforeStart with the hook run in order before the servers start. If it
+// fails, the startup is aborted, and the stop hooks paired by StartStop with
+// the completed BeforeStart hooks run in reverse order.
+func BeforeStart(fn func(context.Context) error) Option {
+	return func(o *options) {
+		o.beforeStart = append(o.beforeStart, fn)
+		o.teardown = append(o.teardown, nil)
+	}
+}
+
+// StartStop with start run as a BeforeStart hook and stop as an AfterStop one,
+// stop also tears down what start set up if a later BeforeStart hook fails.
+func StartStop(start, stop func(context.Context) error) Option {
+	return func(o *options) {
+		o.beforeStart = append(o.beforeStart, start)
+		o.teardown = append(o.teardown, stop)
+		o.afterStop = append(o.afterStop, stop)
+	}
+}
+
+// AfterStart with the hook run in order after the servers start and the
+// instance is registered, the app is stopped if it fails.
+func AfterStart(fn func(context.Context) error) Option {
+	return func(o *options) { o.afterStart = append(o.afterStart, fn) }
+}
+
+// BeforeStop with the hook run in order before the instance is deregistered
+// and the servers stop.
+func BeforeStop(fn func(context.Context) error) Option {
+	return func(o *options) { o.beforeStop = append(o.beforeStop, fn) }
+}
+
+// AfterStop with the hook run in reverse order after the servers stop.
+func AfterStop(fn func(context.Context) error) Option {
+	return func(o *options) { o.afterStop = append(o.afterStop, fn) }
+}
+
+// Readiness with the check that must pass before the app registers itself, it
+// is polled after the servers start until all the checks pass, e.g. the caches
+// are warmed up. App.Ready reports the readiness status for health endpoints.
+func Readiness(fn func(context.Context) bool) Option {
+	return func(o *options) { o.readiness = append(o.readiness, fn) }
+}
@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	// HeartbeatKey is the metadata key of the unix time in seconds of the
+	// last heartbeat of the instance.
+	HeartbeatKey = "kratos.heartbeat"
+	// TTLKey is the metadata key of the seconds the heartbeat of the instance
+	// lapses in.
+	TTLKey = "kratos.ttl"
+)
+
+// WithHeartbeat returns a copy of the instance with the heartbeat at now
+// and the ttl in its metadata.
+func WithHeartbeat(in *ServiceInstance, now time.Time, ttl time.Duration) *ServiceInstance {
+	out := *in
+	out.Metadata = make(map[string]string, len(in.Metadata)+2)
+	for k, v := range in.Metadata {
+		out.Metadata[k] = v
+	}
+	out.Metadata[HeartbeatKey] = strconv.FormatInt(now.Unix(), 10)
+	out.Metadata[TTLKey] = strconv.FormatInt(int64(ttl/time.Second), 10)
+	return &out
+}
+
+// Alive reports whether the heartbeat of the instance has not lapsed at now,
+// the instances without a valid heartbeat in their metadata are alive.
+func Alive(in *ServiceInstance, now time.Time) bool {
+	deadline, ok := heartbeatDeadline(in)
+	return !ok || now.Unix() <= deadline
+}
+
+// Lapse returns the earliest time the heartbeat of an instance alive at now
+// lapses, false if none of the alive instances has a valid heartbeat.
+func Lapse(ins []*ServiceInstance, now time.Time) (time.Time, bool) {
+	var (
+		lapse time.Time
+		found bool
+	)
+	for _, in := range ins {
+		deadline, ok := heartbeatDeadline(in)
+		if !ok || now.Unix() > deadline {
+			continue
+		}
+		// the heartbeat lapses once the deadline second has passed
+		if at := time.Unix(deadline+1, 0); !found || at.Before(lapse) {
+			lapse, found = at, true
+		}
+	}
+	return lapse, found
+}
+
+// heartbeatDeadline returns the unix second the heartbeat of the instance is
+// valid until.
+func heartbeatDeadline(in *ServiceInstance) (int64, bool) {
+	heartbeat, err := strconv.ParseInt(in.Metadata[HeartbeatKey], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	ttl, err := strconv.ParseInt(in.Metadata[TTLKey], 10, 64)
+	if err != nil || ttl <= 0 {
+		return 0, false
+	}
+	return heartbeat + ttl, true
+}
@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlive(t *testing.T) {
+	now := time.Unix(1000, 0)
+	in := &ServiceInstance{ID: "1", Metadata: map[string]string{"region": "sh"}}
+	hb := WithHeartbeat(in, now, 30*time.Second)
+	if _, ok := in.Metadata[HeartbeatKey]; ok {
+		t.Fatal("the instance should not be modified")
+	}
+	if hb.Metadata["region"] != "sh" {
+		t.Fatalf("the metadata should be kept, got %v", hb.Metadata)
+	}
+	tests := []struct {
+		name string
+		in   *ServiceInstance
+		now  time.Time
+		want bool
+	}{
+		{"no heartbeat", in, now.Add(time.Hour), true},
+		{"alive", hb, now.Add(30 * time.Second), true},
+		{"lapsed", hb, now.Add(31 * time.Second), false},
+		{"invalid", &ServiceInstance{Metadata: map[string]string{HeartbeatKey: "x", TTLKey: "1"}}, now, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Alive(test.in, test.now); got != test.want {
+				t.Fatalf("want %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestLapse(t *testing.T) {
+	now := time.Unix(1000, 0)
+	in := &ServiceInstance{ID: "1"}
+	ins := []*ServiceInstance{
+		in,
+		WithHeartbeat(in, now.Add(-time.Minute), 30*time.Second),
+		WithHeartbeat(in, now, 30*time.Second),
+		WithHeartbeat(in, now, 10*time.Second),
+	}
+	lapse, ok := Lapse(ins, now)
+	if !ok {
+		t.Fatal("the lapse should be found")
+	}
+	if want := time.Unix(1011, 0); !lapse.Equal(want) {
+		t.Fatalf("want %v, got %v", want, lapse)
+	}
+	if Alive(ins[3], lapse) || !Alive(ins[3], lapse.Add(-time.Nanosecond)) {
+		t.Fatal("the instance should lapse at the lapse time")
+	}
+	if _, ok := Lapse(ins[:2], now); ok {
+		t.Fatal("the lapsed instances and the ones without heartbeat should not lapse")
+	}
+}
@@ -0,0 +1,229 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watchRetryDelay is the delay before a failed watcher of MultiDiscovery is retried.
+const watchRetryDelay = time.Second
+
+var (
+	_ Registrar = (*MultiRegistrar)(nil)
+	_ Discovery = (*MultiDiscovery)(nil)
+)
+
+// Policy is the policy of the partial failures of the registrars.
+type Policy int
+
+const (
+	// PolicyAll fails if any registrar fails, the instance is deregistered
+	// from the others on a failed registration.
+	PolicyAll Policy = iota
+	// PolicyBestEffort fails only if all the registrars fail.
+	PolicyBestEffort
+)
+
+// multiError is the errors of the backends.
+type multiError []error
+
+func (e multiError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return "registry: " + strings.Join(msgs, "; ")
+}
+
+// MultiRegistrar is a registrar fanning out to the registrars, e.g. to
+// register to both the old and the new registries during a migration.
+type MultiRegistrar struct {
+	registrars []Registrar
+	policy     Policy
+}
+
+// NewMultiRegistrar new a registrar fanning out to the registrars.
+func NewMultiRegistrar(policy Policy, registrars ...Registrar) *MultiRegistrar {
+	return &MultiRegistrar{
+		registrars: registrars,
+		policy:     policy,
+	}
+}
+
+// Register the registration to all the registrars.
+func (r *MultiRegistrar) Register(ctx context.Context, service *ServiceInstance) error {
+	var (
+		errs       multiError
+		registered []Registrar
+	)
+	for _, registrar := range r.registrars {
+		if err := registrar.Register(ctx, service); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		registered = append(registered, registrar)
+	}
+	if len(errs) == 0 || (r.policy == PolicyBestEffort && len(registered) > 0) {
+		return nil
+	}
+	for _, registrar := range registered {
+		_ = registrar.Deregister(ctx, service)
+	}
+	return errs
+}
+
+// Deregister the registration from all the registrars.
+func (r *MultiRegistrar) Deregister(ctx context.Context, service *ServiceInstance) error {
+	var errs multiError
+	for _, registrar := range r.registrars {
+		if err := registrar.Deregister(ctx, service); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 || (r.policy == PolicyBestEffort && len(errs) < len(r.registrars)) {
+		return nil
+	}
+	return errs
+}
+
+// MultiDiscovery is a discovery merging the instances of the discoveries,
+// the instances are deduplicated by ID in the order of the discoveries.
+// It fails only if all the discoveries fail.
+type MultiDiscovery struct {
+	discoveries []Discovery
+}
+
+// NewMultiDiscovery new a discovery merging the discoveries.
+func NewMultiDiscovery(discoveries ...Discovery) *MultiDiscovery {
+	return &MultiDiscovery{discoveries: discoveries}
+}
+
+// GetService return the merged service instances of the discoveries.
+func (d *MultiDiscovery) GetService(ctx context.Context, serviceName string) ([]*ServiceInstance, error) {
+	var (
+		errs  multiError
+		lists [][]*ServiceInstance
+	)
+	for _, discovery := range d.discoveries {
+		ins, err := discovery.GetService(ctx, serviceName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		lists = append(lists, ins)
+	}
+	if len(errs) > 0 && len(errs) == len(d.discoveries) {
+		return nil, errs
+	}
+	return mergeInstances(lists), nil
+}
+
+// Watch creates a watcher of the merged service instances, a change in any
+// discovery is returned by Next.
+func (d *MultiDiscovery) Watch(ctx context.Context, serviceName string) (Watcher, error) {
+	var (
+		errs     multiError
+		watchers []Watcher
+	)
+	for _, discovery := range d.discoveries {
+		w, err := discovery.Watch(ctx, serviceName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		watchers = append(watchers, w)
+	}
+	if len(errs) > 0 && len(errs) == len(d.discoveries) {
+		return nil, errs
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w := &multiWatcher{
+		ctx:      ctx,
+		cancel:   cancel,
+		watchers: watchers,
+		latest:   make([][]*ServiceInstance, len(watchers)),
+		updated:  make(chan struct{}, 1),
+	}
+	for i, watcher := range watchers {
+		go w.watch(i, watcher)
+	}
+	return w, nil
+}
+
+type multiWatcher struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	watchers []Watcher
+
+	mu      sync.Mutex
+	latest  [][]*ServiceInstance
+	updated chan struct{}
+}
+
+func (w *multiWatcher) watch(i int, watcher Watcher) {
+	for {
+		ins, err := watcher.Next()
+		if w.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-time.After(watchRetryDelay):
+			}
+			continue
+		}
+		w.mu.Lock()
+		w.latest[i] = ins
+		w.mu.Unlock()
+		select {
+		case w.updated <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *multiWatcher) Next() ([]*ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case <-w.updated:
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return mergeInstances(w.latest), nil
+}
+
+func (w *multiWatcher) Stop() error {
+	w.cancel()
+	var errs multiError
+	for _, watcher := range w.watchers {
+		if err := watcher.Stop(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func mergeInstances(lists [][]*ServiceInstance) []*ServiceInstance {
+	var (
+		merged []*ServiceInstance
+		seen   = make(map[string]struct{})
+	)
+	for _, ins := range lists {
+		for _, in := range ins {
+			if _, ok := seen[in.ID]; ok {
+				continue
+			}
+			seen[in.ID] = struct{}{}
+			merged = append(merged, in)
+		}
+	}
+	return merged
+}
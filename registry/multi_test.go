@@ -0,0 +1,172 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+var errBackend = errors.New("backend failed")
+
+type testBackend struct {
+	fail       bool
+	registered map[string]*ServiceInstance
+	updates    chan []*ServiceInstance
+}
+
+func newTestBackend(fail bool) *testBackend {
+	return &testBackend{
+		fail:       fail,
+		registered: make(map[string]*ServiceInstance),
+		updates:    make(chan []*ServiceInstance, 1),
+	}
+}
+
+func (b *testBackend) Register(ctx context.Context, service *ServiceInstance) error {
+	if b.fail {
+		return errBackend
+	}
+	b.registered[service.ID] = service
+	return nil
+}
+
+func (b *testBackend) Deregister(ctx context.Context, service *ServiceInstance) error {
+	if b.fail {
+		return errBackend
+	}
+	delete(b.registered, service.ID)
+	return nil
+}
+
+func (b *testBackend) GetService(ctx context.Context, serviceName string) ([]*ServiceInstance, error) {
+	if b.fail {
+		return nil, errBackend
+	}
+	var ins []*ServiceInstance
+	for _, in := range b.registered {
+		ins = append(ins, in)
+	}
+	sort.Slice(ins, func(i, j int) bool { return ins[i].ID < ins[j].ID })
+	return ins, nil
+}
+
+func (b *testBackend) Watch(ctx context.Context, serviceName string) (Watcher, error) {
+	if b.fail {
+		return nil, errBackend
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &testWatcher{ctx: ctx, cancel: cancel, updates: b.updates}, nil
+}
+
+type testWatcher struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	updates chan []*ServiceInstance
+}
+
+func (w *testWatcher) Next() ([]*ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case ins := <-w.updates:
+		return ins, nil
+	}
+}
+
+func (w *testWatcher) Stop() error {
+	w.cancel()
+	return nil
+}
+
+func TestMultiRegistrar(t *testing.T) {
+	service := &ServiceInstance{ID: "1", Name: "helloworld"}
+	tests := []struct {
+		name    string
+		policy  Policy
+		fails   []bool
+		wantErr bool
+		want    []bool
+	}{
+		{"all", PolicyAll, []bool{false, false}, false, []bool{true, true}},
+		{"all partial", PolicyAll, []bool{false, true}, true, []bool{false, false}},
+		{"best effort partial", PolicyBestEffort, []bool{false, true}, false, []bool{true, false}},
+		{"best effort failed", PolicyBestEffort, []bool{true, true}, true, []bool{false, false}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				backends   []*testBackend
+				registrars []Registrar
+			)
+			for _, fail := range test.fails {
+				b := newTestBackend(fail)
+				backends = append(backends, b)
+				registrars = append(registrars, b)
+			}
+			r := NewMultiRegistrar(test.policy, registrars...)
+			if err := r.Register(context.Background(), service); (err != nil) != test.wantErr {
+				t.Fatalf("want error %v, got %v", test.wantErr, err)
+			}
+			for i, b := range backends {
+				if _, ok := b.registered[service.ID]; ok != test.want[i] {
+					t.Fatalf("backend %d: want registered %v, got %v", i, test.want[i], ok)
+				}
+			}
+			if err := r.Deregister(context.Background(), service); (err != nil) != test.wantErr {
+				t.Fatalf("want error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMultiDiscovery(t *testing.T) {
+	consul, etcd, down := newTestBackend(false), newTestBackend(false), newTestBackend(true)
+	consul.registered["1"] = &ServiceInstance{ID: "1", Version: "consul"}
+	etcd.registered["1"] = &ServiceInstance{ID: "1", Version: "etcd"}
+	etcd.registered["2"] = &ServiceInstance{ID: "2", Version: "etcd"}
+	d := NewMultiDiscovery(consul, etcd, down)
+
+	ins, err := d.GetService(context.Background(), "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ServiceInstance{consul.registered["1"], etcd.registered["2"]}
+	if !reflect.DeepEqual(ins, want) {
+		t.Fatalf("want %v, got %v", want, ins)
+	}
+	if _, err = NewMultiDiscovery(down).GetService(context.Background(), "helloworld"); err == nil {
+		t.Fatal("want error if all the discoveries fail")
+	}
+
+	w, err := d.Watch(context.Background(), "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	consul.updates <- []*ServiceInstance{{ID: "1"}}
+	if ins, err = w.Next(); err != nil || len(ins) != 1 {
+		t.Fatalf("want 1 instance, got %v %v", ins, err)
+	}
+	etcd.updates <- []*ServiceInstance{{ID: "1"}, {ID: "3"}}
+	if ins, err = w.Next(); err != nil || len(ins) != 2 || ins[1].ID != "3" {
+		t.Fatalf("want the merged instances, got %v %v", ins, err)
+	}
+	if err = w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		_, err = w.Next()
+		close(done)
+	}()
+	select {
+	case <-done:
+		if err == nil {
+			t.Fatal("want error after stop")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Next should return after stop")
+	}
+}
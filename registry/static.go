@@ -0,0 +1,167 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	_ Registrar = (*Static)(nil)
+	_ Discovery = (*Static)(nil)
+)
+
+// ErrWatcherStopped is returned by Next of a stopped watcher.
+var ErrWatcherStopped = errors.New("registry: watcher stopped")
+
+// Static is an in-memory registry of a mutable set of instances, e.g. for tests.
+type Static struct {
+	mu        sync.RWMutex
+	instances []*ServiceInstance
+	watchers  map[*staticWatcher]struct{}
+}
+
+// NewStatic new an in-memory registry of the instances.
+func NewStatic(instances ...*ServiceInstance) *Static {
+	s := &Static{watchers: make(map[*staticWatcher]struct{})}
+	s.Add(instances...)
+	return s
+}
+
+// Add adds the instances, the ones of the same IDs are replaced, and notifies
+// the watchers of their services.
+func (s *Static) Add(instances ...*ServiceInstance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make(map[string]struct{}, len(instances))
+	for _, in := range instances {
+		names[in.Name] = struct{}{}
+		replaced := false
+		for i, old := range s.instances {
+			if old.ID == in.ID {
+				names[old.Name] = struct{}{}
+				s.instances[i] = in
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.instances = append(s.instances, in)
+		}
+	}
+	s.notify(names)
+}
+
+// Remove removes the instances by their IDs and notifies the watchers of their services.
+func (s *Static) Remove(instances ...*ServiceInstance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make(map[string]struct{}, len(instances))
+	for _, in := range instances {
+		ids[in.ID] = struct{}{}
+	}
+	names := make(map[string]struct{}, len(instances))
+	kept := s.instances[:0]
+	for _, in := range s.instances {
+		if _, ok := ids[in.ID]; ok {
+			names[in.Name] = struct{}{}
+			continue
+		}
+		kept = append(kept, in)
+	}
+	for i := len(kept); i < len(s.instances); i++ {
+		s.instances[i] = nil
+	}
+	s.instances = kept
+	s.notify(names)
+}
+
+// Register adds the instance.
+func (s *Static) Register(ctx context.Context, service *ServiceInstance) error {
+	s.Add(service)
+	return nil
+}
+
+// Deregister removes the instance.
+func (s *Static) Deregister(ctx context.Context, service *ServiceInstance) error {
+	s.Remove(service)
+	return nil
+}
+
+// GetService return the instances of the service.
+func (s *Static) GetService(ctx context.Context, serviceName string) ([]*ServiceInstance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.service(serviceName), nil
+}
+
+// Watch creates a watcher of the service, the instances are returned by Next
+// every time they are changed by Add or Remove.
+func (s *Static) Watch(ctx context.Context, serviceName string) (Watcher, error) {
+	w := &staticWatcher{
+		ctx:     ctx,
+		static:  s,
+		name:    serviceName,
+		updated: make(chan struct{}, 1),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchers[w] = struct{}{}
+	if len(s.service(serviceName)) > 0 {
+		w.updated <- struct{}{}
+	}
+	return w, nil
+}
+
+func (s *Static) service(name string) []*ServiceInstance {
+	var ins []*ServiceInstance
+	for _, in := range s.instances {
+		if in.Name == name {
+			ins = append(ins, in)
+		}
+	}
+	return ins
+}
+
+func (s *Static) notify(names map[string]struct{}) {
+	for w := range s.watchers {
+		if _, ok := names[w.name]; !ok {
+			continue
+		}
+		select {
+		case w.updated <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type staticWatcher struct {
+	ctx     context.Context
+	static  *Static
+	name    string
+	updated chan struct{}
+	once    sync.Once
+}
+
+func (w *staticWatcher) Next() ([]*ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case _, ok := <-w.updated:
+		if !ok {
+			return nil, ErrWatcherStopped
+		}
+	}
+	return w.static.GetService(w.ctx, w.name)
+}
+
+// Stop removes the watcher and closes its channel.
+func (w *staticWatcher) Stop() error {
+	w.once.Do(func() {
+		w.static.mu.Lock()
+		defer w.static.mu.Unlock()
+		delete(w.static.watchers, w)
+		close(w.updated)
+	})
+	return nil
+}
@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStatic(t *testing.T) {
+	s := NewStatic(
+		&ServiceInstance{ID: "1", Name: "helloworld"},
+		&ServiceInstance{ID: "2", Name: "other"},
+	)
+	ins, err := s.GetService(context.Background(), "helloworld")
+	if err != nil || len(ins) != 1 || ins[0].ID != "1" {
+		t.Fatalf("want instance 1, got %v %v", ins, err)
+	}
+
+	w, err := s.Watch(context.Background(), "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ins, err = w.Next(); err != nil || len(ins) != 1 {
+		t.Fatalf("want the initial instances, got %v %v", ins, err)
+	}
+	if err = s.Register(context.Background(), &ServiceInstance{ID: "3", Name: "helloworld"}); err != nil {
+		t.Fatal(err)
+	}
+	if ins, err = w.Next(); err != nil || len(ins) != 2 {
+		t.Fatalf("want 2 instances, got %v %v", ins, err)
+	}
+	s.Remove(&ServiceInstance{ID: "1"})
+	if ins, err = w.Next(); err != nil || len(ins) != 1 || ins[0].ID != "3" {
+		t.Fatalf("want instance 3, got %v %v", ins, err)
+	}
+
+	// the changes of the other services are not notified
+	s.Add(&ServiceInstance{ID: "4", Name: "other"})
+	next := make(chan error, 1)
+	go func() {
+		_, err := w.Next()
+		next <- err
+	}()
+	select {
+	case err = <-next:
+		t.Fatalf("Next should block, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err = w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err = <-next:
+		if err != ErrWatcherStopped {
+			t.Fatalf("want ErrWatcherStopped, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Next should return after stop")
+	}
+}
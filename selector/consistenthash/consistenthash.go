@@ -0,0 +1,188 @@
+package consistenthash
+
+import (
+	"context"
+	"hash/crc32"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/selector/node/direct"
+)
+
+const (
+	// Name is consistent hash balancer name
+	Name = "consistenthash"
+
+	defaultReplicas = 160
+)
+
+var _ selector.Balancer = &Balancer{}
+
+// WithFilter with select filters
+func WithFilter(filters ...selector.Filter) Option {
+	return func(o *options) {
+		o.filters = filters
+	}
+}
+
+// WithStats with the stats collector of the picks, see selector.Stats.
+func WithStats(stats *selector.Stats) Option {
+	return func(o *options) {
+		o.stats = stats
+	}
+}
+
+// WithKey with the func extracting the hash key of the request,
+// requests without a key are balanced randomly.
+func WithKey(key func(ctx context.Context) string) Option {
+	return func(o *options) {
+		o.key = key
+	}
+}
+
+// WithReplicas with the number of virtual nodes of every node on the ring.
+func WithReplicas(n int) Option {
+	return func(o *options) {
+		o.replicas = n
+	}
+}
+
+// Option is consistent hash builder option.
+type Option func(o *options)
+
+// options is consistent hash builder options
+type options struct {
+	filters  []selector.Filter
+	key      func(ctx context.Context) string
+	replicas int
+	stats    *selector.Stats
+}
+
+// Balancer is a consistent hash balancer with virtual nodes.
+type Balancer struct {
+	key      func(ctx context.Context) string
+	replicas int
+
+	mu   sync.RWMutex
+	ring *ring
+}
+
+// ring is the hash ring of a set of nodes.
+type ring struct {
+	id     string
+	hashes []uint32
+	owners map[uint32]string
+}
+
+// New creates a consistent hash selector.
+func New(opts ...Option) selector.Selector {
+	return NewBuilder(opts...).Build()
+}
+
+// Pick pick the node of the hash key.
+func (b *Balancer) Pick(ctx context.Context, nodes []selector.WeightedNode) (selector.WeightedNode, selector.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, selector.ErrNoAvailable
+	}
+	var key string
+	if b.key != nil {
+		key = b.key(ctx)
+	}
+	if key == "" {
+		selected := nodes[rand.Intn(len(nodes))]
+		return selected, selected.Pick(), nil
+	}
+	addr := b.getRing(nodes).lookup(key)
+	for _, n := range nodes {
+		if n.Address() == addr {
+			return n, n.Pick(), nil
+		}
+	}
+	return nil, nil, selector.ErrNoAvailable
+}
+
+// getRing returns the ring of nodes, it is rebuilt only if the nodes changed.
+func (b *Balancer) getRing(nodes []selector.WeightedNode) *ring {
+	addrs := make([]string, len(nodes))
+	for i, n := range nodes {
+		addrs[i] = n.Address()
+	}
+	sort.Strings(addrs)
+	id := strings.Join(addrs, ",")
+	b.mu.RLock()
+	r := b.ring
+	b.mu.RUnlock()
+	if r != nil && r.id == id {
+		return r
+	}
+	r = newRing(id, addrs, b.replicas)
+	b.mu.Lock()
+	b.ring = r
+	b.mu.Unlock()
+	return r
+}
+
+func newRing(id string, addrs []string, replicas int) *ring {
+	r := &ring{
+		id:     id,
+		hashes: make([]uint32, 0, len(addrs)*replicas),
+		owners: make(map[uint32]string, len(addrs)*replicas),
+	}
+	for _, addr := range addrs {
+		for i := 0; i < replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(addr + "#" + strconv.Itoa(i)))
+			if _, ok := r.owners[h]; ok {
+				continue
+			}
+			r.owners[h] = addr
+			r.hashes = append(r.hashes, h)
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+func (r *ring) lookup(key string) string {
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
+
+// NewBuilder returns a selector builder with consistent hash balancer
+func NewBuilder(opts ...Option) selector.Builder {
+	option := options{replicas: defaultReplicas}
+	for _, opt := range opts {
+		opt(&option)
+	}
+	if option.replicas <= 0 {
+		option.replicas = defaultReplicas
+	}
+	return &selector.DefaultBuilder{
+		Filters:  option.filters,
+		Stats:    option.stats,
+		Balancer: &Builder{Key: option.key, Replicas: option.replicas},
+		Node:     &direct.Builder{},
+	}
+}
+
+// Builder is consistent hash builder
+type Builder struct {
+	Key      func(ctx context.Context) string
+	Replicas int
+}
+
+// Build creates Balancer
+func (b *Builder) Build() selector.Balancer {
+	replicas := b.Replicas
+	if replicas <= 0 {
+		replicas = defaultReplicas
+	}
+	return &Balancer{key: b.Key, replicas: replicas}
+}
@@ -0,0 +1,73 @@
+package consistenthash
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+type shardKey struct{}
+
+func newNodes(n int) []selector.Node {
+	nodes := make([]selector.Node, 0, n)
+	for i := 0; i < n; i++ {
+		addr := fmt.Sprintf("127.0.0.%d:8080", i)
+		nodes = append(nodes, selector.NewNode("http", addr, &registry.ServiceInstance{ID: addr}))
+	}
+	return nodes
+}
+
+func TestConsistentHash(t *testing.T) {
+	s := New(WithKey(func(ctx context.Context) string {
+		key, _ := ctx.Value(shardKey{}).(string)
+		return key
+	}))
+	s.Apply(newNodes(5))
+
+	pick := func(key string) string {
+		n, done, err := s.Select(context.WithValue(context.Background(), shardKey{}, key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(context.Background(), selector.DoneInfo{})
+		return n.Address()
+	}
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("shard-%d", i)
+		before[key] = pick(key)
+		if pick(key) != before[key] {
+			t.Fatalf("key %s should always pick the same node", key)
+		}
+	}
+
+	// a node joins, only the keys moved to it change
+	s.Apply(newNodes(6))
+	moved := 0
+	for key, addr := range before {
+		if now := pick(key); now != addr {
+			if now != "127.0.0.5:8080" {
+				t.Fatalf("key %s moved from %s to %s", key, addr, now)
+			}
+			moved++
+		}
+	}
+	if moved == 0 || moved > 400 {
+		t.Fatalf("unexpected number of moved keys: %d", moved)
+	}
+
+	// no key falls back to random
+	if _, _, err := s.Select(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	b := (&Builder{}).Build()
+	if _, _, err := b.Pick(context.Background(), nil); err != selector.ErrNoAvailable {
+		t.Fatalf("want ErrNoAvailable, got %v", err)
+	}
+}
@@ -56,8 +56,10 @@ func NewNode(scheme, addr string, ins *registry.ServiceInstance) Node {
 		n.name = ins.Name
 		n.version = ins.Version
 		n.metadata = ins.Metadata
+		// the weight is left nil to use the default weight of the node
+		// builder if it is absent or not positive
 		if str, ok := ins.Metadata["weight"]; ok {
-			if weight, err := strconv.ParseInt(str, 10, 64); err == nil {
+			if weight, err := strconv.ParseInt(str, 10, 64); err == nil && weight > 0 {
 				n.weight = &weight
 			}
 		}
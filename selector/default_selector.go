@@ -10,6 +10,8 @@ type Default struct {
 	NodeBuilder WeightedNodeBuilder
 	Balancer    Balancer
 	Filters     []Filter
+	// Stats collects the stats of the picks if it is not nil.
+	Stats *Stats
 
 	nodes atomic.Value
 }
@@ -20,6 +22,11 @@ func (d *Default) Select(ctx context.Context, opts ...SelectOption) (selected No
 		options    SelectOptions
 		candidates []WeightedNode
 	)
+	p, hasPeer := FromPeerContext(ctx)
+	if hasPeer {
+		// the node of a previous pick, e.g. of a retried call, is stale
+		p.set(nil)
+	}
 	nodes, ok := d.nodes.Load().([]WeightedNode)
 	if !ok {
 		return nil, nil, ErrNoAvailable
@@ -27,7 +34,8 @@ func (d *Default) Select(ctx context.Context, opts ...SelectOption) (selected No
 	for _, o := range opts {
 		o(&options)
 	}
-	if len(d.Filters) > 0 || len(options.Filters) > 0 {
+	ctxFilters := filtersFromContext(ctx)
+	if len(d.Filters) > 0 || len(options.Filters) > 0 || len(ctxFilters) > 0 {
 		newNodes := make([]Node, len(nodes))
 		for i, wc := range nodes {
 			newNodes[i] = wc
@@ -38,6 +46,9 @@ func (d *Default) Select(ctx context.Context, opts ...SelectOption) (selected No
 		for _, f := range options.Filters {
 			newNodes = f(ctx, newNodes)
 		}
+		for _, f := range ctxFilters {
+			newNodes = f(ctx, newNodes)
+		}
 		candidates = make([]WeightedNode, len(newNodes))
 		for i, n := range newNodes {
 			candidates[i] = n.(WeightedNode)
@@ -53,6 +64,12 @@ func (d *Default) Select(ctx context.Context, opts ...SelectOption) (selected No
 	if err != nil {
 		return nil, nil, err
 	}
+	if hasPeer {
+		p.set(wn.Raw())
+	}
+	if d.Stats != nil {
+		done = d.Stats.pick(wn.Raw(), done)
+	}
 	return wn.Raw(), done, nil
 }
 
@@ -71,6 +88,8 @@ type DefaultBuilder struct {
 	Node     WeightedNodeBuilder
 	Balancer BalancerBuilder
 	Filters  []Filter
+	// Stats collects the stats of the picks of the built selectors if it is not nil.
+	Stats *Stats
 }
 
 // Build create builder
@@ -79,5 +98,6 @@ func (db *DefaultBuilder) Build() Selector {
 		NodeBuilder: db.Node,
 		Balancer:    db.Balancer.Build(),
 		Filters:     db.Filters,
+		Stats:       db.Stats,
 	}
 }
@@ -0,0 +1,37 @@
+package filter
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+// Metadata is metadata filter, it keeps the nodes whose metadata key has the value.
+func Metadata(key, value string) selector.Filter {
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		newNodes := nodes[:0]
+		for _, n := range nodes {
+			if v, ok := n.Metadata()[key]; ok && v == value {
+				newNodes = append(newNodes, n)
+			}
+		}
+		return newNodes
+	}
+}
+
+// Fallback composes filters in order, all nodes are kept if the filters
+// filter out every node.
+func Fallback(filters ...selector.Filter) selector.Filter {
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		// the filters may filter in place
+		newNodes := make([]selector.Node, len(nodes))
+		copy(newNodes, nodes)
+		for _, f := range filters {
+			newNodes = f(ctx, newNodes)
+		}
+		if len(newNodes) == 0 {
+			return nodes
+		}
+		return newNodes
+	}
+}
@@ -0,0 +1,48 @@
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+func testNodes() []selector.Node {
+	return []selector.Node{
+		selector.NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{
+			ID:       "127.0.0.1:9090",
+			Version:  "v1.0.0",
+			Metadata: map[string]string{"zone": "a"},
+		}),
+		selector.NewNode("http", "127.0.0.2:9090", &registry.ServiceInstance{
+			ID:       "127.0.0.2:9090",
+			Version:  "v2.0.0",
+			Metadata: map[string]string{"zone": "b"},
+		}),
+		selector.NewNode("http", "127.0.0.3:9090", &registry.ServiceInstance{
+			ID:       "127.0.0.3:9090",
+			Version:  "v2.0.0",
+			Metadata: map[string]string{"zone": "a"},
+		}),
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	nodes := Metadata("zone", "a")(context.Background(), testNodes())
+	if len(nodes) != 2 || nodes[0].Address() != "127.0.0.1:9090" || nodes[1].Address() != "127.0.0.3:9090" {
+		t.Fatalf("unexpected nodes: %v", nodes)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	nodes := Fallback(Version("v2.0.0"), Metadata("zone", "a"))(context.Background(), testNodes())
+	if len(nodes) != 1 || nodes[0].Address() != "127.0.0.3:9090" {
+		t.Fatalf("unexpected nodes: %v", nodes)
+	}
+	all := testNodes()
+	nodes = Fallback(Version("v3.0.0"))(context.Background(), all)
+	if len(nodes) != 3 || nodes[0].Address() != all[0].Address() || nodes[2].Address() != all[2].Address() {
+		t.Fatalf("all nodes should be kept, got %v", nodes)
+	}
+}
@@ -68,3 +68,22 @@ func TestDirectDefaultWeight(t *testing.T) {
 		t.Errorf("time.Millisecond*5 >= wn.PickElapsed()(%s)", wn.PickElapsed())
 	}
 }
+
+func TestDirectInvalidWeight(t *testing.T) {
+	b := &Builder{}
+	for _, weight := range []string{"0", "-10", "abc", ""} {
+		wn := b.Build(selector.NewNode(
+			"http",
+			"127.0.0.1:9090",
+			&registry.ServiceInstance{
+				ID:       "127.0.0.1:9090",
+				Metadata: map[string]string{"weight": weight, "region": "sh"},
+			}))
+		if !reflect.DeepEqual(float64(100), wn.Weight()) {
+			t.Errorf("weight %q: expect %v, got %v", weight, float64(100), wn.Weight())
+		}
+		if wn.Raw().Metadata()["region"] != "sh" {
+			t.Errorf("expect the metadata of the instance, got %v", wn.Raw().Metadata())
+		}
+	}
+}
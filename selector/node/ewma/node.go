@@ -42,13 +42,17 @@ type Node struct {
 	// last lastPick timestamp
 	lastPick int64
 
-	errHandler func(err error) (isErr bool)
-	lk         sync.RWMutex
+	errHandler   func(err error) (isErr bool)
+	successDecay int64
+	lk           sync.RWMutex
 }
 
 // Builder is ewma node builder.
 type Builder struct {
 	ErrHandler func(err error) (isErr bool)
+	// SuccessDecay is the mean lifetime of the success rate, a smaller one
+	// avoids and recovers erroring nodes faster. The default is the one of lag.
+	SuccessDecay time.Duration
 }
 
 // Build create a weighted node.
@@ -61,6 +65,10 @@ func (b *Builder) Build(n selector.Node) selector.WeightedNode {
 		inflights:  list.New(),
 		errHandler: b.ErrHandler,
 	}
+	s.successDecay = tau
+	if b.SuccessDecay > 0 {
+		s.successDecay = int64(b.SuccessDecay)
+	}
 	return s
 }
 
@@ -140,6 +148,7 @@ func (n *Node) Pick() selector.DoneFunc {
 			td = 0
 		}
 		w := math.Exp(float64(-td) / float64(tau))
+		ws := math.Exp(float64(-td) / float64(n.successDecay))
 
 		start := e.Value.(int64)
 		lag := now - start
@@ -165,7 +174,7 @@ func (n *Node) Pick() selector.DoneFunc {
 			}
 		}
 		oldSuc := atomic.LoadUint64(&n.success)
-		success = uint64(float64(oldSuc)*w + float64(success)*(1.0-w))
+		success = uint64(float64(oldSuc)*ws + float64(success)*(1.0-ws))
 		atomic.StoreUint64(&n.success, success)
 	}
 }
@@ -120,3 +120,30 @@ func TestDirectErrorHandler(t *testing.T) {
 		t.Errorf("float64(60000) <= wn.Weight()(%v)", wn.Weight())
 	}
 }
+
+func TestSuccessDecay(t *testing.T) {
+	node := func(b *Builder) *Node {
+		return b.Build(selector.NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{ID: "127.0.0.1:9090"})).(*Node)
+	}
+	call := func(n *Node, err error) {
+		done := n.Pick()
+		time.Sleep(5 * time.Millisecond)
+		done(context.Background(), selector.DoneInfo{Err: err})
+	}
+	fast, slow := node(&Builder{SuccessDecay: 5 * time.Millisecond}), node(&Builder{})
+	call(fast, nil)
+	call(slow, nil)
+	for i := 0; i < 3; i++ {
+		call(fast, context.DeadlineExceeded)
+		call(slow, context.DeadlineExceeded)
+	}
+	if fast.health() >= slow.health() {
+		t.Fatalf("short decay should drop the success rate faster, got %d >= %d", fast.health(), slow.health())
+	}
+	for i := 0; i < 10; i++ {
+		call(fast, nil)
+	}
+	if fast.health() < 900 {
+		t.Fatalf("success rate should recover, got %d", fast.health())
+	}
+}
@@ -26,12 +26,29 @@ func WithFilter(filters ...selector.Filter) Option {
 	}
 }
 
+// WithStats with the stats collector of the picks, see selector.Stats.
+func WithStats(stats *selector.Stats) Option {
+	return func(o *options) {
+		o.stats = stats
+	}
+}
+
+// WithSuccessDecay with the mean lifetime of the success rate of nodes,
+// see ewma.Builder.SuccessDecay.
+func WithSuccessDecay(d time.Duration) Option {
+	return func(o *options) {
+		o.successDecay = d
+	}
+}
+
 // Option is random builder option.
 type Option func(o *options)
 
 // options is random builder options
 type options struct {
-	filters []selector.Filter
+	filters      []selector.Filter
+	successDecay time.Duration
+	stats        *selector.Stats
 }
 
 // New creates a p2c selector.
@@ -96,8 +113,9 @@ func NewBuilder(opts ...Option) selector.Builder {
 	}
 	return &selector.DefaultBuilder{
 		Filters:  option.filters,
+		Stats:    option.stats,
 		Balancer: &Builder{},
-		Node:     &ewma.Builder{},
+		Node:     &ewma.Builder{SuccessDecay: option.successDecay},
 	}
 }
 
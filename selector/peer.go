@@ -0,0 +1,66 @@
+package selector
+
+import (
+	"context"
+	"sync"
+)
+
+// Peer is the node picked by the selector for the request of the context,
+// e.g. to pick distinct nodes for the hedged requests.
+type Peer struct {
+	mu   sync.Mutex
+	node Node
+}
+
+// Node returns the node picked, nil if it is not picked yet.
+func (p *Peer) Node() Node {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.node
+}
+
+func (p *Peer) set(node Node) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.node = node
+}
+
+type peerKey struct{}
+
+// NewPeerContext returns a new Context that carries the peer set by the
+// selector once the node is picked.
+func NewPeerContext(ctx context.Context, p *Peer) context.Context {
+	return context.WithValue(ctx, peerKey{}, p)
+}
+
+// FromPeerContext returns the peer stored in ctx, if any.
+func FromPeerContext(ctx context.Context) (p *Peer, ok bool) {
+	p, ok = ctx.Value(peerKey{}).(*Peer)
+	return
+}
+
+// NodeFromContext returns the node picked for the request of ctx, e.g. by the
+// client middleware after the call or by the hedged and retried calls, the
+// node of the last pick is returned. The transport clients carry a peer in the
+// context of every call, so the node is available in the client middleware.
+func NodeFromContext(ctx context.Context) (Node, bool) {
+	p, ok := FromPeerContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	node := p.Node()
+	return node, node != nil
+}
+
+type filterKey struct{}
+
+// NewFilterContext returns a new Context that carries the filters applied
+// after the ones of the selector and the select options.
+func NewFilterContext(ctx context.Context, filters ...Filter) context.Context {
+	return context.WithValue(ctx, filterKey{}, filters)
+}
+
+func filtersFromContext(ctx context.Context) []Filter {
+	filters, _ := ctx.Value(filterKey{}).([]Filter)
+	return filters
+}
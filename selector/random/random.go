@@ -22,12 +22,20 @@ func WithFilter(filters ...selector.Filter) Option {
 	}
 }
 
+// WithStats with the stats collector of the picks, see selector.Stats.
+func WithStats(stats *selector.Stats) Option {
+	return func(o *options) {
+		o.stats = stats
+	}
+}
+
 // Option is random builder option.
 type Option func(o *options)
 
 // options is random builder options
 type options struct {
 	filters []selector.Filter
+	stats   *selector.Stats
 }
 
 // Balancer is a random balancer.
@@ -57,6 +65,7 @@ func NewBuilder(opts ...Option) selector.Builder {
 	}
 	return &selector.DefaultBuilder{
 		Filters:  option.filters,
+		Stats:    option.stats,
 		Balancer: &Builder{},
 		Node:     &direct.Builder{},
 	}
@@ -174,3 +174,36 @@ func TestDefault(t *testing.T) {
 		t.Errorf("expect %v, got %v", nil, n)
 	}
 }
+
+func TestNodeFromContext(t *testing.T) {
+	builder := DefaultBuilder{
+		Node:     &mockWeightedNodeBuilder{},
+		Balancer: &mockBalancerBuilder{},
+	}
+	selector := builder.Build()
+	selector.Apply([]Node{NewNode("http", "127.0.0.1:8080", &registry.ServiceInstance{
+		Version:  "v2.0.0",
+		Metadata: map[string]string{"zone": "sh"},
+	})})
+	ctx := NewPeerContext(context.Background(), new(Peer))
+	if _, ok := NodeFromContext(ctx); ok {
+		t.Fatal("want no node before the pick")
+	}
+	if _, _, err := selector.Select(ctx); err != nil {
+		t.Fatal(err)
+	}
+	n, ok := NodeFromContext(ctx)
+	if !ok || n.Address() != "127.0.0.1:8080" || n.Metadata()["zone"] != "sh" {
+		t.Fatalf("want the picked node, got %v", n)
+	}
+	// a retry failing to pick clears the node of the previous pick
+	if _, _, err := selector.Select(ctx, WithFilter(mockFilter("v3.0.0"))); err != ErrNoAvailable {
+		t.Fatalf("want ErrNoAvailable, got %v", err)
+	}
+	if n, ok = NodeFromContext(ctx); ok {
+		t.Fatalf("want the stale node cleared, got %v", n)
+	}
+	if _, ok = NodeFromContext(context.Background()); ok {
+		t.Fatal("want no node without a peer")
+	}
+}
@@ -0,0 +1,87 @@
+package selector
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NodeStats is the snapshot of the stats of the picks of a node.
+type NodeStats struct {
+	// Address is the address of the node.
+	Address string
+	// Picks is the count of the picks of the node.
+	Picks int64
+	// Inflight is the count of the picks not done yet.
+	Inflight int64
+	// Errors is the count of the picks done with an error.
+	Errors int64
+	// Latency is the mean duration between the picks and their done.
+	Latency time.Duration
+}
+
+// Stats collects the stats of the picks of the nodes by address, it is
+// optional and shared by the selectors built with it, e.g. to confirm that
+// the picks follow the weights of the nodes.
+type Stats struct {
+	nodes sync.Map // address -> *nodeStats
+}
+
+type nodeStats struct {
+	picks    int64
+	inflight int64
+	done     int64
+	errors   int64
+	latency  int64 // the total nanoseconds of the done picks
+}
+
+// NewStats returns an empty stats collector.
+func NewStats() *Stats {
+	return &Stats{}
+}
+
+// Snapshot returns the stats of the picked nodes sorted by address.
+func (s *Stats) Snapshot() []NodeStats {
+	var stats []NodeStats
+	s.nodes.Range(func(key, value interface{}) bool {
+		ns := value.(*nodeStats)
+		st := NodeStats{
+			Address:  key.(string),
+			Picks:    atomic.LoadInt64(&ns.picks),
+			Inflight: atomic.LoadInt64(&ns.inflight),
+			Errors:   atomic.LoadInt64(&ns.errors),
+		}
+		if done := atomic.LoadInt64(&ns.done); done > 0 {
+			st.Latency = time.Duration(atomic.LoadInt64(&ns.latency) / done)
+		}
+		stats = append(stats, st)
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Address < stats[j].Address })
+	return stats
+}
+
+// pick records the pick of the node and returns the done func recording its done.
+func (s *Stats) pick(node Node, done DoneFunc) DoneFunc {
+	v, ok := s.nodes.Load(node.Address())
+	if !ok {
+		v, _ = s.nodes.LoadOrStore(node.Address(), &nodeStats{})
+	}
+	ns := v.(*nodeStats)
+	atomic.AddInt64(&ns.picks, 1)
+	atomic.AddInt64(&ns.inflight, 1)
+	start := time.Now()
+	return func(ctx context.Context, di DoneInfo) {
+		atomic.AddInt64(&ns.inflight, -1)
+		atomic.AddInt64(&ns.done, 1)
+		atomic.AddInt64(&ns.latency, int64(time.Since(start)))
+		if di.Err != nil {
+			atomic.AddInt64(&ns.errors, 1)
+		}
+		if done != nil {
+			done(ctx, di)
+		}
+	}
+}
@@ -64,3 +64,39 @@ func TestEmpty(t *testing.T) {
 		t.Errorf("expect no error, got %v", err)
 	}
 }
+
+func TestStats(t *testing.T) {
+	stats := selector.NewStats()
+	wrr := New(WithStats(stats))
+	wrr.Apply([]selector.Node{
+		selector.NewNode("http", "127.0.0.1:8080", &registry.ServiceInstance{Metadata: map[string]string{"weight": "10"}}),
+		selector.NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{Metadata: map[string]string{"weight": "20"}}),
+	})
+	var last selector.DoneFunc
+	for i := 0; i < 90; i++ {
+		_, done, err := wrr.Select(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < 89 {
+			done(context.Background(), selector.DoneInfo{})
+		} else {
+			last = done
+		}
+	}
+	snapshot := stats.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatalf("want the stats of 2 nodes, got %+v", snapshot)
+	}
+	if snapshot[0].Picks != 30 || snapshot[1].Picks != 60 {
+		t.Fatalf("want the picks following the weights, got %+v", snapshot)
+	}
+	if snapshot[0].Inflight+snapshot[1].Inflight != 1 {
+		t.Fatalf("want 1 inflight pick, got %+v", snapshot)
+	}
+	last(context.Background(), selector.DoneInfo{Err: context.Canceled})
+	snapshot = stats.Snapshot()
+	if snapshot[0].Inflight+snapshot[1].Inflight != 0 || snapshot[0].Errors+snapshot[1].Errors != 1 {
+		t.Fatalf("want the done pick recorded, got %+v", snapshot)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package kratos
+
+import (
+	"os"
+	"syscall"
+)
+
+var defaultDrainSignals = []os.Signal{syscall.SIGUSR1}
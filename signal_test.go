@@ -0,0 +1,48 @@
+//go:build !windows
+// +build !windows
+
+package kratos
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+func TestApp_DrainSignal(t *testing.T) {
+	app := New(Server(http.NewServer()), DrainSignal(syscall.SIGUSR2))
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+	time.Sleep(time.Second)
+	if !app.Ready() {
+		t.Fatal("want the app ready")
+	}
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if app.Ready() {
+		t.Fatal("want the app drained by the signal")
+	}
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !app.Ready() {
+		t.Fatal("want the app resumed by the signal")
+	}
+	if err := app.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,5 @@
+package kratos
+
+import "os"
+
+var defaultDrainSignals []os.Signal
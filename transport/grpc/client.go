@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/internal/hedge"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/registry"
@@ -68,6 +69,13 @@ func WithUnaryInterceptor(in ...grpc.UnaryClientInterceptor) ClientOption {
 	}
 }
 
+// WithStreamInterceptor returns a DialOption that specifies the interceptor for streaming RPCs.
+func WithStreamInterceptor(in ...grpc.StreamClientInterceptor) ClientOption {
+	return func(o *clientOptions) {
+		o.streamInts = in
+	}
+}
+
 // WithOptions with gRPC options.
 func WithOptions(opts ...grpc.DialOption) ClientOption {
 	return func(o *clientOptions) {
@@ -104,6 +112,7 @@ type clientOptions struct {
 	discovery    registry.Discovery
 	middleware   []middleware.Middleware
 	ints         []grpc.UnaryClientInterceptor
+	streamInts   []grpc.StreamClientInterceptor
 	grpcOpts     []grpc.DialOption
 	balancerName string
 	filters      []selector.Filter
@@ -135,9 +144,16 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 	if len(options.ints) > 0 {
 		ints = append(ints, options.ints...)
 	}
+	// the header of the transport is sent after the interceptors set it
+	streamInts := []grpc.StreamClientInterceptor{
+		streamClientInterceptor(options.filters),
+	}
+	streamInts = append(streamInts, options.streamInts...)
+	streamInts = append(streamInts, streamHeaderInterceptor)
 	grpcOpts := []grpc.DialOption{
 		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, options.balancerName)),
 		grpc.WithChainUnaryInterceptor(ints...),
+		grpc.WithChainStreamInterceptor(streamInts...),
 	}
 	if options.discovery != nil {
 		grpcOpts = append(grpcOpts,
@@ -168,27 +184,66 @@ func unaryClientInterceptor(ms []middleware.Middleware, timeout time.Duration, f
 			reqHeader: headerCarrier{},
 			filters:   filters,
 		})
+		if _, ok := selector.FromPeerContext(ctx); !ok {
+			// the node picked is set by the selector, see selector.NodeFromContext
+			ctx = selector.NewPeerContext(ctx, new(selector.Peer))
+		}
 		if timeout > 0 {
 			var cancel context.CancelFunc
 			ctx, cancel = context.WithTimeout(ctx, timeout)
 			defer cancel()
 		}
 		h := func(ctx context.Context, req interface{}) (interface{}, error) {
-			if tr, ok := transport.FromClientContext(ctx); ok {
-				header := tr.RequestHeader()
-				keys := header.Keys()
-				keyvals := make([]string, 0, len(keys))
-				for _, k := range keys {
-					keyvals = append(keyvals, k, header.Get(k))
-				}
-				ctx = grpcmd.AppendToOutgoingContext(ctx, keyvals...)
-			}
-			return reply, invoker(ctx, method, req, reply, cc, opts...)
+			ctx = appendHeader(ctx)
+			out := hedge.Reply(ctx, reply)
+			return out, invoker(ctx, method, req, out, cc, opts...)
 		}
 		if len(ms) > 0 {
 			h = middleware.Chain(ms...)(h)
 		}
-		_, err := h(ctx, req)
+		out, err := h(ctx, req)
+		if err == nil {
+			hedge.Copy(reply, out)
+		}
 		return err
 	}
 }
+
+func streamClientInterceptor(filters []selector.Filter) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = transport.NewClientContext(ctx, &Transport{
+			endpoint:     cc.Target(),
+			operation:    method,
+			reqHeader:    headerCarrier{},
+			filters:      filters,
+			clientStream: desc.ClientStreams,
+			serverStream: desc.ServerStreams,
+		})
+		if _, ok := selector.FromPeerContext(ctx); !ok {
+			ctx = selector.NewPeerContext(ctx, new(selector.Peer))
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// streamHeaderInterceptor is the last stream interceptor, it sends the request
+// header of the transport set by the previous ones.
+func streamHeaderInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(appendHeader(ctx), desc, cc, method, opts...)
+}
+
+// appendHeader appends the request header of the client transport to the
+// outgoing metadata.
+func appendHeader(ctx context.Context) context.Context {
+	tr, ok := transport.FromClientContext(ctx)
+	if !ok {
+		return ctx
+	}
+	header := tr.RequestHeader()
+	keys := header.Keys()
+	keyvals := make([]string, 0, len(keys))
+	for _, k := range keys {
+		keyvals = append(keyvals, k, header.Get(k))
+	}
+	return grpcmd.AppendToOutgoingContext(ctx, keyvals...)
+}
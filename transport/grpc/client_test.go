@@ -3,6 +3,7 @@ package grpc
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -10,7 +11,10 @@ import (
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/transport"
 	"google.golang.org/grpc"
+	grpcmd "google.golang.org/grpc/metadata"
 )
 
 func TestWithEndpoint(t *testing.T) {
@@ -94,6 +98,9 @@ func TestUnaryClientInterceptor(t *testing.T) {
 
 	err := f(context.TODO(), "hello", req, resp, &grpc.ClientConn{},
 		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			if _, ok := selector.FromPeerContext(ctx); !ok {
+				return errors.New("no peer carrying the picked node")
+			}
 			return nil
 		})
 	if err != nil {
@@ -117,6 +124,47 @@ func TestWithUnaryInterceptor(t *testing.T) {
 	}
 }
 
+func TestStreamClientInterceptor(t *testing.T) {
+	cc := &grpc.ClientConn{}
+	desc := &grpc.StreamDesc{ServerStreams: true}
+	_, err := streamClientInterceptor(nil)(context.Background(), desc, cc, "/hello/world",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				t.Fatal("want the client transport")
+			}
+			if s, ok := tr.(transport.Streamer); !ok || s.IsClientStream() || !s.IsServerStream() {
+				t.Fatalf("want the server stream, got %+v", tr)
+			}
+			// set by a stream interceptor, e.g. tracing
+			tr.RequestHeader().Set("x-md-hello", "world")
+			return streamHeaderInterceptor(ctx, desc, cc, method,
+				func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+					md, _ := grpcmd.FromOutgoingContext(ctx)
+					if v := md.Get("x-md-hello"); len(v) != 1 || v[0] != "world" {
+						t.Fatalf("want the header sent, got %v", md)
+					}
+					return nil, nil
+				})
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithStreamInterceptor(t *testing.T) {
+	o := &clientOptions{}
+	v := []grpc.StreamClientInterceptor{
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, nil
+		},
+	}
+	WithStreamInterceptor(v...)(o)
+	if !reflect.DeepEqual(v, o.streamInts) {
+		t.Errorf("expect %v but got %v", v, o.streamInts)
+	}
+}
+
 func TestWithOptions(t *testing.T) {
 	o := &clientOptions{}
 	v := []grpc.DialOption{
@@ -66,10 +66,12 @@ func (s *Server) streamServerInterceptor() grpc.StreamServerInterceptor {
 		md, _ := grpcmd.FromIncomingContext(ctx)
 		replyHeader := grpcmd.MD{}
 		ctx = transport.NewServerContext(ctx, &Transport{
-			endpoint:    s.endpoint.String(),
-			operation:   info.FullMethod,
-			reqHeader:   headerCarrier(md),
-			replyHeader: headerCarrier(replyHeader),
+			endpoint:     s.endpoint.String(),
+			operation:    info.FullMethod,
+			reqHeader:    headerCarrier(md),
+			replyHeader:  headerCarrier(replyHeader),
+			clientStream: info.IsClientStream,
+			serverStream: info.IsServerStream,
 		})
 
 		ws := NewWrappedStream(ctx, ss)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/internal/endpoint"
@@ -24,6 +25,11 @@ type discoveryResolver struct {
 
 	insecure         bool
 	debugLogDisabled bool
+
+	mu     sync.Mutex
+	timer  *time.Timer
+	gen    uint64
+	closed bool
 }
 
 func (r *discoveryResolver) watch() {
@@ -47,9 +53,21 @@ func (r *discoveryResolver) watch() {
 }
 
 func (r *discoveryResolver) update(ins []*registry.ServiceInstance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.apply(ins)
+}
+
+func (r *discoveryResolver) apply(ins []*registry.ServiceInstance) {
 	addrs := make([]resolver.Address, 0)
 	endpoints := make(map[string]struct{})
+	now := time.Now()
+	r.refilter(ins, now)
 	for _, in := range ins {
+		// skip the instances of lapsed heartbeats
+		if !registry.Alive(in, now) {
+			continue
+		}
 		endpoint, err := endpoint.ParseEndpoint(in.Endpoints, "grpc", !r.insecure)
 		if err != nil {
 			r.log.Errorf("[resolver] Failed to parse discovery endpoint: %v", err)
@@ -86,8 +104,37 @@ func (r *discoveryResolver) update(ins []*registry.ServiceInstance) {
 	}
 }
 
+// refilter applies the instances again at the earliest lapse of their
+// heartbeats, so that the lapsed instances are dropped without a watch event.
+func (r *discoveryResolver) refilter(ins []*registry.ServiceInstance, now time.Time) {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.gen++
+	lapse, ok := registry.Lapse(ins, now)
+	if !ok || r.closed {
+		return
+	}
+	gen := r.gen
+	r.timer = time.AfterFunc(lapse.Sub(now), func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		// skip if the instances were updated or the resolver was closed
+		if gen == r.gen {
+			r.apply(ins)
+		}
+	})
+}
+
 func (r *discoveryResolver) Close() {
 	r.cancel()
+	r.mu.Lock()
+	r.closed = true
+	r.gen++
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.mu.Unlock()
 	err := r.w.Stop()
 	if err != nil {
 		r.log.Errorf("[resolver] failed to watch top: %s", err)
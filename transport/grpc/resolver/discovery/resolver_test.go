@@ -105,6 +105,47 @@ func TestWatchContextCancel(t *testing.T) {
 	t.Log("watch goroutine exited after 2 second")
 }
 
+type recordClientConn struct {
+	resolver.ClientConn
+	states chan resolver.State
+}
+
+func (c *recordClientConn) UpdateState(s resolver.State) error {
+	c.states <- s
+	return nil
+}
+
+func TestUpdateLapse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cc := &recordClientConn{states: make(chan resolver.State, 2)}
+	r := &discoveryResolver{
+		w:                &testWatch{},
+		cc:               cc,
+		log:              log.NewHelper(log.GetLogger()),
+		ctx:              ctx,
+		cancel:           cancel,
+		insecure:         true,
+		debugLogDisabled: true,
+	}
+	defer r.Close()
+	now := time.Now()
+	r.update([]*registry.ServiceInstance{
+		{ID: "1", Endpoints: []string{"grpc://127.0.0.1:9001"}},
+		registry.WithHeartbeat(&registry.ServiceInstance{ID: "2", Endpoints: []string{"grpc://127.0.0.1:9002"}}, now.Add(-time.Second), time.Second),
+	})
+	if s := <-cc.states; len(s.Addresses) != 2 {
+		t.Fatalf("expect 2 addresses, got %v", s.Addresses)
+	}
+	select {
+	case s := <-cc.states:
+		if len(s.Addresses) != 1 || s.Addresses[0].Addr != "127.0.0.1:9001" {
+			t.Fatalf("expect the alive address, got %v", s.Addresses)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("the lapsed address should be dropped without a watch event")
+	}
+}
+
 func TestParseAttributes(t *testing.T) {
 	a := parseAttributes(map[string]string{"a": "b"})
 	if !reflect.DeepEqual("b", a.Value("a").(string)) {
@@ -5,6 +5,8 @@ import (
 	"crypto/tls"
 	"net"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/internal/endpoint"
@@ -20,6 +22,7 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 
 	"google.golang.org/grpc/reflection"
 )
@@ -53,6 +56,23 @@ func Timeout(timeout time.Duration) ServerOption {
 	}
 }
 
+// GracefulTimeout with the max duration of the graceful stop, the server is
+// force stopped once it is exceeded, zero waits until the stop context is done.
+func GracefulTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.gracefulTimeout = timeout
+	}
+}
+
+// HealthCheck with the func reporting the serving status of services, it is
+// consulted by the health service unless the status of the service has been
+// set to not serving, e.g. when the server is stopping.
+func HealthCheck(f func(ctx context.Context, service string) grpc_health_v1.HealthCheckResponse_ServingStatus) ServerOption {
+	return func(s *Server) {
+		s.healthCheck = f
+	}
+}
+
 // Logger with server logger.
 func Logger(logger log.Logger) ServerOption {
 	return func(s *Server) {
@@ -95,6 +115,41 @@ func StreamInterceptor(in ...grpc.StreamServerInterceptor) ServerOption {
 	}
 }
 
+// KeepaliveParams with the keepalive parameters of the server, by default the
+// server pings the idle connections after 2 hours and closes them if the ping
+// is not acknowledged within 20 seconds, and never closes the idle or the old
+// connections.
+func KeepaliveParams(kp keepalive.ServerParameters) ServerOption {
+	return func(s *Server) {
+		s.keepaliveParams = &kp
+	}
+}
+
+// KeepaliveEnforcementPolicy with the keepalive enforcement policy of the
+// server, by default the connections pinging more often than every 5 minutes
+// or without active streams are closed.
+func KeepaliveEnforcementPolicy(ep keepalive.EnforcementPolicy) ServerOption {
+	return func(s *Server) {
+		s.keepalivePolicy = &ep
+	}
+}
+
+// MaxRecvMsgSize with the max bytes of the messages the server receives, the
+// default is 4MB. Non-positive sizes keep the default.
+func MaxRecvMsgSize(size int) ServerOption {
+	return func(s *Server) {
+		s.maxRecvMsgSize = size
+	}
+}
+
+// MaxSendMsgSize with the max bytes of the messages the server sends, the
+// default is math.MaxInt32. Non-positive sizes keep the default.
+func MaxSendMsgSize(size int) ServerOption {
+	return func(s *Server) {
+		s.maxSendMsgSize = size
+	}
+}
+
 // Options with grpc options.
 func Options(opts ...grpc.ServerOption) ServerOption {
 	return func(s *Server) {
@@ -104,6 +159,8 @@ func Options(opts ...grpc.ServerOption) ServerOption {
 
 // Server is a gRPC server wrapper.
 type Server struct {
+	// conns must be the first field to be 64-bit aligned.
+	conns int64
 	*grpc.Server
 	baseCtx    context.Context
 	tlsConf    *tls.Config
@@ -120,6 +177,13 @@ type Server struct {
 	grpcOpts   []grpc.ServerOption
 	health     *health.Server
 	metadata   *apimd.Server
+	// gracefulTimeout is the max duration of GracefulStop.
+	gracefulTimeout time.Duration
+	healthCheck     func(ctx context.Context, service string) grpc_health_v1.HealthCheckResponse_ServingStatus
+	keepaliveParams *keepalive.ServerParameters
+	keepalivePolicy *keepalive.EnforcementPolicy
+	maxRecvMsgSize  int
+	maxSendMsgSize  int
 }
 
 // NewServer creates a gRPC server by options.
@@ -154,6 +218,18 @@ func NewServer(opts ...ServerOption) *Server {
 	if srv.tlsConf != nil {
 		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(srv.tlsConf)))
 	}
+	if srv.keepaliveParams != nil {
+		grpcOpts = append(grpcOpts, grpc.KeepaliveParams(*srv.keepaliveParams))
+	}
+	if srv.keepalivePolicy != nil {
+		grpcOpts = append(grpcOpts, grpc.KeepaliveEnforcementPolicy(*srv.keepalivePolicy))
+	}
+	if srv.maxRecvMsgSize > 0 {
+		grpcOpts = append(grpcOpts, grpc.MaxRecvMsgSize(srv.maxRecvMsgSize))
+	}
+	if srv.maxSendMsgSize > 0 {
+		grpcOpts = append(grpcOpts, grpc.MaxSendMsgSize(srv.maxSendMsgSize))
+	}
 	if len(srv.grpcOpts) > 0 {
 		grpcOpts = append(grpcOpts, srv.grpcOpts...)
 	}
@@ -162,7 +238,7 @@ func NewServer(opts ...ServerOption) *Server {
 	// listen and endpoint
 	srv.err = srv.listenAndEndpoint()
 	// internal register
-	grpc_health_v1.RegisterHealthServer(srv.Server, srv.health)
+	grpc_health_v1.RegisterHealthServer(srv.Server, &healthServer{Server: srv.health, check: srv.healthCheck})
 	apimd.RegisterMetadataServer(srv.Server, srv.metadata)
 	reflection.Register(srv.Server)
 	return srv
@@ -186,17 +262,96 @@ func (s *Server) Start(ctx context.Context) error {
 	s.baseCtx = ctx
 	s.log.Infof("[gRPC] server listening on: %s", s.lis.Addr().String())
 	s.health.Resume()
-	return s.Serve(s.lis)
+	return s.Serve(&countListener{Listener: s.lis, conns: &s.conns})
 }
 
-// Stop stop the gRPC server.
+// Stop stop the gRPC server gracefully, it is force stopped if the graceful
+// stop is not done within the graceful timeout or before ctx is done.
 func (s *Server) Stop(ctx context.Context) error {
 	s.health.Shutdown()
-	s.GracefulStop()
 	s.log.Info("[gRPC] server stopping")
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	var timeout <-chan time.Time
+	if s.gracefulTimeout > 0 {
+		timer := time.NewTimer(s.gracefulTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-done:
+		return nil
+	case <-timeout:
+	case <-ctx.Done():
+	}
+	n := atomic.LoadInt64(&s.conns)
+	s.Server.Stop()
+	<-done
+	s.log.Warnf("[gRPC] server graceful stop timeout, force closed %d connections", n)
 	return nil
 }
 
+// SetServingStatus sets the serving status of a service of the health service,
+// the empty service is the status of the server.
+func (s *Server) SetServingStatus(service string, status grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	s.health.SetServingStatus(service, status)
+}
+
+// Drain sets the serving status of all the services of the health service to
+// not serving, the server keeps serving the requests.
+func (s *Server) Drain() {
+	s.health.Shutdown()
+}
+
+// Resume sets the serving status of all the services of the health service to
+// serving again after Drain.
+func (s *Server) Resume() {
+	s.health.Resume()
+}
+
+// healthServer is the health server consulting check.
+type healthServer struct {
+	*health.Server
+	check func(ctx context.Context, service string) grpc_health_v1.HealthCheckResponse_ServingStatus
+}
+
+func (h *healthServer) Check(ctx context.Context, in *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	resp, err := h.Server.Check(ctx, in)
+	if h.check == nil || (err == nil && resp.Status != grpc_health_v1.HealthCheckResponse_SERVING) {
+		return resp, err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: h.check(ctx, in.Service)}, nil
+}
+
+// countListener counts the connections accepted and not closed yet.
+type countListener struct {
+	net.Listener
+	conns *int64
+}
+
+func (l *countListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(l.conns, 1)
+	return &countConn{Conn: conn, conns: l.conns}, nil
+}
+
+type countConn struct {
+	net.Conn
+	conns *int64
+	once  sync.Once
+}
+
+func (c *countConn) Close() error {
+	c.once.Do(func() { atomic.AddInt64(c.conns, -1) })
+	return c.Conn.Close()
+}
+
 func (s *Server) listenAndEndpoint() error {
 	if s.lis == nil {
 		lis, err := net.Listen(s.network, s.address)
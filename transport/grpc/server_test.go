@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,6 +19,10 @@ import (
 	"github.com/go-kratos/kratos/v2/transport"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
 // server is used to implement helloworld.GreeterServer.
@@ -273,3 +278,138 @@ func TestListener(t *testing.T) {
 		t.Errorf("expect %v, got %v", lis, s.lis)
 	}
 }
+
+func TestGracefulTimeout(t *testing.T) {
+	srv := NewServer(GracefulTimeout(100 * time.Millisecond))
+	// a server stream blocked until the server is force stopped
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Blocking",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "Block",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				<-stream.Context().Done()
+				return stream.Context().Err()
+			},
+		}},
+	}, nil)
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	stream, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ServerStreams: true}, "/test.Blocking/Block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	if err = srv.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("stop should be forced after the graceful timeout, took %v", d)
+	}
+	if err = stream.RecvMsg(new(interface{})); err == nil {
+		t.Fatal("stream should be interrupted")
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	var dbUp int32 = 1
+	srv := NewServer(HealthCheck(func(ctx context.Context, service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		if service == "db" && atomic.LoadInt32(&dbUp) == 0 {
+			return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		}
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	}))
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() {
+		_ = srv.Stop(context.Background())
+	}()
+	time.Sleep(100 * time.Millisecond)
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := grpc_health_v1.NewHealthClient(conn)
+	check := func(service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Status
+	}
+	if s := check("db"); s != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("want SERVING, got %v", s)
+	}
+	atomic.StoreInt32(&dbUp, 0)
+	if s := check("db"); s != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("want NOT_SERVING, got %v", s)
+	}
+	srv.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	if s := check(""); s != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("want NOT_SERVING, got %v", s)
+	}
+}
+
+func TestKeepalive(t *testing.T) {
+	kp := keepalive.ServerParameters{MaxConnectionIdle: time.Minute, Time: 30 * time.Second}
+	ep := keepalive.EnforcementPolicy{MinTime: 10 * time.Second, PermitWithoutStream: true}
+	srv := NewServer(KeepaliveParams(kp), KeepaliveEnforcementPolicy(ep))
+	if !reflect.DeepEqual(*srv.keepaliveParams, kp) {
+		t.Fatalf("want %+v, got %+v", kp, *srv.keepaliveParams)
+	}
+	if !reflect.DeepEqual(*srv.keepalivePolicy, ep) {
+		t.Fatalf("want %+v, got %+v", ep, *srv.keepalivePolicy)
+	}
+}
+
+func TestMaxMsgSize(t *testing.T) {
+	srv := NewServer(MaxRecvMsgSize(32), MaxSendMsgSize(-1))
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() {
+		_ = srv.Stop(context.Background())
+	}()
+	time.Sleep(100 * time.Millisecond)
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := grpc_health_v1.NewHealthClient(conn)
+	if _, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: strings.Repeat("a", 64)})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("want ResourceExhausted, got %v", err)
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
-var _ transport.Transporter = &Transport{}
+var (
+	_ transport.Transporter = &Transport{}
+	_ transport.Streamer    = &Transport{}
+)
 
 // Transport is a gRPC transport.
 type Transport struct {
@@ -15,6 +18,9 @@ type Transport struct {
 	reqHeader   headerCarrier
 	replyHeader headerCarrier
 	filters     []selector.Filter
+	// clientStream and serverStream are the directions of the streaming RPCs.
+	clientStream bool
+	serverStream bool
 }
 
 // Kind returns the transport kind.
@@ -42,6 +48,27 @@ func (tr *Transport) ReplyHeader() transport.Header {
 	return tr.replyHeader
 }
 
+// IsClientStream reports whether the client sends a stream of messages.
+func (tr *Transport) IsClientStream() bool {
+	return tr.clientStream
+}
+
+// IsServerStream reports whether the server sends a stream of messages.
+func (tr *Transport) IsServerStream() bool {
+	return tr.serverStream
+}
+
+// Clone returns a copy of the transport with a copy of the headers, e.g. for
+// the hedged attempts running in parallel.
+func (tr *Transport) Clone() transport.Transporter {
+	c := *tr
+	c.reqHeader = headerCarrier(metadata.MD(tr.reqHeader).Copy())
+	if tr.replyHeader != nil {
+		c.replyHeader = headerCarrier(metadata.MD(tr.replyHeader).Copy())
+	}
+	return &c
+}
+
 // SelectFilters returns the client select filters.
 func (tr *Transport) SelectFilters() []selector.Filter {
 	return tr.filters
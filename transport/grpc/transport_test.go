@@ -68,3 +68,13 @@ func TestHeaderCarrier_Keys(t *testing.T) {
 		t.Errorf("expect %v, got %v", want, keys)
 	}
 }
+
+func TestTransport_Clone(t *testing.T) {
+	o := &Transport{operation: "/test", reqHeader: headerCarrier{}}
+	o.reqHeader.Set("a", "1")
+	c := o.Clone()
+	c.RequestHeader().Set("a", "2")
+	if o.reqHeader.Get("a") != "1" || c.RequestHeader().Get("a") != "2" || c.Operation() != "/test" {
+		t.Errorf("expect the header copied, got %v and %v", o.reqHeader, c.RequestHeader())
+	}
+}
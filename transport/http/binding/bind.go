@@ -1,6 +1,9 @@
 package binding
 
 import (
+	"encoding/base64"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 
@@ -20,3 +23,38 @@ func BindForm(req *http.Request, target interface{}) error {
 	}
 	return encoding.GetCodec(form.Name).Unmarshal([]byte(req.Form.Encode()), target)
 }
+
+// BindMultipartForm bind multipart form parameters to target, the file parts
+// are bound to the bytes fields of proto messages matching their part names.
+// maxMemory is passed to http.Request.ParseMultipartForm.
+func BindMultipartForm(req *http.Request, maxMemory int64, target interface{}) error {
+	if err := req.ParseMultipartForm(maxMemory); err != nil {
+		return err
+	}
+	defer func() {
+		_ = req.MultipartForm.RemoveAll()
+	}()
+	vars := make(url.Values, len(req.MultipartForm.Value)+len(req.MultipartForm.File))
+	for k, v := range req.MultipartForm.Value {
+		vars[k] = v
+	}
+	for k, files := range req.MultipartForm.File {
+		for _, fh := range files {
+			data, err := readFile(fh)
+			if err != nil {
+				return err
+			}
+			vars.Add(k, base64.StdEncoding.EncodeToString(data))
+		}
+	}
+	return encoding.GetCodec(form.Name).Unmarshal([]byte(vars.Encode()), target)
+}
+
+func readFile(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
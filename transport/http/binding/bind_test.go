@@ -1,12 +1,17 @@
 package binding
 
 import (
+	"bytes"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/go-kratos/kratos/v2/internal/testdata/complex"
 )
 
 func TestBindQuery(t *testing.T) {
@@ -98,3 +103,33 @@ func TestBindForm(t *testing.T) {
 		})
 	}
 }
+
+func TestBindMultipartForm(t *testing.T) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	_ = mw.WriteField("numberOne", "kratos")
+	_ = mw.WriteField("simples", "a")
+	_ = mw.WriteField("simples", "b")
+	fw, err := mw.CreateFormFile("byte", "data.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write([]byte{0, 1, 2, 0xff})
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	target := new(complex.Complex)
+	if err = BindMultipartForm(req, 1024, target); err != nil {
+		t.Fatal(err)
+	}
+	if target.NoOne != "kratos" {
+		t.Fatalf("want kratos, got %v", target.NoOne)
+	}
+	if !reflect.DeepEqual(target.Simples, []string{"a", "b"}) {
+		t.Fatalf("want [a b], got %v", target.Simples)
+	}
+	if !bytes.Equal(target.Byte, []byte{0, 1, 2, 0xff}) {
+		t.Fatalf("unexpected file content %v", target.Byte)
+	}
+}
@@ -11,8 +11,8 @@ import (
 
 	"github.com/go-kratos/kratos/v2/encoding"
 	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/hedge"
 	"github.com/go-kratos/kratos/v2/internal/host"
-	"github.com/go-kratos/kratos/v2/internal/httputil"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/registry"
 	"github.com/go-kratos/kratos/v2/selector"
@@ -226,12 +226,32 @@ func (client *Client) Invoke(ctx context.Context, method, path string, args inte
 		request:      req,
 		pathTemplate: c.pathTemplate,
 	})
+	if _, ok := selector.FromPeerContext(ctx); !ok {
+		// the node picked is set by the selector, see selector.NodeFromContext
+		ctx = selector.NewPeerContext(ctx, new(selector.Peer))
+	}
 	return client.invoke(ctx, req, args, reply, c, opts...)
 }
 
 func (client *Client) invoke(ctx context.Context, req *http.Request, args interface{}, reply interface{}, c callInfo, opts ...CallOption) error {
 	h := func(ctx context.Context, in interface{}) (interface{}, error) {
-		res, err := client.do(req.WithContext(ctx))
+		r := req
+		if tr, ok := transport.FromClientContext(ctx); ok {
+			// the request of the transport, e.g. cloned by a hedged attempt
+			if ht, ok := tr.(*Transport); ok && ht.request != nil {
+				r = ht.request
+			}
+		}
+		r = r.WithContext(ctx)
+		if req.GetBody != nil {
+			// the body is sent again by every call, e.g. the retries
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		res, err := client.do(r)
 		if res != nil {
 			cs := csAttempt{res: res}
 			for _, o := range opts {
@@ -242,15 +262,19 @@ func (client *Client) invoke(ctx context.Context, req *http.Request, args interf
 			return nil, err
 		}
 		defer res.Body.Close()
-		if err := client.opts.decoder(ctx, res, reply); err != nil {
+		out := hedge.Reply(ctx, reply)
+		if err := client.opts.decoder(ctx, res, out); err != nil {
 			return nil, err
 		}
-		return reply, nil
+		return out, nil
 	}
 	if len(client.opts.middleware) > 0 {
 		h = middleware.Chain(client.opts.middleware...)(h)
 	}
-	_, err := h(ctx, args)
+	out, err := h(ctx, args)
+	if err == nil {
+		hedge.Copy(reply, out)
+	}
 	return err
 }
 
@@ -308,8 +332,11 @@ func (client *Client) Close() error {
 
 // DefaultRequestEncoder is an HTTP request encoder.
 func DefaultRequestEncoder(ctx context.Context, contentType string, in interface{}) ([]byte, error) {
-	name := httputil.ContentSubtype(contentType)
-	body, err := encoding.GetCodec(name).Marshal(in)
+	codec, err := encoding.GetCodecByContentType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	body, err := codec.Marshal(in)
 	if err != nil {
 		return nil, err
 	}
@@ -345,8 +372,7 @@ func DefaultErrorDecoder(ctx context.Context, res *http.Response) error {
 
 // CodecForResponse get encoding.Codec via http.Response
 func CodecForResponse(r *http.Response) encoding.Codec {
-	codec := encoding.GetCodec(httputil.ContentSubtype(r.Header.Get("Content-Type")))
-	if codec != nil {
+	if codec, err := encoding.GetCodecByContentType(r.Header.Get("Content-Type")); err == nil {
 		return codec
 	}
 	return encoding.GetCodec("json")
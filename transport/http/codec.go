@@ -2,13 +2,21 @@ package http
 
 import (
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-kratos/kratos/v2/encoding"
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/internal/httputil"
+	"github.com/go-kratos/kratos/v2/transport/http/binding"
 )
 
+// defaultMultipartMemory is the max bytes of the multipart parts stored in
+// memory, the rest are stored in temporary files.
+const defaultMultipartMemory = 32 << 20
+
 // SupportPackageIsVersion1 These constants should not be referenced from any other code.
 const SupportPackageIsVersion1 = true
 
@@ -21,8 +29,15 @@ type EncodeResponseFunc func(http.ResponseWriter, *http.Request, interface{}) er
 // EncodeErrorFunc is encode error func.
 type EncodeErrorFunc func(http.ResponseWriter, *http.Request, error)
 
-// DefaultRequestDecoder decodes the request body to object.
+// DefaultRequestDecoder decodes the request body to object,
+// multipart forms are bound by binding.BindMultipartForm.
 func DefaultRequestDecoder(r *http.Request, v interface{}) error {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		if err := binding.BindMultipartForm(r, defaultMultipartMemory, v); err != nil {
+			return errors.BadRequest("CODEC", err.Error())
+		}
+		return nil
+	}
 	codec, ok := CodecForRequest(r, "Content-Type")
 	if !ok {
 		return errors.BadRequest("CODEC", r.Header.Get("Content-Type"))
@@ -40,8 +55,21 @@ func DefaultRequestDecoder(r *http.Request, v interface{}) error {
 	return nil
 }
 
-// DefaultResponseEncoder encodes the object to the HTTP response.
+// DefaultResponseEncoder encodes the object to the HTTP response,
+// SSEStream is written as server-sent events.
 func DefaultResponseEncoder(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if stream, ok := v.(SSEStream); ok {
+		if rw, ok := w.(*responseWriter); ok {
+			// the status of responseWriter is written with every write
+			w = rw.w
+		}
+		sw, err := NewSSEWriter(r.Context(), w)
+		if err != nil {
+			return err
+		}
+		defer sw.Close()
+		return stream(r.Context(), sw)
+	}
 	if v == nil {
 		_, err := w.Write(nil)
 		return err
@@ -59,10 +87,12 @@ func DefaultResponseEncoder(w http.ResponseWriter, r *http.Request, v interface{
 	return nil
 }
 
-// DefaultErrorEncoder encodes the error to the HTTP response.
+// DefaultErrorEncoder encodes the error to the HTTP response, the codec is
+// negotiated by the Accept header and falls back to the one of the request
+// Content-Type, so a proto client gets a proto Status.
 func DefaultErrorEncoder(w http.ResponseWriter, r *http.Request, err error) {
 	se := errors.FromError(err)
-	codec, _ := CodecForRequest(r, "Accept")
+	codec := codecForAccept(r)
 	body, err := codec.Marshal(se)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -76,10 +106,44 @@ func DefaultErrorEncoder(w http.ResponseWriter, r *http.Request, err error) {
 // CodecForRequest get encoding.Codec via http.Request
 func CodecForRequest(r *http.Request, name string) (encoding.Codec, bool) {
 	for _, accept := range r.Header[name] {
-		codec := encoding.GetCodec(httputil.ContentSubtype(accept))
-		if codec != nil {
+		if codec, err := encoding.GetCodecByContentType(accept); err == nil {
 			return codec, true
 		}
 	}
 	return encoding.GetCodec("json"), false
 }
+
+// codecForAccept returns the codec of the media type of the highest quality in
+// the Accept header, or the codec of the Content-Type header if none of them
+// is supported.
+func codecForAccept(r *http.Request) encoding.Codec {
+	var (
+		best  encoding.Codec
+		bestQ float64
+	)
+	for _, accept := range r.Header["Accept"] {
+		for _, v := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(v))
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if s, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(s, 64); err != nil {
+					continue
+				}
+			}
+			if q <= bestQ {
+				continue
+			}
+			if codec, err := encoding.GetCodecByContentType(mediaType); err == nil {
+				best, bestQ = codec, q
+			}
+		}
+	}
+	if best != nil {
+		return best
+	}
+	codec, _ := CodecForRequest(r, "Content-Type")
+	return codec
+}
@@ -2,12 +2,16 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"io"
 	nethttp "net/http"
 	"reflect"
 	"testing"
 
+	"github.com/go-kratos/kratos/v2/encoding"
 	"github.com/go-kratos/kratos/v2/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestDefaultRequestDecoder(t *testing.T) {
@@ -151,3 +155,71 @@ func TestCodecForRequest(t *testing.T) {
 		t.Errorf("expected %v, got %v", "json", c.Name())
 	}
 }
+
+func TestDefaultErrorEncoderAccept(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		want        string
+	}{
+		{"json", "application/json", "", "application/json"},
+		{"proto", "application/proto", "", "application/proto"},
+		{"quality", "text/html;q=0.9, application/json;q=0.5, application/proto", "", "application/proto"},
+		{"wildcard", "*/*", "application/proto", "application/proto"},
+		{"content type", "", "application/proto", "application/proto"},
+		{"default", "", "", "application/json"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := &mockResponseWriter{header: make(nethttp.Header)}
+			req := &nethttp.Request{Header: make(nethttp.Header)}
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			DefaultErrorEncoder(w, req, errors.BadRequest("REASON", "message"))
+			if got := w.Header().Get("Content-Type"); got != test.want {
+				t.Fatalf("want %v, got %v", test.want, got)
+			}
+			if w.StatusCode != 400 {
+				t.Fatalf("want 400, got %v", w.StatusCode)
+			}
+			codec, err := encoding.GetCodecByContentType(test.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st := new(errors.Status)
+			if err := codec.Unmarshal(w.Data, st); err != nil {
+				t.Fatal(err)
+			}
+			if st.Reason != "REASON" || st.Code != 400 {
+				t.Fatalf("unexpected status %v", st)
+			}
+		})
+	}
+}
+
+func TestDefaultErrorEncoderDetails(t *testing.T) {
+	w := &mockResponseWriter{header: make(nethttp.Header)}
+	req := &nethttp.Request{Header: make(nethttp.Header)}
+	req.Header.Set("Accept", "application/json")
+	violation := &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "name", Description: "required"}},
+	}
+	DefaultErrorEncoder(w, req, errors.BadRequest("REASON", "message").WithDetails(violation))
+	if !bytes.Contains(w.Data, []byte(`"type.googleapis.com/google.rpc.BadRequest"`)) {
+		t.Fatalf("want the details in the body, got %s", w.Data)
+	}
+	res := &nethttp.Response{
+		StatusCode: w.StatusCode,
+		Header:     w.header,
+		Body:       io.NopCloser(bytes.NewReader(w.Data)),
+	}
+	details := errors.Details(DefaultErrorDecoder(context.Background(), res))
+	if len(details) != 1 || !proto.Equal(details[0], violation) {
+		t.Fatalf("want %v, got %v", violation, details)
+	}
+}
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var compressors = map[string]func(io.Writer) io.WriteCloser{
+	"gzip": func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	},
+	"deflate": func(w io.Writer) io.WriteCloser {
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return fw
+	},
+}
+
+// compressedTypes are the prefixes of the content types already compressed.
+var compressedTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-bzip2",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+}
+
+// Compression with the responses compressed by the first of the algos of the
+// highest quality in the Accept-Encoding header, "gzip" and "deflate" are
+// supported and the others are ignored, both of them by default.
+// The responses smaller than minSize, unless flushed, or of a compressed
+// content type are not compressed.
+func Compression(minSize int, algos ...string) ServerOption {
+	return func(s *Server) {
+		if len(algos) == 0 {
+			algos = []string{"gzip", "deflate"}
+		}
+		s.compressMinSize = minSize
+		s.compressAlgos = s.compressAlgos[:0]
+		for _, algo := range algos {
+			if _, ok := compressors[algo]; ok {
+				s.compressAlgos = append(s.compressAlgos, algo)
+			}
+		}
+	}
+}
+
+func (s *Server) compress(next http.Handler) http.Handler {
+	if len(s.compressAlgos) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		algo := negotiateEncoding(req.Header.Get("Accept-Encoding"), s.compressAlgos)
+		if algo == "" || req.Method == http.MethodHead {
+			next.ServeHTTP(w, req)
+			return
+		}
+		cw := &compressWriter{
+			ResponseWriter: w,
+			algo:           algo,
+			minSize:        s.compressMinSize,
+			code:           http.StatusOK,
+		}
+		defer cw.close()
+		next.ServeHTTP(cw, req)
+	})
+}
+
+// negotiateEncoding returns the first of the algos of the highest quality in
+// the Accept-Encoding header, or empty if none of them is acceptable.
+func negotiateEncoding(header string, algos []string) string {
+	if header == "" {
+		return ""
+	}
+	var (
+		best  string
+		bestQ float64
+	)
+	qs := make(map[string]float64)
+	for _, v := range strings.Split(header, ",") {
+		name, q := strings.TrimSpace(v), 1.0
+		if i := strings.IndexByte(name, ';'); i >= 0 {
+			param := strings.TrimSpace(name[i+1:])
+			name = strings.TrimSpace(name[:i])
+			if strings.HasPrefix(param, "q=") {
+				var err error
+				if q, err = strconv.ParseFloat(param[2:], 64); err != nil {
+					continue
+				}
+			}
+		}
+		qs[strings.ToLower(name)] = q
+	}
+	for _, algo := range algos {
+		q, ok := qs[algo]
+		if !ok {
+			q, ok = qs["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = algo, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the body until minSize is reached to decide whether
+// it is compressed.
+type compressWriter struct {
+	http.ResponseWriter
+	algo    string
+	minSize int
+	code    int
+	buf     []byte
+	decided bool
+	cw      io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.code = code
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.minSize {
+			return len(data), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.cw != nil {
+		return w.cw.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush writes the buffered data to the client, the response of an undecided
+// compression is compressed as it is streamed.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if f, ok := w.cw.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handlers take over the connection, e.g. websocket.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http: response writer does not implement http.Hijacker")
+	}
+	w.decided = true
+	return h.Hijack()
+}
+
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	if compress && w.compressible() {
+		header.Set("Content-Encoding", w.algo)
+		header.Del("Content-Length")
+		w.cw = compressors[w.algo](w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.cw != nil {
+		_, err = w.cw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	if w.code < http.StatusOK || w.code == http.StatusNoContent || w.code == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buf)
+	}
+	contentType = strings.ToLower(contentType)
+	for _, t := range compressedTypes {
+		if strings.HasPrefix(contentType, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.cw != nil {
+		_ = w.cw.Close()
+	}
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/httputil"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	algos := []string{"gzip", "deflate"}
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"gzip;q=0, deflate;q=0", ""},
+		{"br", ""},
+		{"*", "gzip"},
+		{"identity", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.header, func(t *testing.T) {
+			if got := negotiateEncoding(test.header, algos); got != test.want {
+				t.Fatalf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCompression(t *testing.T) {
+	large := strings.Repeat("kratos", 100)
+	srv := NewServer(Compression(256))
+	route := srv.Route("/")
+	route.GET("/large", func(ctx Context) error {
+		return ctx.Result(200, &User{Name: large})
+	})
+	route.GET("/small", func(ctx Context) error {
+		return ctx.Result(200, &User{Name: "kratos"})
+	})
+	route.GET("/image", func(ctx Context) error {
+		return ctx.Blob(200, "image/png", []byte(large))
+	})
+	route.GET("/error", func(ctx Context) error {
+		return errors.BadRequest("REASON", large)
+	})
+	srv.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		code     int
+		encoding string
+		contains string
+	}{
+		{"large", "/large", 200, "gzip", large},
+		{"small", "/small", 200, "", "kratos"},
+		{"image", "/image", 200, "", large},
+		{"error", "/error", 400, "gzip", "REASON"},
+		{"stream", "/stream", 200, "gzip", "data: 1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+			if w.Code != test.code {
+				t.Fatalf("want code %d, got %d", test.code, w.Code)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != test.encoding {
+				t.Fatalf("want encoding %q, got %q", test.encoding, got)
+			}
+			body := io.Reader(w.Body)
+			if test.encoding == "gzip" {
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = zr
+			}
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), test.contains) {
+				t.Fatalf("want %q in body, got %q", test.contains, data)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/large", nil)
+	req.Header.Set("Accept", httputil.ContentType("json"))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("want no encoding without Accept-Encoding, got %q", got)
+	}
+}
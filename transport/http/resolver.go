@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/internal/endpoint"
@@ -47,6 +48,11 @@ type resolver struct {
 	logger  *log.Helper
 
 	insecure bool
+
+	mu     sync.Mutex
+	timer  *time.Timer
+	gen    uint64
+	closed bool
 }
 
 func newResolver(ctx context.Context, discovery registry.Discovery, target *Target, rebalancer selector.Rebalancer, block, insecure bool) (*resolver, error) {
@@ -112,8 +118,20 @@ func newResolver(ctx context.Context, discovery registry.Discovery, target *Targ
 }
 
 func (r *resolver) update(services []*registry.ServiceInstance) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.apply(services)
+}
+
+func (r *resolver) apply(services []*registry.ServiceInstance) bool {
 	nodes := make([]selector.Node, 0)
+	now := time.Now()
+	r.refilter(services, now)
 	for _, ins := range services {
+		// skip the instances of lapsed heartbeats
+		if !registry.Alive(ins, now) {
+			continue
+		}
 		ept, err := endpoint.ParseEndpoint(ins.Endpoints, "http", !r.insecure)
 		if err != nil {
 			r.logger.Errorf("Failed to parse (%v) discovery endpoint: %v error %v", r.target, ins.Endpoints, err)
@@ -132,6 +150,35 @@ func (r *resolver) update(services []*registry.ServiceInstance) bool {
 	return true
 }
 
+// refilter applies the services again at the earliest lapse of their
+// heartbeats, so that the lapsed instances are dropped without a watch event.
+func (r *resolver) refilter(services []*registry.ServiceInstance, now time.Time) {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.gen++
+	lapse, ok := registry.Lapse(services, now)
+	if !ok || r.closed {
+		return
+	}
+	gen := r.gen
+	r.timer = time.AfterFunc(lapse.Sub(now), func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		// skip if the services were updated or the resolver was closed
+		if gen == r.gen {
+			r.apply(services)
+		}
+	})
+}
+
 func (r *resolver) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	r.gen++
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.mu.Unlock()
 	return r.watcher.Stop()
 }
@@ -113,3 +113,39 @@ func TestResolver(t *testing.T) {
 		t.Errorf("expect %v, got %v", nil, err)
 	}
 }
+
+type recordRebalancer struct {
+	nodes chan []selector.Node
+}
+
+func (m *recordRebalancer) Apply(nodes []selector.Node) {
+	m.nodes <- nodes
+}
+
+func TestResolverLapse(t *testing.T) {
+	now := time.Now()
+	alive := &registry.ServiceInstance{ID: "1", Endpoints: []string{"http://127.0.0.1:9001"}}
+	lapsing := registry.WithHeartbeat(&registry.ServiceInstance{ID: "2", Endpoints: []string{"http://127.0.0.1:9002"}}, now.Add(-time.Second), time.Second)
+	rebalancer := &recordRebalancer{nodes: make(chan []selector.Node, 2)}
+	r := &resolver{
+		rebalancer: rebalancer,
+		target:     &Target{Endpoint: "discovery:///helloworld"},
+		watcher:    &mockWatch{},
+		insecure:   true,
+	}
+	defer r.Close()
+	if !r.update([]*registry.ServiceInstance{alive, lapsing}) {
+		t.Fatal("the services should be applied")
+	}
+	if nodes := <-rebalancer.nodes; len(nodes) != 2 {
+		t.Fatalf("expect 2 nodes, got %d", len(nodes))
+	}
+	select {
+	case nodes := <-rebalancer.nodes:
+		if len(nodes) != 1 || nodes[0].Address() != "127.0.0.1:9001" {
+			t.Fatalf("expect the alive node, got %v", nodes)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("the lapsed node should be dropped without a watch event")
+	}
+}
@@ -1,11 +1,17 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"path"
 	"sync"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
 )
 
+type routeMiddlewareKey struct{}
+
 // HandlerFunc defines a function to serve HTTP requests.
 type HandlerFunc func(Context) error
 
@@ -29,6 +35,41 @@ func newRouter(prefix string, srv *Server, filters ...FilterFunc) *Router {
 	return r
 }
 
+// WithRouteMiddleware returns a filter attaching the middleware to the route,
+// it is chained inside the server middleware by Context.Middleware.
+func WithRouteMiddleware(m ...middleware.Middleware) FilterFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ms, _ := req.Context().Value(routeMiddlewareKey{}).([]middleware.Middleware)
+			ms = append(ms[:len(ms):len(ms)], m...)
+			next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), routeMiddlewareKey{}, ms)))
+		})
+	}
+}
+
+// routeMiddleware returns the server middleware followed by the route middleware.
+func routeMiddleware(srv *Server, req *http.Request) []middleware.Middleware {
+	ms, ok := req.Context().Value(routeMiddlewareKey{}).([]middleware.Middleware)
+	if !ok {
+		return srv.ms
+	}
+	return append(srv.ms[:len(srv.ms):len(srv.ms)], ms...)
+}
+
+// notFoundHandler replies the error through the server middleware, so that
+// unmatched requests are still seen by them, e.g. logging.
+func notFoundHandler(srv *Server, code int, reason, message string) http.Handler {
+	err := errors.New(code, reason, message)
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		h := middleware.Chain(srv.ms...)(func(context.Context, interface{}) (interface{}, error) {
+			return nil, err
+		})
+		if _, err := h(req.Context(), req); err != nil {
+			srv.ene(res, req, err)
+		}
+	})
+}
+
 // Group returns a new router group.
 func (r *Router) Group(prefix string, filters ...FilterFunc) *Router {
 	var newFilters []FilterFunc
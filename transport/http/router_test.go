@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/internal/host"
+	"github.com/go-kratos/kratos/v2/middleware"
 )
 
 const appJSONStr = "application/json"
@@ -192,3 +194,55 @@ func TestHandle(t *testing.T) {
 	r.OPTIONS("/options", h)
 	r.TRACE("/trace", h)
 }
+
+func TestRouteMiddleware(t *testing.T) {
+	var calls []string
+	record := func(name string) middleware.Middleware {
+		return func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls = append(calls, name)
+				return handler(ctx, req)
+			}
+		}
+	}
+	srv := NewServer(Middleware(record("global")))
+	route := srv.Route("/v1", WithRouteMiddleware(record("group")))
+	route.GET("/admin", func(ctx Context) error {
+		h := ctx.Middleware(func(ctx context.Context, in interface{}) (interface{}, error) {
+			calls = append(calls, "handler")
+			return &User{Name: "admin"}, nil
+		})
+		return ctx.Returns(h(ctx, nil))
+	}, WithRouteMiddleware(record("route")))
+	route.GET("/users", func(ctx Context) error {
+		h := ctx.Middleware(func(ctx context.Context, in interface{}) (interface{}, error) {
+			calls = append(calls, "handler")
+			return &User{Name: "user"}, nil
+		})
+		return ctx.Returns(h(ctx, nil))
+	})
+
+	tests := []struct {
+		name  string
+		path  string
+		code  int
+		calls []string
+	}{
+		{"route", "/v1/admin", http.StatusOK, []string{"global", "group", "route", "handler"}},
+		{"group", "/v1/users", http.StatusOK, []string{"global", "group", "handler"}},
+		{"not found", "/v1/missing", http.StatusNotFound, []string{"global"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls = nil
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+			if w.Code != test.code {
+				t.Fatalf("want code %d, got %d", test.code, w.Code)
+			}
+			if !reflect.DeepEqual(calls, test.calls) {
+				t.Fatalf("want calls %v, got %v", test.calls, calls)
+			}
+		})
+	}
+}
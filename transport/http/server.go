@@ -7,6 +7,10 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/internal/endpoint"
@@ -19,9 +23,21 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// retryAfter is the seconds of the Retry-After header of the requests
+// rejected while the server is draining.
+const retryAfter = 1
+
+// the states of the server stored in Server.draining.
+const (
+	stateServing int32 = iota
+	stateDraining
+	stateStopping
+)
+
 var (
 	_ transport.Server     = (*Server)(nil)
 	_ transport.Endpointer = (*Server)(nil)
+	_ transport.Drainer    = (*Server)(nil)
 )
 
 // ServerOption is an HTTP server option.
@@ -48,6 +64,24 @@ func Timeout(timeout time.Duration) ServerOption {
 	}
 }
 
+// ShutdownTimeout with the max duration of the shutdown draining in-flight
+// requests, the server is force closed once it is exceeded, zero waits until
+// the stop context is done.
+func ShutdownTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// MaxMultipartBodySize with the max bytes of the multipart request bodies,
+// the larger ones fail to be decoded. Zero disables the limit. The parts are
+// stored in memory up to 32 MiB, the rest in temporary files.
+func MaxMultipartBodySize(size int64) ServerOption {
+	return func(s *Server) {
+		s.maxMultipartBodySize = size
+	}
+}
+
 // Logger with server logger.
 func Logger(logger log.Logger) ServerOption {
 	return func(s *Server) {
@@ -115,6 +149,9 @@ func Listener(lis net.Listener) ServerOption {
 
 // Server is an HTTP server wrapper.
 type Server struct {
+	// inflight must be the first field to be 64-bit aligned.
+	inflight int64
+	draining int32
 	*http.Server
 	lis         net.Listener
 	tlsConf     *tls.Config
@@ -131,6 +168,14 @@ type Server struct {
 	strictSlash bool
 	router      *mux.Router
 	log         *log.Helper
+	// shutdownTimeout is the max duration of Shutdown.
+	shutdownTimeout time.Duration
+	mu              sync.Mutex
+	baseCancel      context.CancelFunc
+	compressMinSize int
+	compressAlgos   []string
+	// maxMultipartBodySize is the max bytes of the multipart request bodies.
+	maxMultipartBodySize int64
 }
 
 // NewServer creates an HTTP server by options.
@@ -150,8 +195,10 @@ func NewServer(opts ...ServerOption) *Server {
 	}
 	srv.router = mux.NewRouter().StrictSlash(srv.strictSlash)
 	srv.router.Use(srv.filter())
+	srv.router.NotFoundHandler = srv.filter()(notFoundHandler(srv, http.StatusNotFound, "NOT_FOUND", "404 page not found"))
+	srv.router.MethodNotAllowedHandler = srv.filter()(notFoundHandler(srv, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "405 method not allowed"))
 	srv.Server = &http.Server{
-		Handler:   FilterChain(srv.filters...)(srv.router),
+		Handler:   srv.drain(srv.compress(FilterChain(srv.filters...)(srv.router))),
 		TLSConfig: srv.tlsConf,
 	}
 	srv.err = srv.listenAndEndpoint()
@@ -188,6 +235,22 @@ func (s *Server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	s.Handler.ServeHTTP(res, req)
 }
 
+// drain tracks the in-flight requests, and rejects the new ones with 503
+// once the server is drained or stopping.
+func (s *Server) drain(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&s.draining) != stateServing {
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		atomic.AddInt64(&s.inflight, 1)
+		defer atomic.AddInt64(&s.inflight, -1)
+		next.ServeHTTP(w, req)
+	})
+}
+
 func (s *Server) filter() mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -202,6 +265,10 @@ func (s *Server) filter() mux.MiddlewareFunc {
 			}
 			defer cancel()
 
+			if s.maxMultipartBodySize > 0 && strings.HasPrefix(strings.ToLower(req.Header.Get("Content-Type")), "multipart/") {
+				req.Body = http.MaxBytesReader(w, req.Body, s.maxMultipartBodySize)
+			}
+
 			pathTemplate := req.URL.Path
 			if route := mux.CurrentRoute(req); route != nil {
 				// /path/123 -> /path/{id}
@@ -238,6 +305,10 @@ func (s *Server) Start(ctx context.Context) error {
 	if s.err != nil {
 		return s.err
 	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.baseCancel = cancel
+	s.mu.Unlock()
 	s.BaseContext = func(net.Listener) context.Context {
 		return ctx
 	}
@@ -254,10 +325,47 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop stop the HTTP server.
+// Drain rejects the new requests with 503 while the in-flight ones are
+// served, so that the load balancers and the health checks take the server
+// out of traffic, see kratos.App.Drain.
+func (s *Server) Drain() {
+	atomic.CompareAndSwapInt32(&s.draining, stateServing, stateDraining)
+}
+
+// Resume serves the new requests again after Drain, it does nothing once the
+// server is stopping.
+func (s *Server) Resume() {
+	atomic.CompareAndSwapInt32(&s.draining, stateDraining, stateServing)
+}
+
+// Stop stop the HTTP server gracefully, the new requests are rejected with 503
+// while the in-flight ones are drained. If they are not done within the
+// shutdown timeout or before ctx is done, the contexts of the requests are
+// canceled, so that long-poll and hijacked handlers can return, and the server
+// is force closed.
 func (s *Server) Stop(ctx context.Context) error {
+	atomic.StoreInt32(&s.draining, stateStopping)
 	s.log.Info("[HTTP] server stopping")
-	return s.Shutdown(ctx)
+	if s.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.shutdownTimeout)
+		defer cancel()
+	}
+	err := s.Shutdown(ctx)
+	s.mu.Lock()
+	if s.baseCancel != nil {
+		s.baseCancel()
+	}
+	s.mu.Unlock()
+	if err == nil {
+		return nil
+	}
+	n := atomic.LoadInt64(&s.inflight)
+	if err = s.Close(); err != nil {
+		return err
+	}
+	s.log.Warnf("[HTTP] server shutdown timeout, force closed %d in-flight requests", n)
+	return nil
 }
 
 func (s *Server) listenAndEndpoint() error {
@@ -1,13 +1,16 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
@@ -315,3 +318,114 @@ func TestListener(t *testing.T) {
 		t.Errorf("expected %v got %v", lis, s.lis)
 	}
 }
+
+func TestShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	returned := make(chan error, 1)
+	srv := NewServer(Timeout(0), ShutdownTimeout(100*time.Millisecond))
+	srv.HandleFunc("/poll", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		returned <- r.Context().Err()
+	})
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	time.Sleep(100 * time.Millisecond)
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		resp, err := http.Get(e.String() + "/poll")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	begin := time.Now()
+	if err = srv.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(begin); d > time.Second {
+		t.Fatalf("stop took %v", d)
+	}
+	select {
+	case err = <-returned:
+		if err != context.Canceled {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the in-flight handler did not return")
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/poll", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("want 503, got %d", w.Code)
+	}
+	if v := w.Header().Get("Retry-After"); v != "1" {
+		t.Fatalf("want Retry-After 1, got %q", v)
+	}
+}
+
+func TestMaxMultipartBodySize(t *testing.T) {
+	srv := NewServer(MaxMultipartBodySize(1024))
+	srv.Route("/").POST("/upload", func(ctx Context) error {
+		in := new(testData)
+		if err := ctx.Bind(in); err != nil {
+			return err
+		}
+		return ctx.Result(200, in)
+	})
+	tests := []struct {
+		name string
+		size int
+		code int
+	}{
+		{"small", 16, 200},
+		{"large", 4096, 400},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := new(bytes.Buffer)
+			mw := multipart.NewWriter(body)
+			_ = mw.WriteField("path", strings.Repeat("a", test.size))
+			_ = mw.Close()
+			req := httptest.NewRequest(http.MethodPost, "/upload", body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+			if w.Code != test.code {
+				t.Fatalf("want %d, got %d: %s", test.code, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestServer_DrainResume(t *testing.T) {
+	srv := NewServer()
+	srv.HandleFunc("/ping", func(w http.ResponseWriter, req *http.Request) {})
+	serve := func() int {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+		return w.Code
+	}
+	srv.Drain()
+	if code := serve(); code != http.StatusServiceUnavailable {
+		t.Fatalf("want 503 once drained, got %d", code)
+	}
+	srv.Resume()
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("want 200 once resumed, got %d", code)
+	}
+	if err := srv.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// resuming does not serve the stopping server again
+	srv.Resume()
+	if code := serve(); code != http.StatusServiceUnavailable {
+		t.Fatalf("want 503 once stopping, got %d", code)
+	}
+}
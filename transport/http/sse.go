@@ -0,0 +1,180 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSSEHeartbeat is the interval of the keepalive comments.
+const defaultSSEHeartbeat = 15 * time.Second
+
+// ErrStreamingUnsupported is returned if the response writer can not be flushed.
+var ErrStreamingUnsupported = errors.New("http: streaming unsupported")
+
+var errSSEWriterClosed = errors.New("http: SSE writer closed")
+
+// SSEEvent is a server-sent event.
+type SSEEvent struct {
+	// ID is the event id, it is sent back by the client as Last-Event-ID on reconnecting.
+	ID string
+	// Event is the event type, "message" by the client if empty.
+	Event string
+	// Data is the event data, every line of it is sent in a data field.
+	Data string
+	// Retry is the reconnection time of the client.
+	Retry time.Duration
+}
+
+// SSEStream is a reply streaming server-sent events, it is written by
+// DefaultResponseEncoder, so handlers can return it as the reply:
+//
+//	return ctx.Returns(http.SSEStream(func(ctx context.Context, w *http.SSEWriter) error {
+//		return w.Send(http.SSEEvent{Data: "progress"})
+//	}), nil)
+//
+// The stream is bounded by the server Timeout as the other requests.
+type SSEStream func(ctx context.Context, w *SSEWriter) error
+
+// SSEOption is SSE writer option.
+type SSEOption func(*SSEWriter)
+
+// SSEHeartbeat with the interval of the keepalive comments, zero disables them.
+func SSEHeartbeat(d time.Duration) SSEOption {
+	return func(w *SSEWriter) {
+		w.heartbeat = d
+	}
+}
+
+// SSEWriter writes server-sent events to the response and flushes them one by
+// one. It is safe for concurrent use.
+type SSEWriter struct {
+	ctx       context.Context
+	heartbeat time.Duration
+
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	f      http.Flusher
+	once   sync.Once
+	closed chan struct{}
+}
+
+// NewSSEWriter new an SSE writer writing the headers of the event stream, the
+// writes fail once ctx is done, e.g. the client is disconnected.
+func NewSSEWriter(ctx context.Context, w http.ResponseWriter, opts ...SSEOption) (*SSEWriter, error) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrStreamingUnsupported
+	}
+	sw := &SSEWriter{
+		ctx:       ctx,
+		heartbeat: defaultSSEHeartbeat,
+		w:         w,
+		f:         f,
+		closed:    make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(sw)
+	}
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	header.Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+	if sw.heartbeat > 0 {
+		go sw.keepalive()
+	}
+	return sw, nil
+}
+
+// Send writes the event and flushes it.
+func (w *SSEWriter) Send(e SSEEvent) error {
+	var b strings.Builder
+	if e.ID != "" {
+		writeSSEField(&b, "id", e.ID)
+	}
+	if e.Event != "" {
+		writeSSEField(&b, "event", e.Event)
+	}
+	if e.Retry > 0 {
+		writeSSEField(&b, "retry", strconv.FormatInt(e.Retry.Milliseconds(), 10))
+	}
+	for _, line := range strings.Split(e.Data, "\n") {
+		writeSSEField(&b, "data", line)
+	}
+	b.WriteByte('\n')
+	return w.write(b.String())
+}
+
+// Comment writes a comment ignored by the client and flushes it.
+func (w *SSEWriter) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(": ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return w.write(b.String())
+}
+
+// Close stops the keepalive comments, it waits for the write in progress so
+// that the response is not written after it returns, the response is finished
+// by returning from the handler.
+func (w *SSEWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.once.Do(func() {
+		close(w.closed)
+	})
+	return nil
+}
+
+func (w *SSEWriter) write(s string) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-w.closed:
+		return errSSEWriterClosed
+	default:
+	}
+	if _, err := w.w.Write([]byte(s)); err != nil {
+		return err
+	}
+	w.f.Flush()
+	return nil
+}
+
+func (w *SSEWriter) keepalive() {
+	ticker := time.NewTicker(w.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-w.closed:
+			return
+		case <-ticker.C:
+			if err := w.Comment("keepalive"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeSSEField(b *strings.Builder, name, value string) {
+	b.WriteString(name)
+	b.WriteString(": ")
+	b.WriteString(strings.TrimSuffix(value, "\r"))
+	b.WriteByte('\n')
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	sw, err := NewSSEWriter(context.Background(), w, SSEHeartbeat(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sw.Send(SSEEvent{ID: "1", Event: "progress", Data: "a\nb", Retry: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sw.Send(SSEEvent{Data: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = sw.Close()
+	want := "id: 1\nevent: progress\nretry: 1000\ndata: a\ndata: b\n\ndata: c\n\n"
+	if got := w.Body.String(); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("want text/event-stream, got %q", got)
+	}
+	if !w.Flushed {
+		t.Fatal("events should be flushed")
+	}
+	if err = sw.Send(SSEEvent{Data: "d"}); err == nil {
+		t.Fatal("send after close should fail")
+	}
+}
+
+func TestSSEWriterHeartbeat(t *testing.T) {
+	w := httptest.NewRecorder()
+	sw, err := NewSSEWriter(context.Background(), w, SSEHeartbeat(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	_ = sw.Close()
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if !strings.Contains(w.Body.String(), ": keepalive\n\n") {
+		t.Fatalf("want keepalive comments, got %q", w.Body.String())
+	}
+}
+
+// blockingWriter blocks the writes until release is closed.
+type blockingWriter struct {
+	*httptest.ResponseRecorder
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	select {
+	case w.writing <- struct{}{}:
+	default:
+	}
+	<-w.release
+	return w.ResponseRecorder.Write(b)
+}
+
+func TestSSEWriterCloseWaitsWrite(t *testing.T) {
+	w := &blockingWriter{ResponseRecorder: httptest.NewRecorder(), writing: make(chan struct{}, 1), release: make(chan struct{})}
+	sw, err := NewSSEWriter(context.Background(), w, SSEHeartbeat(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the keepalive is writing
+	<-w.writing
+	closed := make(chan struct{})
+	go func() {
+		_ = sw.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("want Close to wait for the write in progress")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(w.release)
+	<-closed
+	if err = sw.Comment("after close"); err == nil {
+		t.Fatal("want the writes after close failed")
+	}
+}
+
+func TestSSEWriterDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sw, err := NewSSEWriter(ctx, httptest.NewRecorder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sw.Close()
+	cancel()
+	if err = sw.Send(SSEEvent{Data: "a"}); err != context.Canceled {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+}
+
+func TestSSEStream(t *testing.T) {
+	srv := NewServer()
+	srv.Route("/").GET("/events", func(ctx Context) error {
+		return ctx.Returns(SSEStream(func(ctx context.Context, w *SSEWriter) error {
+			for _, data := range []string{"1", "2"} {
+				if err := w.Send(SSEEvent{Data: data}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}), nil)
+	})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	if got := w.Body.String(); got != "data: 1\n\ndata: 2\n\n" {
+		t.Fatalf("unexpected events %q", got)
+	}
+}
@@ -41,14 +41,30 @@ func (c statusConverter) ToGRPCCode(code int) codes.Code {
 		return codes.PermissionDenied
 	case http.StatusNotFound:
 		return codes.NotFound
+	case http.StatusMethodNotAllowed:
+		return codes.Unimplemented
+	case http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
 	case http.StatusConflict:
 		return codes.Aborted
+	case http.StatusGone:
+		return codes.NotFound
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge:
+		return codes.ResourceExhausted
+	case http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
 	case http.StatusTooManyRequests:
 		return codes.ResourceExhausted
 	case http.StatusInternalServerError:
 		return codes.Internal
 	case http.StatusNotImplemented:
 		return codes.Unimplemented
+	case http.StatusBadGateway:
+		return codes.Unavailable
 	case http.StatusServiceUnavailable:
 		return codes.Unavailable
 	case http.StatusGatewayTimeout:
@@ -18,10 +18,18 @@ func TestToGRPCCode(t *testing.T) {
 		{"http.StatusUnauthorized", http.StatusUnauthorized, codes.Unauthenticated},
 		{"http.StatusForbidden", http.StatusForbidden, codes.PermissionDenied},
 		{"http.StatusNotFound", http.StatusNotFound, codes.NotFound},
+		{"http.StatusMethodNotAllowed", http.StatusMethodNotAllowed, codes.Unimplemented},
+		{"http.StatusRequestTimeout", http.StatusRequestTimeout, codes.DeadlineExceeded},
 		{"http.StatusConflict", http.StatusConflict, codes.Aborted},
+		{"http.StatusGone", http.StatusGone, codes.NotFound},
+		{"http.StatusPreconditionFailed", http.StatusPreconditionFailed, codes.FailedPrecondition},
+		{"http.StatusRequestEntityTooLarge", http.StatusRequestEntityTooLarge, codes.ResourceExhausted},
+		{"http.StatusUnsupportedMediaType", http.StatusUnsupportedMediaType, codes.InvalidArgument},
+		{"http.StatusUnprocessableEntity", http.StatusUnprocessableEntity, codes.InvalidArgument},
 		{"http.StatusTooManyRequests", http.StatusTooManyRequests, codes.ResourceExhausted},
 		{"http.StatusInternalServerError", http.StatusInternalServerError, codes.Internal},
 		{"http.StatusNotImplemented", http.StatusNotImplemented, codes.Unimplemented},
+		{"http.StatusBadGateway", http.StatusBadGateway, codes.Unavailable},
 		{"http.StatusServiceUnavailable", http.StatusServiceUnavailable, codes.Unavailable},
 		{"http.StatusGatewayTimeout", http.StatusGatewayTimeout, codes.DeadlineExceeded},
 		{"StatusClientClosed", ClientClosed, codes.Canceled},
@@ -56,6 +56,22 @@ func (tr *Transport) ReplyHeader() transport.Header {
 	return tr.replyHeader
 }
 
+// Clone returns a copy of the transport with a copy of the request and its
+// header, e.g. for the hedged attempts running in parallel.
+func (tr *Transport) Clone() transport.Transporter {
+	c := *tr
+	if tr.request != nil {
+		c.request = tr.request.Clone(tr.request.Context())
+		c.reqHeader = headerCarrier(c.request.Header)
+	} else {
+		c.reqHeader = headerCarrier(http.Header(tr.reqHeader).Clone())
+	}
+	if tr.replyHeader != nil {
+		c.replyHeader = headerCarrier(http.Header(tr.replyHeader).Clone())
+	}
+	return &c
+}
+
 // PathTemplate returns the http path template.
 func (tr *Transport) PathTemplate() string {
 	return tr.pathTemplate
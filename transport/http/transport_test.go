@@ -92,3 +92,17 @@ func TestSetOperation(t *testing.T) {
 		t.Errorf("expect %v, got %v", "kratos", tr.operation)
 	}
 }
+
+func TestTransport_Clone(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1/test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("a", "1")
+	o := &Transport{operation: "/test", request: req, reqHeader: headerCarrier(req.Header)}
+	c := o.Clone().(*Transport)
+	c.RequestHeader().Set("a", "2")
+	if req.Header.Get("a") != "1" || c.Request().Header.Get("a") != "2" || c.Request() == req || c.Operation() != "/test" {
+		t.Errorf("expect the request and its header copied, got %v and %v", req.Header, c.Request().Header)
+	}
+}
@@ -0,0 +1,147 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultReloadInterval is the default min interval between the checks of the
+// certificate files.
+const defaultReloadInterval = 10 * time.Second
+
+// TLSOption is TLS config option.
+type TLSOption func(*tlsOptions)
+
+type tlsOptions struct {
+	requireClientCert bool
+	reloadInterval    time.Duration
+}
+
+// WithRequireClientCert with the client certificates required and verified
+// against the CA pool, i.e. mTLS.
+func WithRequireClientCert() TLSOption {
+	return func(o *tlsOptions) {
+		o.requireClientCert = true
+	}
+}
+
+// WithReloadInterval with the min interval between the checks of the
+// modification of the certificate files, default is 10 seconds.
+func WithReloadInterval(d time.Duration) TLSOption {
+	return func(o *tlsOptions) {
+		o.reloadInterval = d
+	}
+}
+
+// LoadTLS returns a TLS config of the certificate and the key files and the CA
+// file for both the servers and the clients, e.g. passed to TLSConfig of the
+// HTTP and gRPC servers or to WithTLSConfig of the clients.
+//
+// The certificate is reloaded on the handshakes after its files are modified,
+// so that it is rotated without restart, and the current one is kept if the new
+// one fails to load. The CA file is the pool verifying the servers and the
+// client certificates, it is loaded once. Any of the files may be empty, e.g.
+// the certificate of a client without mTLS or the CA of a server without it.
+func LoadTLS(certFile, keyFile, caFile string, opts ...TLSOption) (*tls.Config, error) {
+	o := &tlsOptions{reloadInterval: defaultReloadInterval}
+	for _, opt := range opts {
+		opt(o)
+	}
+	c := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" || keyFile != "" {
+		kp := &keyPair{certFile: certFile, keyFile: keyFile, interval: o.reloadInterval}
+		if err := kp.load(); err != nil {
+			return nil, err
+		}
+		c.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return kp.get(), nil
+		}
+		c.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return kp.get(), nil
+		}
+	}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("transport: read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("transport: no certificates in CA file %s", caFile)
+		}
+		c.RootCAs = pool
+		c.ClientCAs = pool
+		c.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	if o.requireClientCert {
+		if c.ClientCAs == nil {
+			return nil, errors.New("transport: the client certificates require a CA file")
+		}
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return c, nil
+}
+
+// keyPair is the certificate of the key pair files, reloaded when they are
+// modified.
+type keyPair struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func (p *keyPair) load() error {
+	modTime, err := p.modified()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(p.certFile, p.keyFile)
+	if err != nil {
+		return fmt.Errorf("transport: load key pair: %w", err)
+	}
+	p.cert = &cert
+	p.modTime = modTime
+	p.checked = time.Now()
+	return nil
+}
+
+// get returns the certificate, reloading it if the files are modified since
+// it is loaded and the reload interval has elapsed since the last check.
+func (p *keyPair) get() *tls.Certificate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now := time.Now(); now.Sub(p.checked) >= p.interval {
+		p.checked = now
+		if modTime, err := p.modified(); err == nil && !modTime.Equal(p.modTime) {
+			// the current certificate is kept until the new one loads,
+			// e.g. when the files are partially written
+			_ = p.load()
+		}
+	}
+	return p.cert
+}
+
+// modified returns the latest modification time of the files.
+func (p *keyPair) modified() (time.Time, error) {
+	var modTime time.Time
+	for _, name := range []string{p.certFile, p.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("transport: stat key pair: %w", err)
+		}
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+	}
+	return modTime, nil
+}
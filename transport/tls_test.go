@@ -0,0 +1,209 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, dir string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kratos ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", der)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue writes the certificate and the key files of name signed by the CA.
+func (ca *testCA) issue(t *testing.T, dir, name string, serial int64) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, name+".pem")
+	keyFile = filepath.Join(dir, name+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, name, typ string, der []byte) {
+	if err := os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// handshake returns the serial number of the server certificate seen by the
+// client, or the error of the handshake.
+func handshake(server, client *tls.Config) (*big.Int, error) {
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	if err != nil {
+		return nil, err
+	}
+	defer lis.Close()
+	errc := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer conn.Close()
+		errc <- conn.(*tls.Conn).Handshake()
+	}()
+	client = client.Clone()
+	client.ServerName = "localhost"
+	conn, err := tls.Dial("tcp", lis.Addr().String(), client)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// the server verifies the client certificate after the client handshake
+	if err = <-errc; err != nil {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber, nil
+}
+
+func TestLoadTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	serverCert, serverKey := ca.issue(t, dir, "server", 2)
+	clientCert, clientKey := ca.issue(t, dir, "client", 3)
+
+	server, err := LoadTLS(serverCert, serverKey, caFile, WithRequireClientCert())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := LoadTLS(clientCert, clientKey, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = handshake(server, client); err != nil {
+		t.Fatalf("want the mTLS handshake, got %v", err)
+	}
+	anonymous, err := LoadTLS("", "", caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = handshake(server, anonymous); err == nil {
+		t.Fatal("want the client without certificate rejected")
+	}
+}
+
+func TestLoadTLS_Reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile, keyFile := ca.issue(t, dir, "server", 2)
+
+	server, err := LoadTLS(certFile, keyFile, "", WithReloadInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := LoadTLS("", "", caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := handshake(server, client)
+	if err != nil || serial.Int64() != 2 {
+		t.Fatalf("want the serial 2, got %v, %v", serial, err)
+	}
+	ca.issue(t, dir, "server", 4)
+	// the modification time changes even on the coarse file systems
+	later := time.Now().Add(time.Second)
+	for _, name := range []string{certFile, keyFile} {
+		if err = os.Chtimes(name, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	serial, err = handshake(server, client)
+	if err != nil || serial.Int64() != 4 {
+		t.Fatalf("want the reloaded serial 4, got %v, %v", serial, err)
+	}
+	// an invalid key pair keeps the current certificate
+	if err = os.WriteFile(keyFile, []byte("invalid"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later = later.Add(time.Second)
+	if err = os.Chtimes(keyFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	serial, err = handshake(server, client)
+	if err != nil || serial.Int64() != 4 {
+		t.Fatalf("want the current serial 4, got %v, %v", serial, err)
+	}
+}
+
+func TestLoadTLS_Error(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "server", 2)
+	tests := []struct {
+		name              string
+		cert, key, caFile string
+		opts              []TLSOption
+	}{
+		{"missing key pair", filepath.Join(dir, "none.pem"), keyFile, "", nil},
+		{"missing ca", certFile, keyFile, filepath.Join(dir, "none.pem"), nil},
+		{"invalid ca", certFile, keyFile, keyFile, nil},
+		{"client cert without ca", certFile, keyFile, "", []TLSOption{WithRequireClientCert()}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := LoadTLS(test.cert, test.key, test.caFile, test.opts...); err == nil {
+				t.Fatal("want an error")
+			}
+		})
+	}
+}
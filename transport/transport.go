@@ -18,6 +18,14 @@ type Server interface {
 	Stop(context.Context) error
 }
 
+// Drainer is the server which can be taken out of traffic without stopping,
+// e.g. the gRPC server reports not serving to the health checks and the HTTP
+// server rejects the new requests with 503, see kratos.App.Drain.
+type Drainer interface {
+	Drain()
+	Resume()
+}
+
 // Endpointer is registry endpoint.
 type Endpointer interface {
 	Endpoint() (*url.URL, error)
@@ -54,6 +62,16 @@ type Transporter interface {
 	ReplyHeader() Header
 }
 
+// Streamer is implemented by the transports of the streaming requests, e.g.
+// the gRPC transport, whose streams are not handled by the middleware but by
+// the stream interceptors.
+type Streamer interface {
+	// IsClientStream reports whether the client sends a stream of messages.
+	IsClientStream() bool
+	// IsServerStream reports whether the server sends a stream of messages.
+	IsServerStream() bool
+}
+
 // Kind defines the type of Transport
 type Kind string
 